@@ -42,6 +42,9 @@ func (cs *ConsensusState) readReplayMessage(msg *TimedWALMessage, newStepCh chan
 	if _, ok := msg.Msg.(EndHeightMessage); ok {
 		return nil
 	}
+	if _, ok := msg.Msg.(SignerStateMessage); ok {
+		return nil
+	}
 
 	// for logging
 	switch m := msg.Msg.(type) {
@@ -0,0 +1,53 @@
+package consensus
+
+import (
+	cstypes "github.com/tendermint/tendermint/consensus/types"
+	privval "github.com/tendermint/tendermint/types/priv_validator"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// FromRoundStep returns the step of the privval.LastSignedInfo
+// for the consensus step, ie. the last step signed by then:
+//
+//	NewHeight, NewRound    -> none
+//	Propose                -> propose
+//	Prevote, PrevoteWait   -> prevote
+//	Precommit, PrecommitWait,
+//	Commit                 -> precommit
+//
+// It panics on an unknown step.
+func FromRoundStep(rs cstypes.RoundStepType) int8 {
+	switch rs {
+	case cstypes.RoundStepNewHeight, cstypes.RoundStepNewRound:
+		return privval.StepNone
+	case cstypes.RoundStepPropose:
+		return privval.StepPropose
+	case cstypes.RoundStepPrevote, cstypes.RoundStepPrevoteWait:
+		return privval.StepPrevote
+	case cstypes.RoundStepPrecommit, cstypes.RoundStepPrecommitWait, cstypes.RoundStepCommit:
+		return privval.StepPrecommit
+	default:
+		cmn.PanicSanity(cmn.Fmt("Unknown round step %v", rs))
+		return privval.StepNone
+	}
+}
+
+// ToRoundStep returns the consensus step in which the step of the
+// privval.LastSignedInfo is signed, or RoundStepNewHeight for none.
+// FromRoundStep(ToRoundStep(step)) == step.
+// It panics on an unknown step.
+func ToRoundStep(step int8) cstypes.RoundStepType {
+	switch step {
+	case privval.StepNone:
+		return cstypes.RoundStepNewHeight
+	case privval.StepPropose:
+		return cstypes.RoundStepPropose
+	case privval.StepPrevote:
+		return cstypes.RoundStepPrevote
+	case privval.StepPrecommit:
+		return cstypes.RoundStepPrecommit
+	default:
+		cmn.PanicSanity(cmn.Fmt("Unknown step %v", step))
+		return cstypes.RoundStepNewHeight
+	}
+}
@@ -0,0 +1,52 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cstypes "github.com/tendermint/tendermint/consensus/types"
+	privval "github.com/tendermint/tendermint/types/priv_validator"
+)
+
+func TestRoundStepMapping(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		rs     cstypes.RoundStepType
+		step   int8
+		backTo cstypes.RoundStepType
+	}{
+		{cstypes.RoundStepNewHeight, privval.StepNone, cstypes.RoundStepNewHeight},
+		{cstypes.RoundStepNewRound, privval.StepNone, cstypes.RoundStepNewHeight},
+		{cstypes.RoundStepPropose, privval.StepPropose, cstypes.RoundStepPropose},
+		{cstypes.RoundStepPrevote, privval.StepPrevote, cstypes.RoundStepPrevote},
+		{cstypes.RoundStepPrevoteWait, privval.StepPrevote, cstypes.RoundStepPrevote},
+		{cstypes.RoundStepPrecommit, privval.StepPrecommit, cstypes.RoundStepPrecommit},
+		{cstypes.RoundStepPrecommitWait, privval.StepPrecommit, cstypes.RoundStepPrecommit},
+		{cstypes.RoundStepCommit, privval.StepPrecommit, cstypes.RoundStepPrecommit},
+	}
+	// every RoundStepType is covered
+	assert.Len(cases, int(cstypes.RoundStepCommit-cstypes.RoundStepNewHeight)+1)
+	for _, c := range cases {
+		assert.Equal(c.step, FromRoundStep(c.rs), "%v", c.rs)
+		assert.Equal(c.backTo, ToRoundStep(c.step), "%v", c.rs)
+		assert.True(ToRoundStep(FromRoundStep(c.rs)) <= c.rs, "%v", c.rs)
+	}
+
+	assert.Panics(func() { FromRoundStep(cstypes.RoundStepType(0)) })
+	assert.Panics(func() { FromRoundStep(cstypes.RoundStepCommit + 1) })
+}
+
+func TestRoundStepRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	for step := privval.StepNone; step <= privval.StepPrecommit; step++ {
+		assert.Equal(step, FromRoundStep(ToRoundStep(step)), "step %v", step)
+	}
+	for rs := cstypes.RoundStepNewHeight; rs <= cstypes.RoundStepCommit; rs++ {
+		step := FromRoundStep(rs)
+		assert.Equal(step, FromRoundStep(ToRoundStep(step)), "%v", rs)
+	}
+
+	assert.Panics(func() { ToRoundStep(privval.StepPrecommit + 1) })
+}
@@ -0,0 +1,63 @@
+package consensus
+
+import (
+	"errors"
+
+	privval "github.com/tendermint/tendermint/types/priv_validator"
+)
+
+// NewSignerWAL returns a SignerWAL that keeps the sign state of the validator
+// in the consensus WAL, as SignerStateMessages, eg. for a
+// privval.WALSignerState.
+func NewSignerWAL(wal WAL) privval.SignerWAL {
+	return &signerWAL{wal}
+}
+
+type signerWAL struct {
+	wal WAL
+}
+
+// Append saves the entry and syncs the head of the WAL.
+func (sw *signerWAL) Append(entry []byte) error {
+	group := sw.wal.Group()
+	if group == nil {
+		return errors.New("WAL has no group")
+	}
+	sw.wal.Save(SignerStateMessage{entry})
+	return group.Head.Sync()
+}
+
+// Entries reads the files of the WAL from the newest. A file is read until
+// a message can't be decoded, eg. a truncated tail.
+func (sw *signerWAL) Entries(fn func(entry []byte) bool) error {
+	group := sw.wal.Group()
+	if group == nil {
+		return nil
+	}
+	min, max := group.MinIndex(), group.MaxIndex()
+	for index := max; index >= min; index-- {
+		gr, err := group.NewReader(index)
+		if err != nil {
+			return err
+		}
+		var entries [][]byte
+		dec := NewWALDecoder(gr)
+		for {
+			msg, err := dec.Decode()
+			if err != nil {
+				break
+			}
+			if m, ok := msg.Msg.(SignerStateMessage); ok {
+				entries = append(entries, m.Data)
+			}
+		}
+		gr.Close()
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !fn(entries[i]) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -36,6 +36,12 @@ type EndHeightMessage struct {
 	Height int64 `json:"height"`
 }
 
+// SignerStateMessage holds an entry of the sign state of the validator,
+// see NewSignerWAL. It's skipped on replay.
+type SignerStateMessage struct {
+	Data []byte `json:"data"`
+}
+
 type WALMessage interface{}
 
 var _ = wire.RegisterInterface(
@@ -44,6 +50,7 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{msgInfo{}, 0x02},
 	wire.ConcreteType{timeoutInfo{}, 0x03},
 	wire.ConcreteType{EndHeightMessage{}, 0x04},
+	wire.ConcreteType{SignerStateMessage{}, 0x05},
 )
 
 //--------------------------------------------------------
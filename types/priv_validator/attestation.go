@@ -0,0 +1,89 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Attestation is a signed statement of the high-water mark of a
+// LastSignedInfo, for HA peers (eg. hot and cold spares run by different
+// organizations) to exchange during failover without trusting the transport.
+// See Attest and VerifyAttestation.
+type Attestation struct {
+	ChainID     string     `json:"chain_id"` // empty if not bound, see Bind
+	Height      int64      `json:"height"`
+	Round       int        `json:"round"`
+	Step        int8       `json:"step"`
+	Seq         uint64     `json:"seq"`
+	Fingerprint data.Bytes `json:"fingerprint"`
+	Time        time.Time  `json:"time"`
+	Signature   Signature  `json:"signature"`
+}
+
+// canonicalJSONOnceAttestation is what an Attestation signs. Its only key is
+// "attestation", so the sign bytes can't be taken for a vote or proposal,
+// which have "chain_id" and "vote" or "proposal".
+type canonicalJSONOnceAttestation struct {
+	Attestation canonicalJSONAttestation `json:"attestation"`
+}
+
+type canonicalJSONAttestation struct {
+	ChainID     string     `json:"chain_id"`
+	Fingerprint data.Bytes `json:"fingerprint"`
+	Height      int64      `json:"height"`
+	Round       int        `json:"round"`
+	Seq         uint64     `json:"seq"`
+	Step        int8       `json:"step"`
+	Time        string     `json:"time"`
+}
+
+// SignBytes returns the bytes the Signature is over: the canonical JSON of
+// all the fields but the Signature, with the time as in votes.
+func (att Attestation) SignBytes() []byte {
+	bz, err := json.Marshal(canonicalJSONOnceAttestation{canonicalJSONAttestation{
+		ChainID:     att.ChainID,
+		Fingerprint: att.Fingerprint,
+		Height:      att.Height,
+		Round:       att.Round,
+		Seq:         att.Seq,
+		Step:        att.Step,
+		Time:        types.CanonicalTime(att.Time),
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+// Attest returns an Attestation of the current height/round/step, Sequence and
+// Fingerprint, signed by signer, the validator's. It doesn't change anything.
+func (info *LastSignedInfo) Attest(signer types.Signer) (Attestation, error) {
+	att := Attestation{
+		ChainID:     info.ChainID,
+		Height:      info.LastHeight,
+		Round:       info.LastRound,
+		Step:        info.LastStep,
+		Seq:         info.Seq,
+		Fingerprint: info.Fingerprint(),
+		Time:        info.now(),
+	}
+	sig, err := signer.Sign(att.SignBytes())
+	if err != nil {
+		return Attestation{}, err
+	}
+	att.Signature = SignatureFromCrypto(sig)
+	return att, nil
+}
+
+// VerifyAttestation returns ErrBadSignature unless att is signed by pub.
+// It's up to the caller to check the ChainID and how old the Time is.
+func VerifyAttestation(att Attestation, pub crypto.PubKey) error {
+	if att.Signature.Empty() || !pub.VerifyBytes(att.SignBytes(), att.Signature.Crypto()) {
+		return ErrBadSignature
+	}
+	return nil
+}
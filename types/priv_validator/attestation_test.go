@@ -0,0 +1,49 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestAttest(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := NewLastSignedInfo()
+	info.SetClock(NewManualClock(now))
+	require.Nil(info.Bind("mychainid"))
+	signer, pub := newTestSigner()
+	_, otherPub := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID1)))
+
+	att, err := info.Attest(signer)
+	require.Nil(err)
+	assert.Equal("mychainid", att.ChainID)
+	assert.EqualValues(10, att.Height)
+	assert.Equal(1, att.Round)
+	assert.Equal(stepPrecommit, att.Step)
+	assert.EqualValues(1, att.Seq)
+	assert.Equal(info.Fingerprint(), []byte(att.Fingerprint))
+	assert.Equal(now, att.Time)
+	assert.Nil(VerifyAttestation(att, pub))
+	assert.Equal(ErrBadSignature, VerifyAttestation(att, otherPub))
+
+	// it survives the transport, but not tampering
+	bz, err := json.Marshal(att)
+	require.Nil(err)
+	var received Attestation
+	require.Nil(json.Unmarshal(bz, &received))
+	assert.Nil(VerifyAttestation(received, pub))
+	received.Height = 11
+	assert.Equal(ErrBadSignature, VerifyAttestation(received, pub))
+	assert.Equal(ErrBadSignature, VerifyAttestation(Attestation{Height: 10}, pub))
+
+	// the sign bytes can't pass for a vote or proposal
+	_, ok := decodeSignBytes(att.SignBytes())
+	assert.False(ok)
+}
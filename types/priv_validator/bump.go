@@ -0,0 +1,39 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/tendermint/tmlibs/log"
+)
+
+// BumpTo advances the high-water mark of the LastSignedInfo at statePath to
+// the given height/round/step, eg. after proving from chain data what the
+// validator actually signed. Since we don't have what was signed there,
+// the signature and sign bytes are cleared, so nothing can be signed at
+// that HRS again. The file is written atomically, and the bump logged
+// to logger, along with what's logged on load, see SignInfoFile.SetLogger.
+//
+// It's a manual intervention: it refuses to lower the mark, or leave it
+// as is, and fails if there's a PendingSign to Recover first.
+func BumpTo(statePath string, height int64, round int, step int8, logger log.Logger) error {
+	info, err := loadLastSignedInfo(statePath, JSONCodec{}, logger)
+	if err != nil {
+		return err
+	}
+	if info.PendingSign != nil {
+		return ErrPendingSign
+	}
+	if err := validateHRS(height, round, step); err != nil {
+		return err
+	}
+	if compareHRS(height, round, step, info.LastHeight, info.LastRound, info.LastStep) <= 0 {
+		return fmt.Errorf("Refusing to bump %v to %v/%v/%v: the target must be ahead", info, height, round, step)
+	}
+
+	logger.Error("Manually bumping the high-water mark of the LastSignedInfo",
+		"file", statePath, "from", info, "height", height, "round", round, "step", step)
+	if _, err := info.EnsureAtLeast(height, round, step); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestBumpTo(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	logger := log.TestingLogger()
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+
+	// refuses to lower or keep the mark
+	for _, hrs := range [][3]int64{{10, 1, 2}, {10, 1, 1}, {10, 0, 3}, {9, 5, 3}} {
+		assert.Error(BumpTo(tempFilePath, hrs[0], int(hrs[1]), int8(hrs[2]), logger), "%v", hrs)
+	}
+	assert.Error(BumpTo(tempFilePath, 20, 0, stepMax+1, logger))
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(int64(10), loaded.LastHeight)
+	assert.Equal([]byte("signbytes"), []byte(loaded.LastSignBytes))
+
+	require.Nil(BumpTo(tempFilePath, 12, 0, stepPropose, logger))
+	loaded, err = LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(int64(12), loaded.LastHeight)
+	assert.Equal(0, loaded.LastRound)
+	assert.Equal(stepPropose, loaded.LastStep)
+	assert.True(loaded.LastSignature.Empty())
+	assert.Nil(loaded.LastSignBytes)
+
+	// the bumped HRS can't be signed
+	_, err = loaded.Verify(12, 0, stepPropose)
+	assert.Error(err)
+
+	// a pending sign must be recovered first
+	loaded.PendingSign = &PendingSign{12, 0, stepPrevote}
+	require.Nil(loaded.Save())
+	assert.Equal(ErrPendingSign, BumpTo(tempFilePath, 13, 0, stepPropose, logger))
+
+	assert.Error(BumpTo(tempFilePath+"_missing", 13, 0, stepPropose, logger))
+}
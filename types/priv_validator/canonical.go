@@ -0,0 +1,72 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNonCanonicalSignBytes = errors.New("Sign bytes are not canonical JSON")
+)
+
+// checkCanonical returns ErrNonCanonicalSignBytes unless signBytes are in the
+// canonical form types.SignBytes produces: compact JSON with the keys of every
+// object sorted, once each, and nothing after the value.
+// types.SignBytes escapes <, > and & in strings (eg. the chain ID), as
+// encoding/json does by default, while other canonical encoders leave them
+// as they are, so either is canonical, as long as it's the same throughout.
+//
+// The signature reuse compares sign bytes byte by byte, which only means
+// something if both sides are canonical: the same vote with reordered keys or
+// extra whitespace would have different bytes, and a signature over those
+// doesn't verify for the canonical ones.
+func checkCanonical(signBytes []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(signBytes))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return ErrNonCanonicalSignBytes
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrNonCanonicalSignBytes
+	}
+	// encoding/json writes maps with sorted keys and no whitespace
+	for _, escapeHTML := range []bool{false, true} {
+		canonical, err := encodeJSON(value, escapeHTML)
+		if err == nil && bytes.Equal(canonical, signBytes) {
+			return nil
+		}
+	}
+	return ErrNonCanonicalSignBytes
+}
+
+// encodeJSON is json.Marshal, escaping <, > and & only if escapeHTML is set.
+func encodeJSON(value interface{}, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	// Encode ends the value with a newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// checkCanonical checks both the LastSignBytes and the new signBytes are
+// canonical, so they can be compared. In hash-only mode, only the new ones are.
+// With a CanonicalEncoder other than JSON, canonical is what it produces.
+func (info *LastSignedInfo) checkCanonical(signBytes []byte) error {
+	check := checkCanonical
+	if encoder, ok := info.customEncoder(); ok {
+		check = func(signBytes []byte) error { return checkEncoded(encoder, signBytes) }
+	}
+	if info.LastSignBytes == nil && info.LastSignBytesHash != nil {
+		return check(signBytes)
+	}
+	if err := check(info.LastSignBytes); err != nil {
+		return err
+	}
+	return check(signBytes)
+}
@@ -0,0 +1,181 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// CanonicalEncoder produces the sign bytes of votes and proposals as the
+// target chain encodes them, and parses them back, eg. amino JSON (see
+// JSONEncoder) or protobuf, depending on the version of the chain.
+// Parse must return an error for sign bytes of the other kind, or that
+// VoteBytes or ProposalBytes wouldn't produce.
+type CanonicalEncoder interface {
+	VoteBytes(chainID string, vote *types.Vote) []byte
+	ProposalBytes(chainID string, proposal *types.Proposal) []byte
+	ParseVote(signBytes []byte) (chainID string, vote *types.Vote, err error)
+	ParseProposal(signBytes []byte) (chainID string, proposal *types.Proposal, err error)
+}
+
+// JSONEncoder is the default CanonicalEncoder, the canonical JSON of
+// types.SignBytes, pinned to version 1 (see canonicalVoteBytesV1).
+type JSONEncoder struct{}
+
+// VoteBytes implements CanonicalEncoder.
+func (JSONEncoder) VoteBytes(chainID string, vote *types.Vote) []byte {
+	return canonicalVoteBytesV1(chainID, vote)
+}
+
+// ProposalBytes implements CanonicalEncoder.
+func (JSONEncoder) ProposalBytes(chainID string, proposal *types.Proposal) []byte {
+	return canonicalProposalBytesV1(chainID, proposal)
+}
+
+// ParseVote implements CanonicalEncoder.
+func (JSONEncoder) ParseVote(signBytes []byte) (string, *types.Vote, error) {
+	return parseCanonicalVote(signBytes)
+}
+
+// ParseProposal implements CanonicalEncoder.
+func (JSONEncoder) ParseProposal(signBytes []byte) (string, *types.Proposal, error) {
+	return parseCanonicalProposal(signBytes)
+}
+
+// SetCanonicalEncoder sets how the sign bytes are encoded, for SignVote,
+// SignProposal and ValidateVote. The sign bytes are compared, to reuse the
+// LastSignature or to detect a conflict, once normalized by the encoder: they
+// must be what it produces, and are re-encoded with the same timestamp.
+// It defaults to JSONEncoder. Sign bytes recorded with another encoder never
+// match, so switching encoders at a height already signed is a conflict.
+func (info *LastSignedInfo) SetCanonicalEncoder(encoder CanonicalEncoder) {
+	info.encoder = encoder
+}
+
+func (info *LastSignedInfo) canonicalEncoder() CanonicalEncoder {
+	if info.encoder == nil {
+		return JSONEncoder{}
+	}
+	return info.encoder
+}
+
+// returns the encoder if it's not the JSONEncoder, whose comparisons also
+// keep the fields types.Vote doesn't have, eg. a nonce
+func (info *LastSignedInfo) customEncoder() (CanonicalEncoder, bool) {
+	encoder := info.canonicalEncoder()
+	if _, ok := encoder.(JSONEncoder); ok {
+		return nil, false
+	}
+	return encoder, true
+}
+
+// comparisons returns how to compare sign bytes of the kind compared by
+// onlyDifferByTimestamp and normalize with the JSONEncoder.
+func (info *LastSignedInfo) comparisons(
+	onlyDifferByTimestamp func(lastSignBytes, newSignBytes []byte, now time.Time) bool,
+	normalize func(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte)) (
+	func(lastSignBytes, newSignBytes []byte, now time.Time) bool,
+	func(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte)) {
+	encoder, ok := info.customEncoder()
+	if !ok {
+		return onlyDifferByTimestamp, normalize
+	}
+	normalizeEncoded := func(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte) {
+		setNow := func(timestamp *time.Time) { *timestamp = now }
+		return reencode(encoder, lastSignBytes, setNow), reencode(encoder, newSignBytes, setNow)
+	}
+	onlyDifferEncoded := func(lastSignBytes, newSignBytes []byte, now time.Time) bool {
+		lastNormalized, newNormalized := normalizeEncoded(lastSignBytes, newSignBytes, now)
+		return lastNormalized != nil && bytes.Equal(lastNormalized, newNormalized)
+	}
+	return onlyDifferEncoded, normalizeEncoded
+}
+
+// reencode parses signBytes as a vote or proposal, and encodes it again once
+// update changed its timestamp. It returns nil if they can't be parsed.
+func reencode(encoder CanonicalEncoder, signBytes []byte, update func(timestamp *time.Time)) []byte {
+	if chainID, vote, err := encoder.ParseVote(signBytes); err == nil {
+		update(&vote.Timestamp)
+		return encoder.VoteBytes(chainID, vote)
+	}
+	if chainID, proposal, err := encoder.ParseProposal(signBytes); err == nil {
+		update(&proposal.Timestamp)
+		return encoder.ProposalBytes(chainID, proposal)
+	}
+	return nil
+}
+
+// checkEncoded is checkCanonical for the encoder: the sign bytes must be
+// exactly what it produces for what they parse to.
+func checkEncoded(encoder CanonicalEncoder, signBytes []byte) error {
+	encoded := reencode(encoder, signBytes, func(*time.Time) {})
+	if encoded == nil || !bytes.Equal(encoded, signBytes) {
+		return ErrNonCanonicalSignBytes
+	}
+	return nil
+}
+
+// signedTimestamp is the package level signedTimestamp, with the encoder.
+func (info *LastSignedInfo) signedTimestamp(signBytes []byte) (time.Time, error) {
+	encoder, ok := info.customEncoder()
+	if !ok {
+		return signedTimestamp(signBytes)
+	}
+	var signed time.Time
+	if reencode(encoder, signBytes, func(timestamp *time.Time) { signed = *timestamp }) == nil {
+		return time.Time{}, errors.New("Cannot parse sign bytes with the canonical encoder")
+	}
+	return signed, nil
+}
+
+// sameSignedData is the package level sameSignedData, with the encoder.
+func (info *LastSignedInfo) sameSignedData(signBytesA, signBytesB []byte) bool {
+	if _, ok := info.customEncoder(); !ok {
+		return sameSignedData(signBytesA, signBytesB, info.now())
+	}
+	if bytes.Equal(signBytesA, signBytesB) {
+		return true
+	}
+	onlyDifferByTimestamp, _ := info.comparisons(nil, nil)
+	return onlyDifferByTimestamp(signBytesA, signBytesB, info.now())
+}
+
+// parseCanonicalProposal parses sign bytes produced by canonicalProposalBytesV1
+func parseCanonicalProposal(signBytes []byte) (string, *types.Proposal, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(signBytes, &fields); err != nil {
+		return "", nil, err
+	}
+	if _, ok := fields["proposal"]; !ok {
+		return "", nil, errors.New("Sign bytes are not a proposal")
+	}
+
+	var canonical canonicalJSONOnceProposalV1
+	if err := json.Unmarshal(signBytes, &canonical); err != nil {
+		return "", nil, err
+	}
+	cp := canonical.Proposal
+	if err := validateBlockHash(cp.POLBlockID.Hash); err != nil {
+		return "", nil, err
+	}
+	timestamp, err := time.Parse(canonicalTimeFormatV1, cp.Timestamp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	proposal := &types.Proposal{
+		Height:    cp.Height,
+		Round:     cp.Round,
+		Timestamp: timestamp,
+		BlockPartsHeader: types.PartSetHeader{
+			Total: cp.BlockPartsHeader.Total,
+			Hash:  []byte(cp.BlockPartsHeader.Hash),
+		},
+		POLRound:   cp.POLRound,
+		POLBlockID: cp.POLBlockID.blockID(),
+	}
+	return canonical.ChainID, proposal, nil
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+// textEncoder is a CanonicalEncoder that isn't JSON, as a protobuf one wouldn't be.
+type textEncoder struct{}
+
+func (textEncoder) VoteBytes(chainID string, vote *types.Vote) []byte {
+	return []byte(fmt.Sprintf("vote/%v/%v/%v/%v/%X/%v", chainID, vote.Height, vote.Round,
+		vote.Type, []byte(vote.BlockID.Hash), vote.Timestamp.UnixNano()))
+}
+
+func (textEncoder) ProposalBytes(chainID string, proposal *types.Proposal) []byte {
+	return []byte(fmt.Sprintf("proposal/%v/%v/%v/%v/%v", chainID, proposal.Height, proposal.Round,
+		proposal.POLRound, proposal.Timestamp.UnixNano()))
+}
+
+func (textEncoder) ParseVote(signBytes []byte) (string, *types.Vote, error) {
+	fields := strings.Split(string(signBytes), "/")
+	if len(fields) != 7 || fields[0] != "vote" {
+		return "", nil, errors.New("not a vote")
+	}
+	height, _ := strconv.ParseInt(fields[2], 10, 64)
+	round, _ := strconv.Atoi(fields[3])
+	typ, _ := strconv.Atoi(fields[4])
+	hash, _ := hex.DecodeString(fields[5])
+	timestamp, _ := strconv.ParseInt(fields[6], 10, 64)
+	return fields[1], &types.Vote{
+		Height:    height,
+		Round:     round,
+		Type:      byte(typ),
+		BlockID:   types.BlockID{Hash: hash},
+		Timestamp: time.Unix(0, timestamp).UTC(),
+	}, nil
+}
+
+func (textEncoder) ParseProposal(signBytes []byte) (string, *types.Proposal, error) {
+	fields := strings.Split(string(signBytes), "/")
+	if len(fields) != 6 || fields[0] != "proposal" {
+		return "", nil, errors.New("not a proposal")
+	}
+	height, _ := strconv.ParseInt(fields[2], 10, 64)
+	round, _ := strconv.Atoi(fields[3])
+	polRound, _ := strconv.Atoi(fields[4])
+	timestamp, _ := strconv.ParseInt(fields[5], 10, 64)
+	return fields[1], &types.Proposal{
+		Height:    height,
+		Round:     round,
+		POLRound:  polRound,
+		Timestamp: time.Unix(0, timestamp).UTC(),
+	}, nil
+}
+
+func TestCanonicalEncoder(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetCanonicalEncoder(textEncoder{})
+	signer, pub := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	assert.Equal(textEncoder{}.VoteBytes("mychainid", vote), []byte(info.LastSignBytes))
+	assert.True(pub.VerifyBytes(info.LastSignBytes, vote.Signature))
+	require.Nil(info.ValidateVote("mychainid", vote, pub))
+
+	// only the timestamp differs, once normalized by the encoder
+	again := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	again.Timestamp = vote.Timestamp.Add(time.Second)
+	reason, err := info.SignVoteWithReason(signer, "mychainid", again)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+	assert.Equal(vote.Timestamp, again.Timestamp)
+	assert.Equal(vote.Signature, again.Signature)
+	reusable, ok := info.ReusableSignBytes(textEncoder{}.VoteBytes("mychainid", again))
+	assert.True(ok)
+	assert.Equal([]byte(info.LastSignBytes), reusable)
+
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+
+	proposal := &types.Proposal{Height: 11, POLRound: -1, Timestamp: time.Now().UTC()}
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+	assert.Equal(textEncoder{}.ProposalBytes("mychainid", proposal), []byte(info.LastSignBytes))
+
+	// JSON sign bytes aren't canonical for the encoder, so aren't compared
+	jsonVote := newVote(12, 0, types.VoteTypePrevote, blockID1)
+	signBytes := types.SignBytes("mychainid", jsonVote)
+	sig, err := signer.Sign(signBytes)
+	require.Nil(err)
+	require.Nil(info.Set(12, 0, stepPrevote, signBytes, sig))
+	assert.Equal(ErrNonCanonicalSignBytes, info.SignVote(signer, "mychainid", jsonVote))
+}
+
+func TestJSONEncoderParse(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	encoder := JSONEncoder{}
+	vote := newVote(10, 1, types.VoteTypePrecommit, blockID1)
+	signBytes := encoder.VoteBytes("mychainid", vote)
+	chainID, parsed, err := encoder.ParseVote(signBytes)
+	require.Nil(err)
+	assert.Equal("mychainid", chainID)
+	assert.Equal(signBytes, encoder.VoteBytes(chainID, parsed))
+	_, _, err = encoder.ParseProposal(signBytes)
+	assert.Error(err)
+
+	proposal := &types.Proposal{
+		Height:           10,
+		Round:            1,
+		Timestamp:        time.Now().UTC(),
+		BlockPartsHeader: types.PartSetHeader{Total: 2, Hash: []byte("parts")},
+		POLRound:         0,
+		POLBlockID:       blockID1,
+	}
+	signBytes = encoder.ProposalBytes("mychainid", proposal)
+	chainID, parsedProposal, err := encoder.ParseProposal(signBytes)
+	require.Nil(err)
+	assert.Equal("mychainid", chainID)
+	assert.Equal(signBytes, encoder.ProposalBytes(chainID, parsedProposal))
+	_, _, err = encoder.ParseVote(signBytes)
+	assert.Error(err)
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestCheckCanonical(t *testing.T) {
+	assert := assert.New(t)
+
+	vote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	nilVote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, types.BlockID{}))
+	proposal := types.SignBytes("mychainid", &types.Proposal{Height: 10, Round: 1, POLRound: -1})
+	escaped := types.SignBytes("<chain&id>", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	unescaped := unescapeHTML(escaped)
+	require.Contains(t, string(unescaped), `"chain_id":"<chain&id>"`)
+	for _, signBytes := range [][]byte{vote, nilVote, proposal, escaped, unescaped} {
+		assert.NoError(checkCanonical(signBytes), "%s", signBytes)
+	}
+
+	// semantically equal, but not the same bytes
+	var indented bytes.Buffer
+	require.Nil(t, json.Indent(&indented, vote, "", "  "))
+	reordered := bytes.Replace(vote, []byte(`{"chain_id":"mychainid",`), nil, 1)
+	reordered = append(reordered[:len(reordered)-1], []byte(`,"chain_id":"mychainid"}`)...)
+	duplicated := append([]byte(`{"chain_id":"other",`), vote[1:]...)
+
+	for _, signBytes := range [][]byte{
+		indented.Bytes(),
+		reordered,
+		duplicated,
+		append([]byte(" "), vote...),
+		append(vote, '\n'),
+		append(vote, vote...),
+		// escaped in places only
+		bytes.Replace(escaped, []byte(`\u003c`), []byte("<"), 1),
+		[]byte("garbage"),
+		nil,
+	} {
+		assert.Equal(ErrNonCanonicalSignBytes, checkCanonical(signBytes), "%s", signBytes)
+	}
+}
+
+// unescapeHTML undoes the escaping of <, > and & by encoding/json
+func unescapeHTML(signBytes []byte) []byte {
+	for escaped, c := range map[string]string{`\u003c`: "<", `\u003e`: ">", `\u0026`: "&"} {
+		signBytes = bytes.Replace(signBytes, []byte(escaped), []byte(c), -1)
+	}
+	return signBytes
+}
+
+func TestSignVoteNonCanonicalLastSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+
+	// eg. a hand-edited state file
+	var indented bytes.Buffer
+	assert.Nil(json.Indent(&indented, info.LastSignBytes, "", "  "))
+	info.LastSignBytes = indented.Bytes()
+
+	// the same vote, and one differing only by timestamp, are not reused
+	again := *vote
+	assert.Equal(ErrNonCanonicalSignBytes, info.SignVote(signer, "mychainid", &again))
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	assert.Equal(ErrNonCanonicalSignBytes, info.SignVote(signer, "mychainid", &later))
+
+	// moving on is not affected
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+}
@@ -0,0 +1,146 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// Version 1 of the canonical JSON of votes and proposals, as types.SignBytes
+// produces it as of tendermint 0.15. It's pinned here rather than taken from
+// the types package, so the sign bytes this package produces and compares
+// (see JSONEncoder and checkVotesOnlyDifferByTimestamp) don't change along
+// with it: after an upgrade, a vote signed before must still compare equal
+// to the same vote signed again. A new canonical form is a new version,
+// next to this one, with its own golden sign bytes in testdata.
+//
+// The keys are sorted, hashes are upper case hex and the timestamps UTC with
+// milliseconds. An empty block hash and a zero parts header are left out.
+
+// canonicalTimeFormatV1 is wire.RFC3339Millis.
+const canonicalTimeFormatV1 = "2006-01-02T15:04:05.000Z"
+
+type canonicalJSONOnceVoteV1 struct {
+	ChainID string              `json:"chain_id"`
+	Vote    canonicalJSONVoteV1 `json:"vote"`
+}
+
+type canonicalJSONVoteV1 struct {
+	BlockID   canonicalJSONBlockIDV1 `json:"block_id"`
+	Height    int64                  `json:"height"`
+	Round     int                    `json:"round"`
+	Timestamp string                 `json:"timestamp"`
+	Type      byte                   `json:"type"`
+}
+
+type canonicalJSONOnceProposalV1 struct {
+	ChainID  string                  `json:"chain_id"`
+	Proposal canonicalJSONProposalV1 `json:"proposal"`
+}
+
+type canonicalJSONProposalV1 struct {
+	BlockPartsHeader canonicalJSONPartSetHeaderV1 `json:"block_parts_header"`
+	Height           int64                        `json:"height"`
+	POLBlockID       canonicalJSONBlockIDV1       `json:"pol_block_id"`
+	POLRound         int                          `json:"pol_round"`
+	Round            int                          `json:"round"`
+	Timestamp        string                       `json:"timestamp"`
+}
+
+type canonicalJSONBlockIDV1 struct {
+	Hash        hexBytes                      `json:"hash,omitempty"`
+	PartsHeader *canonicalJSONPartSetHeaderV1 `json:"parts,omitempty"`
+}
+
+type canonicalJSONPartSetHeaderV1 struct {
+	Hash  hexBytes `json:"hash"`
+	Total int      `json:"total"`
+}
+
+// hexBytes are bytes in JSON as upper case hex.
+type hexBytes []byte
+
+func (bz hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(hex.EncodeToString(bz)))
+}
+
+func (bz *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*bz = decoded
+	return nil
+}
+
+// canonicalVoteBytesV1 returns the sign bytes of the vote, in version 1.
+func canonicalVoteBytesV1(chainID string, vote *types.Vote) []byte {
+	return marshalCanonicalV1(canonicalJSONOnceVoteV1{
+		ChainID: chainID,
+		Vote: canonicalJSONVoteV1{
+			BlockID:   canonicalBlockIDV1(vote.BlockID),
+			Height:    vote.Height,
+			Round:     vote.Round,
+			Timestamp: canonicalTimeV1(vote.Timestamp),
+			Type:      vote.Type,
+		},
+	})
+}
+
+// canonicalProposalBytesV1 returns the sign bytes of the proposal, in version 1.
+func canonicalProposalBytesV1(chainID string, proposal *types.Proposal) []byte {
+	return marshalCanonicalV1(canonicalJSONOnceProposalV1{
+		ChainID: chainID,
+		Proposal: canonicalJSONProposalV1{
+			BlockPartsHeader: canonicalJSONPartSetHeaderV1{
+				Hash:  hexBytes(proposal.BlockPartsHeader.Hash),
+				Total: proposal.BlockPartsHeader.Total,
+			},
+			Height:     proposal.Height,
+			POLBlockID: canonicalBlockIDV1(proposal.POLBlockID),
+			POLRound:   proposal.POLRound,
+			Round:      proposal.Round,
+			Timestamp:  canonicalTimeV1(proposal.Timestamp),
+		},
+	})
+}
+
+func canonicalBlockIDV1(blockID types.BlockID) canonicalJSONBlockIDV1 {
+	canonical := canonicalJSONBlockIDV1{Hash: hexBytes(blockID.Hash)}
+	if len(blockID.PartsHeader.Hash) != 0 || blockID.PartsHeader.Total != 0 {
+		canonical.PartsHeader = &canonicalJSONPartSetHeaderV1{
+			Hash:  hexBytes(blockID.PartsHeader.Hash),
+			Total: blockID.PartsHeader.Total,
+		}
+	}
+	return canonical
+}
+
+// returns the block ID of the canonical one
+func (blockID canonicalJSONBlockIDV1) blockID() types.BlockID {
+	parsed := types.BlockID{Hash: []byte(blockID.Hash)}
+	if parts := blockID.PartsHeader; parts != nil {
+		parsed.PartsHeader = types.PartSetHeader{Total: parts.Total, Hash: []byte(parts.Hash)}
+	}
+	return parsed
+}
+
+func canonicalTimeV1(t time.Time) string {
+	return t.UTC().Format(canonicalTimeFormatV1)
+}
+
+func marshalCanonicalV1(canonical interface{}) []byte {
+	signBytes, err := json.Marshal(canonical)
+	if err != nil {
+		// only plain values, which always marshal
+		panic(err)
+	}
+	return signBytes
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+// canonicalCase is a vote or proposal of testdata/canonical_v1.json.
+type canonicalCase struct {
+	vectorMessage
+	ChainID      string `json:"chain_id"`
+	POLBlockHash string `json:"pol_block_hash"` // hex
+}
+
+// message returns the vote or the proposal of the case.
+func (c canonicalCase) message(t *testing.T) (*types.Vote, *types.Proposal) {
+	blockHash, err := hex.DecodeString(c.BlockHash)
+	require.Nil(t, err)
+	partsHash, err := hex.DecodeString(c.PartsHash)
+	require.Nil(t, err)
+	polBlockHash, err := hex.DecodeString(c.POLBlockHash)
+	require.Nil(t, err)
+	timestamp, err := time.Parse(time.RFC3339Nano, c.Timestamp)
+	require.Nil(t, err)
+	partsHeader := types.PartSetHeader{Total: c.PartsTotal, Hash: partsHash}
+
+	switch c.Type {
+	case "proposal":
+		return nil, &types.Proposal{Height: c.Height, Round: c.Round, BlockPartsHeader: partsHeader,
+			POLRound: c.POLRound, POLBlockID: types.BlockID{Hash: polBlockHash}, Timestamp: timestamp}
+	case "prevote", "precommit":
+		voteType := types.VoteTypePrevote
+		if c.Type == "precommit" {
+			voteType = types.VoteTypePrecommit
+		}
+		return &types.Vote{Height: c.Height, Round: c.Round, Type: voteType,
+			BlockID: types.BlockID{Hash: blockHash, PartsHeader: partsHeader}, Timestamp: timestamp}, nil
+	default:
+		t.Fatalf("Unknown message type %v", c.Type)
+		return nil, nil
+	}
+}
+
+// TestCanonicalV1 checks version 1 of the canonical JSON against the golden
+// sign bytes in testdata/canonical_v1.json, which must never change: sign bytes
+// recorded before an upgrade are compared with those produced after it.
+// It also checks types.SignBytes still produces them; if an upgrade of the types
+// package fails that, the chain signs something else, so add a new version
+// rather than change this one.
+func TestCanonicalV1(t *testing.T) {
+	goldenJSON, err := ioutil.ReadFile("testdata/canonical_v1.json")
+	require.Nil(t, err)
+	var golden []struct {
+		Name      string        `json:"name"`
+		Message   canonicalCase `json:"message"`
+		SignBytes string        `json:"sign_bytes"`
+	}
+	require.Nil(t, json.Unmarshal(goldenJSON, &golden))
+	require.NotEmpty(t, golden)
+
+	encoder := JSONEncoder{}
+	for _, g := range golden {
+		vote, proposal := g.Message.message(t)
+		chainID := g.Message.ChainID
+		if vote != nil {
+			assert.Equal(t, g.SignBytes, string(canonicalVoteBytesV1(chainID, vote)), g.Name)
+			assert.Equal(t, g.SignBytes, string(types.SignBytes(chainID, vote)), g.Name)
+			parsedChainID, parsed, err := encoder.ParseVote([]byte(g.SignBytes))
+			require.Nil(t, err, g.Name)
+			assert.Equal(t, g.SignBytes, string(encoder.VoteBytes(parsedChainID, parsed)), g.Name)
+		} else {
+			assert.Equal(t, g.SignBytes, string(canonicalProposalBytesV1(chainID, proposal)), g.Name)
+			assert.Equal(t, g.SignBytes, string(types.SignBytes(chainID, proposal)), g.Name)
+			parsedChainID, parsed, err := encoder.ParseProposal([]byte(g.SignBytes))
+			require.Nil(t, err, g.Name)
+			assert.Equal(t, g.SignBytes, string(encoder.ProposalBytes(parsedChainID, parsed)), g.Name)
+		}
+		assert.Nil(t, checkCanonical([]byte(g.SignBytes)), g.Name)
+	}
+}
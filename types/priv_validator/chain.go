@@ -0,0 +1,80 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+var (
+	ErrChainMismatch = errors.New("LastSignedInfo is bound to another chain")
+	ErrEmptyChainID  = errors.New("Cannot sign for an empty chain ID")
+)
+
+// Bind binds the LastSignedInfo to the chainID, and persists it, so it can't be
+// used for another chain by mistake, eg. by two differently configured
+// validators pointed at the same state file: once bound, signing for another
+// chain, binding it to another chain, or setting a filePath with a state bound to
+// another chain returns ErrChainMismatch. Binding again to the same chain is a no-op.
+// A LastSignedInfo that isn't bound signs for any chain, as before.
+// Reset keeps the binding.
+func (info *LastSignedInfo) Bind(chainID string) error {
+	if chainID == "" {
+		return errors.New("Cannot bind to an empty chain ID")
+	}
+	if info.ChainID == chainID {
+		return nil
+	}
+	if info.ChainID != "" {
+		return ErrChainMismatch
+	}
+	if err := checkFileChain(info.filePath, chainID); err != nil {
+		return err
+	}
+	info.ChainID = chainID
+	return info.persist()
+}
+
+// checkChain returns ErrEmptyChainID for an empty chainID, as a signature
+// for an empty chain is almost always a configuration error, and doesn't
+// verify for any vote or proposal; and ErrChainMismatch if the LastSignedInfo
+// is bound to another chain than chainID.
+func (info *LastSignedInfo) checkChain(chainID string) error {
+	if chainID == "" {
+		return ErrEmptyChainID
+	}
+	if info.ChainID != "" && info.ChainID != chainID {
+		return ErrChainMismatch
+	}
+	return nil
+}
+
+// checkFileChain returns ErrChainMismatch if the state in filePath is bound to
+// another chain than chainID. A missing or unreadable file is left for loading
+// or saving to report.
+func checkFileChain(filePath string, chainID string) error {
+	if filePath == "" || chainID == "" {
+		return nil
+	}
+	bz, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	existing, err := unmarshalLastSignedInfo(bz)
+	if err != nil {
+		return nil
+	}
+	return existing.checkChain(chainID)
+}
+
+// flagEmptyChainID warns if signBytes, signed before, are for an empty chain ID.
+// They're still compared, as they were signed, but can't have been valid.
+func (info *LastSignedInfo) flagEmptyChainID(signBytes []byte) {
+	var signed struct {
+		ChainID *string `json:"chain_id"`
+	}
+	if json.Unmarshal(signBytes, &signed) != nil || signed.ChainID == nil || *signed.ChainID != "" {
+		return
+	}
+	info.getLogger().Error("Comparing with sign bytes signed for an empty chain ID", "signBytes", string(signBytes))
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestBind(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+
+	assert.Error(info.Bind(""))
+	require.Nil(info.Bind("mychainid"))
+	require.Nil(info.Bind("mychainid"))
+	assert.Equal(ErrChainMismatch, info.Bind("otherchainid"))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrChainMismatch, info.SignVote(signer, "otherchainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrChainMismatch, info.SignProposal(signer, "otherchainid", &types.Proposal{Height: 11, POLRound: -1}))
+	assert.Equal(int64(10), info.LastHeight)
+
+	// a validator of another chain, pointed at the same file
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.Equal(ErrChainMismatch, loaded.Bind("otherchainid"))
+	other := NewLastSignedInfo()
+	require.Nil(other.Bind("otherchainid"))
+	assert.Equal(ErrChainMismatch, other.SetFilePath(filePath))
+	unbound := NewLastSignedInfo()
+	require.Nil(unbound.SetFilePath(filePath))
+	assert.Equal(ErrChainMismatch, unbound.Bind("otherchainid"))
+
+	// sources bound to different chains don't reconcile
+	_, err = ReconcileSources(loaded, other)
+	assert.Equal(ErrChainMismatch, err)
+	reconciled, err := ReconcileSources(loaded, NewLastSignedInfo())
+	require.Nil(err)
+	assert.Equal("mychainid", reconciled.ChainID)
+
+	// Reset keeps the binding
+	require.Nil(loaded.Reset())
+	assert.Equal("mychainid", loaded.ChainID)
+	assert.Equal(ErrChainMismatch, loaded.Bind("otherchainid"))
+}
+
+func TestSignEmptyChainID(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	buf := new(bytes.Buffer)
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetLogger(log.NewTMLogger(buf))
+	var rejected []error
+	info.SetOnReject(func(event RejectEvent) { rejected = append(rejected, event.Err) })
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(ErrEmptyChainID, info.SignVote(signer, "", vote))
+	assert.Equal(ErrEmptyChainID, info.SignProposal(signer, "", &types.Proposal{Height: 10, POLRound: -1}))
+	assert.True(vote.Signature.Empty())
+	assert.Equal(int64(0), info.LastHeight)
+	assert.Equal([]error{ErrEmptyChainID, ErrEmptyChainID}, rejected)
+
+	// sign bytes recorded for an empty chain are still compared, but flagged
+	emptyChain := types.SignBytes("", vote)
+	sig, err := signer.Sign(emptyChain)
+	require.Nil(err)
+	require.Nil(info.Set(10, 0, stepPrevote, emptyChain, sig))
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", vote))
+	assert.Contains(buf.String(), "Comparing with sign bytes signed for an empty chain ID")
+}
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the package.
+// All time access goes through the Clock of the LastSignedInfo,
+// so time dependent behaviour can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock implements Clock using the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ManualClock implements Clock with a time that only changes
+// when it's told to. It's meant for tests.
+type ManualClock struct {
+	mtx     sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+// manualWaiter is a channel returned by ManualClock.After.
+type manualWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// NewManualClock returns a ManualClock set to the given time.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now implements Clock.
+func (c *ManualClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// After implements Clock. The channel receives once the clock is advanced,
+// or set, to d from now or later.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	w := manualWaiter{c.now.Add(d), make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	c.fire()
+	return w.c
+}
+
+// Advance moves the clock forward by d.
+// A negative d moves it backwards, eg. to simulate an NTP step.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set sets the clock to the given time.
+func (c *ManualClock) Set(now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.now = now
+	c.fire()
+}
+
+// fire sends the time to the waiters that are due.
+func (c *ManualClock) fire() {
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiting = append(waiting, w)
+		} else {
+			w.c <- c.now
+		}
+	}
+	c.waiters = waiting
+}
+
+// SetClock sets the Clock used by the LastSignedInfo.
+// Passing nil restores the system clock.
+func (info *LastSignedInfo) SetClock(clock Clock) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	info.clock = clock
+}
+
+func (info *LastSignedInfo) now() time.Time {
+	return info.getClock().Now()
+}
+
+func (info *LastSignedInfo) getClock() Clock {
+	if info.clock == nil {
+		return systemClock{}
+	}
+	return info.clock
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestManualClock(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	assert.Equal(start, clock.Now())
+	assert.Equal(start, clock.Now(), "time must not pass by itself")
+
+	clock.Advance(time.Second)
+	assert.Equal(start.Add(time.Second), clock.Now())
+
+	clock.Advance(-time.Minute)
+	assert.Equal(start.Add(time.Second-time.Minute), clock.Now())
+
+	clock.Set(start)
+	assert.Equal(start, clock.Now())
+}
+
+func TestManualClockAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	c := clock.After(time.Minute)
+	clock.Advance(time.Second)
+	assert.Empty(c)
+	clock.Advance(time.Minute)
+	assert.Equal(start.Add(time.Minute+time.Second), <-c)
+
+	assert.Equal(start.Add(time.Minute+time.Second), <-clock.After(0))
+	c = clock.After(time.Hour)
+	clock.Set(start.Add(2 * time.Hour))
+	assert.Len(c, 1)
+}
+
+func TestLastSignedInfoClock(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	assert.IsType(systemClock{}, info.clock)
+
+	clock := NewManualClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	info.SetClock(clock)
+	assert.Equal(clock.Now(), info.now())
+	clock.Advance(time.Hour)
+	assert.Equal(clock.Now(), info.now())
+
+	// timestamp-only reuse doesn't depend on the time the clock reports
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	sig := vote.Signature
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.Equal(sig, vote.Signature)
+
+	info.SetClock(nil)
+	assert.IsType(systemClock{}, info.clock)
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+)
+
+// StateCodec serializes a LastSignedInfo for a SignerState,
+// so the format can be chosen independently of where it's stored.
+// Decode must return a LastSignedInfo as NewLastSignedInfo does,
+// with its persisted fields set.
+type StateCodec interface {
+	Encode(w io.Writer, info *LastSignedInfo) error
+	Decode(r io.Reader) (*LastSignedInfo, error)
+}
+
+// JSONCodec is the default StateCodec, the format of LoadLastSignedInfo and SaveAs.
+type JSONCodec struct{}
+
+// Encode implements StateCodec.
+func (JSONCodec) Encode(w io.Writer, info *LastSignedInfo) error {
+	jsonBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// Decode implements StateCodec.
+func (JSONCodec) Decode(r io.Reader) (*LastSignedInfo, error) {
+	jsonBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalLastSignedInfo(jsonBytes)
+}
+
+func encodeBytes(codec StateCodec, info *LastSignedInfo) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := codec.Encode(buf, info); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBytes also rejects inconsistent and corrupt states, whatever the codec.
+func decodeBytes(codec StateCodec, bz []byte) (*LastSignedInfo, error) {
+	info, err := codec.Decode(bytes.NewReader(bz))
+	if err != nil {
+		return nil, err
+	}
+	if err := info.checkConsistent(); err != nil {
+		return nil, err
+	}
+	if err := info.checkSignatureIntact(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
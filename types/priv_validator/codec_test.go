@@ -0,0 +1,71 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// prefixCodec is JSON with a prefix, to tell it apart from plain JSON.
+type prefixCodec struct{}
+
+func (prefixCodec) Encode(w io.Writer, info *LastSignedInfo) error {
+	if _, err := w.Write([]byte("prefix")); err != nil {
+		return err
+	}
+	return JSONCodec{}.Encode(w, info)
+}
+
+func (prefixCodec) Decode(r io.Reader) (*LastSignedInfo, error) {
+	prefix := make([]byte, len("prefix"))
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, err
+	}
+	if string(prefix) != "prefix" {
+		return nil, errors.New("Missing prefix")
+	}
+	return JSONCodec{}.Decode(r)
+}
+
+func TestStateCodec(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	file := NewSignInfoFile(tempFilePath)
+	file.SetCodec(prefixCodec{})
+	db := NewSignInfoDB(dbm.NewMemDB())
+	db.SetCodec(prefixCodec{})
+
+	signer, _ := newTestSigner()
+	for _, store := range []SignerState{file, db} {
+		info := NewLastSignedInfo()
+		info.SetSignerState(store)
+		require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)))
+
+		loaded, err := store.Load()
+		require.Nil(err)
+		assert.Equal(int64(10), loaded.LastHeight)
+		assert.Equal(info.LastSignBytes, loaded.LastSignBytes)
+
+		// the loaded info keeps the codec
+		require.Nil(loaded.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+		loaded, err = store.Load()
+		require.Nil(err)
+		assert.Equal(int64(11), loaded.LastHeight)
+	}
+
+	// the file isn't JSON
+	fileBytes, err := ioutil.ReadFile(tempFilePath)
+	require.Nil(err)
+	assert.True(bytes.HasPrefix(fileBytes, []byte("prefix")))
+	_, err = LoadLastSignedInfo(tempFilePath)
+	assert.Error(err)
+}
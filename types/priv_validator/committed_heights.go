@@ -0,0 +1,54 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// HeightRange is a run of consecutive heights, from Start to End inclusive.
+type HeightRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// SetTrackCommittedHeights enables or disables recording the heights at which
+// a precommit for a block (ie. not nil) is Set, see CommittedHeights.
+// It's off by default. It's only for analytics, and never used to decide whether to sign.
+func (info *LastSignedInfo) SetTrackCommittedHeights(track bool) {
+	info.trackCommittedHeights = track
+}
+
+// CommittedHeights returns the heights at which a precommit for a block was signed,
+// in increasing order, while tracking was enabled.
+func (info *LastSignedInfo) CommittedHeights() []int64 {
+	var heights []int64
+	for _, r := range info.CommittedRanges {
+		for height := r.Start; height <= r.End; height++ {
+			heights = append(heights, height)
+		}
+	}
+	return heights
+}
+
+// recordCommitted adds the height to the CommittedRanges
+// if the signBytes are of a precommit for a block.
+func (info *LastSignedInfo) recordCommitted(height int64, step int8, signBytes []byte) {
+	if !info.trackCommittedHeights || step != stepPrecommit {
+		return
+	}
+	var vote types.CanonicalJSONOnceVote
+	if err := json.Unmarshal(signBytes, &vote); err != nil || len(vote.Vote.BlockID.Hash) == 0 {
+		return
+	}
+
+	n := len(info.CommittedRanges)
+	switch {
+	case n == 0 || height > info.CommittedRanges[n-1].End+1:
+		info.CommittedRanges = append(info.CommittedRanges, HeightRange{height, height})
+	case height == info.CommittedRanges[n-1].End+1:
+		info.CommittedRanges[n-1].End = height
+	default:
+		// already recorded
+	}
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestCommittedHeights(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	signer, _ := newTestSigner()
+	sign := func(height int64, round int, typ byte, blockID types.BlockID) {
+		require.Nil(info.SignVote(signer, "mychainid", newVote(height, round, typ, blockID)))
+	}
+
+	// off by default
+	sign(1, 0, types.VoteTypePrecommit, blockID1)
+	assert.Empty(info.CommittedHeights())
+
+	info.SetTrackCommittedHeights(true)
+	sign(2, 0, types.VoteTypePrevote, blockID1)          // not a precommit
+	sign(2, 0, types.VoteTypePrecommit, blockID1)        // committed
+	sign(3, 0, types.VoteTypePrecommit, blockID1)        // committed
+	sign(4, 0, types.VoteTypePrecommit, blockID1)        // committed
+	sign(5, 0, types.VoteTypePrecommit, types.BlockID{}) // nil
+	sign(6, 0, types.VoteTypePrecommit, blockID1)        // committed
+	sign(6, 1, types.VoteTypePrecommit, blockID2)        // same height again
+	assert.Equal([]int64{2, 3, 4, 6}, info.CommittedHeights())
+	assert.Equal([]HeightRange{{2, 4}, {6, 6}}, info.CommittedRanges)
+
+	// not a vote
+	require.Nil(info.Set(7, 0, stepPrecommit, []byte("signbytes"), info.LastSignature.Crypto()))
+	assert.Equal([]int64{2, 3, 4, 6}, info.CommittedHeights())
+
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal([]int64{2, 3, 4, 6}, loaded.CommittedHeights())
+}
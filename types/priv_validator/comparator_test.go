@@ -0,0 +1,78 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestComparatorMatchesChecks(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	later := *vote
+	later.Timestamp = later.Timestamp.Add(time.Second)
+	other := *vote
+	other.BlockID = blockID2
+	nilVote := *vote
+	nilVote.BlockID = types.BlockID{}
+
+	votes := [][]byte{
+		types.SignBytes("mychainid", vote),
+		types.SignBytes("mychainid", &later),
+		types.SignBytes("mychainid", &other),
+		types.SignBytes("mychainid", &nilVote),
+		types.SignBytes("otherchainid", vote),
+	}
+	proposal := &types.Proposal{Height: 10, Round: 1, Timestamp: now, POLRound: -1,
+		BlockPartsHeader: types.PartSetHeader{Total: 5, Hash: []byte{1, 2, 3}}}
+	laterProposal := *proposal
+	laterProposal.Timestamp = now.Add(time.Second)
+	otherProposal := *proposal
+	otherProposal.POLRound = 0
+	proposals := [][]byte{
+		types.SignBytes("mychainid", proposal),
+		types.SignBytes("mychainid", &laterProposal),
+		types.SignBytes("mychainid", &otherProposal),
+	}
+
+	c := NewComparator()
+	for _, now := range []time.Time{now, now.Add(time.Hour)} {
+		// interleave votes and proposals to exercise the cache
+		for _, last := range votes {
+			for _, candidate := range votes {
+				assert.Equal(checkVotesOnlyDifferByTimestamp(last, candidate, now),
+					c.VotesOnlyDifferByTimestamp(last, candidate, now), "%s %s", last, candidate)
+			}
+			for _, last := range proposals {
+				for _, candidate := range proposals {
+					assert.Equal(checkProposalsOnlyDifferByTimestamp(last, candidate, now),
+						c.ProposalsOnlyDifferByTimestamp(last, candidate, now), "%s %s", last, candidate)
+				}
+			}
+		}
+	}
+
+	c.Reset()
+	assert.True(c.VotesOnlyDifferByTimestamp(votes[0], votes[1], now))
+	assert.Panics(func() { c.VotesOnlyDifferByTimestamp(votes[0], []byte("garbage"), now) })
+}
+
+func BenchmarkComparator(b *testing.B) {
+	last := types.SignBytes("test_chain_id", benchVote(10))
+	vote := benchVote(10)
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+	signBytes := types.SignBytes("test_chain_id", vote)
+	c := NewComparator()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !c.VotesOnlyDifferByTimestamp(last, signBytes, benchTime) {
+			b.Fatal("expected votes to only differ by timestamp")
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// The sign bytes of a vote or proposal are its canonical JSON, see types.CanonicalJSONOnceVote
+// and types.CanonicalJSONOnceProposal, as pinned in version 1 (see canonicalVoteBytesV1).
+// A field is cosmetic if it can differ between two signatures for the same
+// height/round/step without the signatures conflicting; all other fields are content.
+// The timestamp is the only cosmetic field, so a signature can only be reused if nothing but
+// the timestamp differs.
+//
+// For votes, the content fields are:
+//
+//	chain_id, vote.block_id (hash and parts), vote.height, vote.nonce, vote.round, vote.type
+//
+// For proposals, the content fields are:
+//
+//	chain_id, proposal.block_parts_header, proposal.height, proposal.round,
+//	proposal.pol_round, proposal.pol_block_id
+//
+// Votes are planned to carry a per-validator nonce, as vote.nonce, that changes
+// each round. It's content: the same round always has the same nonce, so a
+// different nonce is a different vote, and a signature over one doesn't
+// verify for the other.
+//
+// The signature is never part of the sign bytes, it's over them: the signed
+// preimage is exactly the fields above, and a signature attached to the vote or
+// proposal (eg. when re-proposing one that was signed before) is ignored.
+// Should a path put a signature key in the sign bytes of a proposal, it's
+// stripped before comparing, so it can't make the same proposal conflict.
+//
+// Note the POLRound is content: re-proposing with a different proof-of-lock round
+// proposes something different, even for the same block.
+//
+// To compare, the timestamps on both sides are set to the same value, the canonical
+// form of now. Which value doesn't matter, so the clock going backwards, repeating
+// itself or jumping (eg. around a leap second or an NTP step) can't change the result.
+
+// VoteReuseFields returns the fields of the vote sign bytes (types.CanonicalJSONOnceVote)
+// that are cosmetic and content, by JSON path. A signature is only reused for
+// new sign bytes that differ from the LastSignBytes in cosmetic fields.
+func VoteReuseFields() (cosmetic, content []string) {
+	cosmetic = []string{"vote.timestamp"}
+	content = []string{"chain_id", "vote.block_id", "vote.height", "vote.nonce", "vote.round", "vote.type"}
+	return cosmetic, content
+}
+
+// ProposalReuseFields is VoteReuseFields for the proposal sign bytes
+// (types.CanonicalJSONOnceProposal), ie. the signed preimage of a proposal.
+func ProposalReuseFields() (cosmetic, content []string) {
+	cosmetic = []string{"proposal.timestamp"}
+	content = []string{"chain_id", "proposal.block_parts_header", "proposal.height",
+		"proposal.pol_block_id", "proposal.pol_round", "proposal.round"}
+	return cosmetic, content
+}
+
+// comparedJSONOnceVote is canonicalJSONOnceVoteV1 as decoded to compare votes.
+// Decoding into canonicalJSONOnceVoteV1 would silently drop a nonce,
+// and with it the difference between two nonces; this keeps it, as is.
+type comparedJSONOnceVote struct {
+	ChainID string           `json:"chain_id"`
+	Vote    comparedJSONVote `json:"vote"`
+}
+
+type comparedJSONVote struct {
+	canonicalJSONVoteV1
+	Nonce json.RawMessage `json:"nonce,omitempty"`
+}
+
+// returns true if the only difference in the votes is their timestamp.
+// now is used to normalize the timestamps
+func checkVotesOnlyDifferByTimestamp(lastSignBytes, newSignBytes []byte, now time.Time) bool {
+	lastVoteBytes, newVoteBytes := normalizeVotes(lastSignBytes, newSignBytes, now)
+	return bytes.Equal(newVoteBytes, lastVoteBytes)
+}
+
+// returns the votes with their timestamps set to now
+func normalizeVotes(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte) {
+	var lastVote, newVote comparedJSONOnceVote
+	if err := json.Unmarshal(lastSignBytes, &lastVote); err != nil {
+		panic(fmt.Sprintf("LastSignBytes cannot be unmarshalled into vote: %v", err))
+	}
+	if err := json.Unmarshal(newSignBytes, &newVote); err != nil {
+		panic(fmt.Sprintf("signBytes cannot be unmarshalled into vote: %v", err))
+	}
+
+	// set the times to the same value and check equality
+	lastVote.Vote.Timestamp = canonicalTimeV1(now)
+	newVote.Vote.Timestamp = canonicalTimeV1(now)
+	lastVoteBytes, _ := json.Marshal(lastVote)
+	newVoteBytes, _ := json.Marshal(newVote)
+	return lastVoteBytes, newVoteBytes
+}
+
+// comparedJSONOnceProposal is canonicalJSONOnceProposalV1 as decoded to compare
+// proposals. The signature is decoded only to be stripped, see stripSignature.
+type comparedJSONOnceProposal struct {
+	ChainID  string               `json:"chain_id"`
+	Proposal comparedJSONProposal `json:"proposal"`
+}
+
+type comparedJSONProposal struct {
+	canonicalJSONProposalV1
+	Signature json.RawMessage `json:"signature,omitempty"`
+}
+
+// stripSignature removes the signature attached to the proposal, if any,
+// as it's over the sign bytes rather than part of them.
+func (p *comparedJSONOnceProposal) stripSignature() {
+	p.Proposal.Signature = nil
+}
+
+// returns true if the only difference in the proposals is their timestamp.
+// now is used to normalize the timestamps
+func checkProposalsOnlyDifferByTimestamp(lastSignBytes, newSignBytes []byte, now time.Time) bool {
+	lastProposalBytes, newProposalBytes := normalizeProposals(lastSignBytes, newSignBytes, now)
+	return bytes.Equal(newProposalBytes, lastProposalBytes)
+}
+
+// returns the proposals with their timestamps set to now and without signature
+func normalizeProposals(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte) {
+	var lastProposal, newProposal comparedJSONOnceProposal
+	if err := json.Unmarshal(lastSignBytes, &lastProposal); err != nil {
+		panic(fmt.Sprintf("LastSignBytes cannot be unmarshalled into proposal: %v", err))
+	}
+	if err := json.Unmarshal(newSignBytes, &newProposal); err != nil {
+		panic(fmt.Sprintf("signBytes cannot be unmarshalled into proposal: %v", err))
+	}
+
+	lastProposal.stripSignature()
+	newProposal.stripSignature()
+
+	// set the times to the same value and check equality
+	lastProposal.Proposal.Timestamp = canonicalTimeV1(now)
+	newProposal.Proposal.Timestamp = canonicalTimeV1(now)
+	lastProposalBytes, _ := json.Marshal(lastProposal)
+	newProposalBytes, _ := json.Marshal(newProposal)
+	return lastProposalBytes, newProposalBytes
+}
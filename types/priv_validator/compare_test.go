@@ -0,0 +1,298 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestProposalFieldsReusability(t *testing.T) {
+	base := func() *types.Proposal {
+		return &types.Proposal{
+			Height:           10,
+			Round:            1,
+			Timestamp:        time.Now().UTC(),
+			BlockPartsHeader: types.PartSetHeader{Total: 5, Hash: []byte{1, 2, 3}},
+			POLRound:         0,
+			POLBlockID:       blockID1,
+		}
+	}
+
+	cases := []struct {
+		field    string
+		chainID  string
+		mutate   func(*types.Proposal)
+		reusable bool
+	}{
+		{"Timestamp", "mychainid", func(p *types.Proposal) { p.Timestamp = p.Timestamp.Add(time.Hour) }, true},
+		{"Signature", "mychainid", func(p *types.Proposal) { p.Signature = blockSig() }, true},
+		{"ChainID", "otherchainid", func(p *types.Proposal) {}, false},
+		{"Height", "mychainid", func(p *types.Proposal) { p.Height++ }, false},
+		{"Round", "mychainid", func(p *types.Proposal) { p.Round++ }, false},
+		{"BlockPartsHeader.Total", "mychainid", func(p *types.Proposal) { p.BlockPartsHeader.Total++ }, false},
+		{"BlockPartsHeader.Hash", "mychainid", func(p *types.Proposal) { p.BlockPartsHeader.Hash = []byte{3, 2, 1} }, false},
+		{"POLRound", "mychainid", func(p *types.Proposal) { p.POLRound = -1 }, false},
+		{"POLBlockID.Hash", "mychainid", func(p *types.Proposal) { p.POLBlockID = blockID2 }, false},
+		{"POLBlockID.PartsHeader", "mychainid", func(p *types.Proposal) { p.POLBlockID.PartsHeader.Total = 7 }, false},
+	}
+
+	now := time.Now()
+	for _, c := range cases {
+		last := base()
+		candidate := base()
+		candidate.Timestamp = last.Timestamp
+		c.mutate(candidate)
+
+		lastBytes := types.SignBytes("mychainid", last)
+		candidateBytes := types.SignBytes(c.chainID, candidate)
+		assert.Equal(t, c.reusable, checkProposalsOnlyDifferByTimestamp(lastBytes, candidateBytes, now), c.field)
+	}
+}
+
+func TestProposalOnlyDifferByPOLRound(t *testing.T) {
+	// same block re-proposed with a different proof-of-lock round and time
+	last := &types.Proposal{Height: 10, Round: 1, Timestamp: time.Now().UTC(),
+		BlockPartsHeader: types.PartSetHeader{Total: 5, Hash: []byte{1, 2, 3}}, POLRound: -1}
+	candidate := *last
+	candidate.POLRound = 0
+	candidate.Timestamp = last.Timestamp.Add(time.Second)
+
+	assert.False(t, checkProposalsOnlyDifferByTimestamp(
+		types.SignBytes("mychainid", last), types.SignBytes("mychainid", &candidate), time.Now()))
+}
+
+func TestProposalReuseFields(t *testing.T) {
+	assert := assert.New(t)
+	cosmetic, content := ProposalReuseFields()
+
+	// every field is declared, exactly once, and the signature isn't one
+	var paths []string
+	for _, field := range jsonFields(reflect.TypeOf(types.CanonicalJSONOnceProposal{})) {
+		if field == "proposal" {
+			for _, proposalField := range jsonFields(reflect.TypeOf(types.CanonicalJSONProposal{})) {
+				paths = append(paths, "proposal."+proposalField)
+			}
+		} else {
+			paths = append(paths, field)
+		}
+	}
+	declared := append(append([]string{}, cosmetic...), content...)
+	sort.Strings(paths)
+	sort.Strings(declared)
+	assert.Equal(paths, declared, "fields of CanonicalJSONOnceProposal changed")
+	assert.NotContains(paths, "proposal.signature")
+}
+
+func TestProposalSignatureNotCompared(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	proposal := &types.Proposal{Height: 10, Round: 1, Timestamp: time.Now().UTC(),
+		BlockPartsHeader: types.PartSetHeader{Total: 5, Hash: []byte{1, 2, 3}}, POLRound: -1}
+	lastSignBytes := types.SignBytes("mychainid", proposal)
+	proposal.Signature = blockSig()
+	assert.Equal(lastSignBytes, types.SignBytes("mychainid", proposal))
+
+	// sign bytes with a signature attached, should a path add one
+	var withSignature map[string]interface{}
+	require.Nil(json.Unmarshal(lastSignBytes, &withSignature))
+	withSignature["proposal"].(map[string]interface{})["signature"] = "07"
+	signBytes, err := json.Marshal(withSignature)
+	require.Nil(err)
+	assert.True(checkProposalsOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()))
+	assert.True(NewComparator().ProposalsOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()))
+	assert.True(NewComparator().ProposalsOnlyDifferByTimestamp(signBytes, lastSignBytes, time.Now()))
+	// the rest is still compared
+	withSignature["proposal"].(map[string]interface{})["round"] = 2
+	signBytes, err = json.Marshal(withSignature)
+	require.Nil(err)
+	assert.False(checkProposalsOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()))
+	assert.False(NewComparator().ProposalsOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()))
+
+	// re-proposing a signed proposal reuses the signature
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	proposal.Signature = crypto.Signature{}
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+	reproposal := *proposal
+	require.Nil(info.SignProposal(signer, "mychainid", &reproposal))
+	assert.Equal(proposal.Signature, reproposal.Signature)
+	assert.EqualValues(1, info.Sequence())
+}
+
+func blockSig() crypto.Signature {
+	return crypto.SignatureEd25519{7}.Wrap()
+}
+
+func TestVoteReuseFields(t *testing.T) {
+	assert := assert.New(t)
+	cosmetic, content := VoteReuseFields()
+
+	// every field is declared, exactly once
+	var paths []string
+	for _, field := range jsonFields(reflect.TypeOf(types.CanonicalJSONOnceVote{})) {
+		if field == "vote" {
+			for _, voteField := range jsonFields(reflect.TypeOf(types.CanonicalJSONVote{})) {
+				paths = append(paths, "vote."+voteField)
+			}
+			// planned, see comparedJSONVote
+			paths = append(paths, "vote.nonce")
+		} else {
+			paths = append(paths, field)
+		}
+	}
+	declared := append(append([]string{}, cosmetic...), content...)
+	sort.Strings(paths)
+	sort.Strings(declared)
+	assert.Equal(paths, declared, "fields of CanonicalJSONOnceVote changed")
+
+	// mutating a cosmetic field allows reuse, and a content field blocks it
+	mutations := map[string]func(*comparedJSONOnceVote){
+		"chain_id":       func(v *comparedJSONOnceVote) { v.ChainID = "otherchainid" },
+		"vote.block_id":  func(v *comparedJSONOnceVote) { v.Vote.BlockID.Hash = hexBytes(blockID2.Hash) },
+		"vote.height":    func(v *comparedJSONOnceVote) { v.Vote.Height++ },
+		"vote.nonce":     func(v *comparedJSONOnceVote) { v.Vote.Nonce = json.RawMessage("1") },
+		"vote.round":     func(v *comparedJSONOnceVote) { v.Vote.Round++ },
+		"vote.timestamp": func(v *comparedJSONOnceVote) { v.Vote.Timestamp = "2018-01-01T00:00:00.000Z" },
+		"vote.type":      func(v *comparedJSONOnceVote) { v.Vote.Type = types.VoteTypePrecommit },
+	}
+	lastSignBytes := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	for _, fields := range []struct {
+		names    []string
+		reusable bool
+	}{{cosmetic, true}, {content, false}} {
+		for _, name := range fields.names {
+			var vote comparedJSONOnceVote
+			assert.Nil(json.Unmarshal(lastSignBytes, &vote))
+			mutate, ok := mutations[name]
+			if !assert.True(ok, "no mutation for %v", name) {
+				continue
+			}
+			mutate(&vote)
+			signBytes, err := json.Marshal(vote)
+			assert.Nil(err)
+			assert.Equal(fields.reusable, checkVotesOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()), name)
+		}
+	}
+}
+
+func TestVoteNonceBlocksReuse(t *testing.T) {
+	assert := assert.New(t)
+
+	// sign bytes of a vote with a nonce, in the canonical form
+	withNonce := func(nonce int, timestamp string) []byte {
+		var vote map[string]interface{}
+		assert.Nil(json.Unmarshal(types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)), &vote))
+		vote["vote"].(map[string]interface{})["nonce"] = nonce
+		vote["vote"].(map[string]interface{})["timestamp"] = timestamp
+		signBytes, err := json.Marshal(vote)
+		assert.Nil(err)
+		assert.Nil(checkCanonical(signBytes))
+		return signBytes
+	}
+	lastSignBytes := withNonce(1, "2017-12-25T03:00:01.234Z")
+	later := withNonce(1, "2017-12-25T03:00:02.234Z")
+	otherNonce := withNonce(2, "2017-12-25T03:00:01.234Z")
+
+	comparator := NewComparator()
+	now := time.Now()
+	assert.True(checkVotesOnlyDifferByTimestamp(lastSignBytes, later, now))
+	assert.True(comparator.VotesOnlyDifferByTimestamp(lastSignBytes, later, now))
+	assert.False(checkVotesOnlyDifferByTimestamp(lastSignBytes, otherNonce, now))
+	assert.False(comparator.VotesOnlyDifferByTimestamp(lastSignBytes, otherNonce, now))
+
+	info := NewLastSignedInfo()
+	assert.Nil(info.Set(10, 1, stepPrevote, lastSignBytes, blockSig()))
+	_, ok := info.ReusableSignBytes(later)
+	assert.True(ok)
+	_, ok = info.ReusableSignBytes(otherNonce)
+	assert.False(ok)
+}
+
+// returns the JSON names of the fields of the struct type
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		names = append(names, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return names
+}
+
+func TestVotesDifferingByRoundNotReusable(t *testing.T) {
+	assert := assert.New(t)
+
+	// same content and timestamp, different round
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	nextRound := *vote
+	nextRound.Round = 2
+	nextRound.Timestamp = vote.Timestamp.Add(time.Millisecond)
+	lastSignBytes := types.SignBytes("mychainid", vote)
+	signBytes := types.SignBytes("mychainid", &nextRound)
+	assert.False(checkVotesOnlyDifferByTimestamp(lastSignBytes, signBytes, time.Now()))
+
+	// SignVote doesn't reuse the signature across rounds
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	reason, err := info.SignVoteWithReason(signer, "mychainid", &nextRound)
+	assert.NoError(err)
+	assert.Equal(RoundAdvanced, reason)
+	assert.False(vote.Signature.Equals(nextRound.Signature))
+	assert.Equal(2, info.LastRound)
+}
+
+func TestComparisonIgnoresClock(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2016, 12, 31, 23, 59, 59, 999000000, time.UTC)
+	nows := []time.Time{
+		start,
+		start,                               // duplicate
+		start.Add(-time.Second),             // backwards, eg. a leap second
+		start.Add(-time.Hour),               // NTP step
+		start.Add(time.Nanosecond),          // below the canonical precision
+		start.In(time.FixedZone("X", 3600)), // other location
+		{},                                  // zero
+		time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	other := *vote
+	other.BlockID = blockID2
+	lastSignBytes := types.SignBytes("mychainid", vote)
+	laterSignBytes := types.SignBytes("mychainid", &later)
+	otherSignBytes := types.SignBytes("mychainid", &other)
+
+	c := NewComparator()
+	for _, now := range nows {
+		assert.True(checkVotesOnlyDifferByTimestamp(lastSignBytes, laterSignBytes, now), "%v", now)
+		assert.False(checkVotesOnlyDifferByTimestamp(lastSignBytes, otherSignBytes, now), "%v", now)
+		assert.True(c.VotesOnlyDifferByTimestamp(lastSignBytes, laterSignBytes, now), "%v", now)
+		assert.False(c.VotesOnlyDifferByTimestamp(lastSignBytes, otherSignBytes, now), "%v", now)
+	}
+
+	// the same through SignVote, with the clock misbehaving between calls
+	clock := NewManualClock(start)
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetClock(clock)
+	signer, _ := newTestSigner()
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	for _, now := range nows {
+		clock.Set(now)
+		reused := later
+		reason, err := info.SignVoteWithReason(signer, "mychainid", &reused)
+		assert.NoError(err, "%v", now)
+		assert.Equal(Reused, reason, "%v", now)
+		assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", &other), "%v", now)
+	}
+}
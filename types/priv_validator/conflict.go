@@ -0,0 +1,76 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFrozen = errors.New("Signing is frozen after conflicting data")
+)
+
+// ConflictStrategy is how to react when asked to sign data that conflicts with
+// the data signed at the same height/round/step. Whatever the strategy,
+// the conflicting data is never signed.
+type ConflictStrategy int
+
+const (
+	// ConflictFreeze returns ErrConflictingData and refuses to sign anything
+	// else, with ErrFrozen, until Unfreeze is called. It's the default.
+	ConflictFreeze ConflictStrategy = iota
+	// ConflictError returns ErrConflictingData, and keeps signing otherwise.
+	ConflictError
+	// ConflictPanic panics.
+	ConflictPanic
+	// ConflictEvidence passes both sign bytes to the handler set with
+	// SetOnConflictEvidence, eg. to build evidence with BuildConflictingVotes,
+	// returns ErrConflictingData and keeps signing otherwise.
+	ConflictEvidence
+	// ConflictTombstone returns ErrConflictingData and tombstones the
+	// LastSignedInfo, which survives a restart, see Tombstone.
+	ConflictTombstone
+)
+
+// SetConflictStrategy sets how to react to conflicting data at the same
+// height/round/step. Conflicts with the history (ErrBackdatedConflict) are
+// rejected as regressions are, whatever the strategy.
+func (info *LastSignedInfo) SetConflictStrategy(strategy ConflictStrategy) {
+	info.conflictStrategy = strategy
+}
+
+// SetOnConflictEvidence sets the handler for ConflictEvidence.
+// It's called synchronously with the LastSignBytes and the conflicting ones,
+// so it can also get the extension of a precommit with LastSignedExtension.
+// Passing nil removes it.
+func (info *LastSignedInfo) SetOnConflictEvidence(onConflictEvidence func(lastSignBytes, signBytes []byte)) {
+	info.onConflictEvidence = onConflictEvidence
+}
+
+// Frozen returns true if signing is frozen, see ConflictFreeze.
+func (info *LastSignedInfo) Frozen() bool {
+	return info.frozen
+}
+
+// Unfreeze lets signing resume after a conflict froze it,
+// once the cause was investigated. Restarting also unfreezes it.
+func (info *LastSignedInfo) Unfreeze() {
+	info.frozen = false
+}
+
+// handleConflict applies the ConflictStrategy to conflicting signBytes.
+func (info *LastSignedInfo) handleConflict(height int64, round int, step int8, signBytes []byte) {
+	info.emitEvent(SignerEvent{Type: EventConflict, Height: height, Round: round, Step: step, SignBytes: copyBytes(signBytes)})
+	switch info.conflictStrategy {
+	case ConflictFreeze:
+		info.getLogger().Error("Freezing signing after conflicting data", "height", height, "round", round, "step", step)
+		info.freeze(fmt.Sprintf("Conflicting data at %v/%v/%v", height, round, step))
+	case ConflictPanic:
+		panic(fmt.Sprintf("Conflicting data at %v/%v/%v", height, round, step))
+	case ConflictEvidence:
+		if info.onConflictEvidence != nil {
+			info.onConflictEvidence(info.LastSignBytes, signBytes)
+		}
+	case ConflictTombstone:
+		info.tombstoneConflict(height, round, step)
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+)
+
+// ConflictCase is a self-contained reproduction of a conflict, eg. one reported
+// by SetOnConflictEvidence in production, to be saved as JSON and replayed in
+// a regression test. See DumpConflictCase.
+//
+// It only holds public data: the sign bytes (the canonical JSON of a vote or
+// proposal, which is broadcast) and the public key of the validator, which
+// identifies it. No private key or signature is needed to replay it.
+type ConflictCase struct {
+	Height int64 `json:"height"`
+	Round  int   `json:"round"`
+	Step   int8  `json:"step"`
+
+	LastSignBytes      data.Bytes    `json:"last_signbytes"`
+	AttemptedSignBytes data.Bytes    `json:"attempted_signbytes"`
+	PubKey             crypto.PubKey `json:"pub_key"`
+
+	// The fields that differ, as DiffSignBytes, for whoever reads the case.
+	Diff string `json:"diff"`
+}
+
+// DumpConflictCase bundles the last and attempted sign bytes of a conflict,
+// their height/round/step, and the validator's pub into a ConflictCase.
+// It returns an error if either sign bytes aren't the canonical JSON of a vote
+// or proposal, or if they aren't at the same height/round/step.
+func DumpConflictCase(last, attempted []byte, pub crypto.PubKey) (ConflictCase, error) {
+	for _, signBytes := range [][]byte{last, attempted} {
+		if err := checkCanonical(signBytes); err != nil {
+			return ConflictCase{}, err
+		}
+	}
+	lastReq, err := replayRequest(last)
+	if err != nil {
+		return ConflictCase{}, err
+	}
+	attemptedReq, err := replayRequest(attempted)
+	if err != nil {
+		return ConflictCase{}, err
+	}
+	if compareHRS(lastReq.height, lastReq.round, lastReq.step,
+		attemptedReq.height, attemptedReq.round, attemptedReq.step) != 0 {
+		return ConflictCase{}, fmt.Errorf("Sign bytes are at %v/%v/%v and %v/%v/%v",
+			lastReq.height, lastReq.round, lastReq.step, attemptedReq.height, attemptedReq.round, attemptedReq.step)
+	}
+	diff, err := DiffSignBytes(last, attempted)
+	if err != nil {
+		return ConflictCase{}, err
+	}
+	return ConflictCase{
+		Height:             lastReq.height,
+		Round:              lastReq.round,
+		Step:               lastReq.step,
+		LastSignBytes:      copyBytes(last),
+		AttemptedSignBytes: copyBytes(attempted),
+		PubKey:             pub,
+		Diff:               diff,
+	}, nil
+}
+
+// Replay signs the LastSignBytes, then the AttemptedSignBytes, from a fresh
+// LastSignedInfo, with ReplayOperations, and returns the outcome of the latter:
+// its Err is ErrConflictingData as long as the conflict is still caught.
+func (c ConflictCase) Replay() ReplayOutcome {
+	_, report := ReplayOperations(NewLastSignedInfo().Snapshot(),
+		[]SignOp{{SignBytes: c.LastSignBytes}, {SignBytes: c.AttemptedSignBytes}})
+	return report.Outcomes[1]
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestDumpConflictCase(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, pub := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrecommit, blockID1)
+	last := types.SignBytes("mychainid", vote)
+	attempted := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID2))
+
+	conflictCase, err := DumpConflictCase(last, attempted, pub)
+	require.Nil(err)
+	assert.EqualValues(10, conflictCase.Height)
+	assert.Equal(1, conflictCase.Round)
+	assert.Equal(stepPrecommit, conflictCase.Step)
+	assert.Contains(conflictCase.Diff, "Vote.BlockID.Hash")
+
+	// it round-trips through JSON, and still replays as a conflict
+	bz, err := json.Marshal(conflictCase)
+	require.Nil(err)
+	var loaded ConflictCase
+	require.Nil(json.Unmarshal(bz, &loaded))
+	assert.Equal(conflictCase, loaded)
+	assert.Equal(ErrConflictingData, loaded.Replay().Err)
+
+	// not a conflict, just another timestamp
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	reuse, err := DumpConflictCase(last, types.SignBytes("mychainid", &later), pub)
+	require.Nil(err)
+	assert.True(reuse.Replay().Reused)
+
+	_, err = DumpConflictCase(last, types.SignBytes("mychainid", newVote(11, 1, types.VoteTypePrecommit, blockID2)), pub)
+	assert.Error(err, "different HRS")
+	_, err = DumpConflictCase(last, []byte("garbage"), pub)
+	assert.Error(err)
+}
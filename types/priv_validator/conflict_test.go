@@ -0,0 +1,77 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+// signs a vote at 10/1/prevote and returns a conflicting one
+func setupConflict(t *testing.T, info *LastSignedInfo) (types.Signer, *types.Vote) {
+	signer, _ := newTestSigner()
+	require.Nil(t, info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)))
+	return signer, newVote(10, 1, types.VoteTypePrevote, blockID2)
+}
+
+func TestConflictFreeze(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	signer, conflicting := setupConflict(t, info)
+	assert.False(info.Frozen())
+
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", conflicting))
+	assert.True(info.Frozen())
+	next := newVote(11, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(ErrFrozen, info.SignVote(signer, "mychainid", next))
+	assert.EqualValues(10, info.LastHeight)
+
+	info.Unfreeze()
+	assert.NoError(info.SignVote(signer, "mychainid", next))
+}
+
+func TestConflictError(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, conflicting := setupConflict(t, info)
+
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", conflicting))
+	assert.False(info.Frozen())
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+}
+
+func TestConflictPanic(t *testing.T) {
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictPanic)
+	signer, conflicting := setupConflict(t, info)
+
+	assert.Panics(t, func() { info.SignVote(signer, "mychainid", conflicting) })
+	assert.EqualValues(t, 10, info.LastHeight)
+}
+
+func TestConflictEvidence(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictEvidence)
+	signer, conflicting := setupConflict(t, info)
+	var evidence [][]byte
+	info.SetOnConflictEvidence(func(lastSignBytes, signBytes []byte) {
+		evidence = append(evidence, lastSignBytes, signBytes)
+	})
+	lastSignBytes := []byte(info.LastSignBytes)
+
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", conflicting))
+	assert.Equal([][]byte{lastSignBytes, types.SignBytes("mychainid", conflicting)}, evidence)
+	assert.False(info.Frozen())
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+	// timestamp-only changes are no conflicts
+	evidence = nil
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.Empty(evidence)
+}
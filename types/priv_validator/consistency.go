@@ -0,0 +1,71 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+var (
+	ErrInconsistentState = errors.New("LastSignBytes or LastSignature set without a height/round/step")
+	ErrCorruptState      = errors.New("LastSignature is corrupt")
+)
+
+// checkConsistent returns ErrInconsistentState if there are sign bytes or
+// a signature, but the step is stepNone, as only Reset and NewLastSignedInfo
+// leave it. Nothing is signed at stepNone, so such a state is corrupt, and
+// Verify would panic on it at height 0.
+//
+// It also returns it for a LastExtension without a precommit to extend.
+func (info *LastSignedInfo) checkConsistent() error {
+	if info.LastExtension != nil && (info.LastStep != stepPrecommit || !info.hasSignBytes()) {
+		return ErrInconsistentState
+	}
+	if info.LastStep != stepNone {
+		return nil
+	}
+	if info.hasSignBytes() || !info.LastSignature.Empty() {
+		return ErrInconsistentState
+	}
+	return nil
+}
+
+// checkSignatureIntact returns ErrCorruptState if the LastSignature is missing
+// though there are LastSignBytes, or is zero, as a signature that failed to
+// decode may be left, eg. by a decoder that pads short data with zeros.
+// Set always records both, so either way the file is corrupt, and Verify
+// would panic on the first case.
+func (info *LastSignedInfo) checkSignatureIntact() error {
+	if info.LastSignature.Empty() {
+		if info.hasSignBytes() {
+			return ErrCorruptState
+		}
+		return nil
+	}
+	if info.LastSignature.IsZero() {
+		return ErrCorruptState
+	}
+	if ed25519, ok := info.LastSignature.Unwrap().(crypto.SignatureEd25519); ok &&
+		bytes.Equal(ed25519[:], make([]byte, len(ed25519))) {
+		return ErrCorruptState
+	}
+	return nil
+}
+
+// checkSignatureDecodes returns ErrCorruptState if the last_signature of the
+// JSON doesn't decode, to tell it apart from other failures to decode it.
+func checkSignatureDecodes(jsonBytes []byte) error {
+	var fields struct {
+		LastSignature json.RawMessage `json:"last_signature"`
+	}
+	if err := json.Unmarshal(jsonBytes, &fields); err != nil || fields.LastSignature == nil {
+		return nil
+	}
+	var sig Signature
+	if err := sig.UnmarshalJSON(fields.LastSignature); err != nil {
+		return ErrCorruptState
+	}
+	return nil
+}
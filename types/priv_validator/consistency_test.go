@@ -0,0 +1,77 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestLoadInconsistentState(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	sig := SignatureFromCrypto(crypto.SignatureEd25519{1}.Wrap())
+
+	// sign bytes and/or signature left behind at the initial step
+	cases := []struct {
+		height    int64
+		sig       Signature
+		signBytes []byte
+	}{
+		{0, sig, []byte("signbytes")},
+		{5, Signature{}, []byte("signbytes")},
+		{0, sig, nil},
+	}
+	for i, c := range cases {
+		info := NewLastSignedInfo()
+		info.LastHeight, info.LastSignature, info.LastSignBytes = c.height, c.sig, c.signBytes
+		require.Nil(info.SaveAs(filePath))
+		_, err := LoadLastSignedInfo(filePath)
+		if assert.Error(err, "case %v", i) {
+			assert.Contains(err.Error(), ErrInconsistentState.Error(), "case %v", i)
+		}
+	}
+
+	// once signed it loads fine, and Reset clears it consistently
+	info := NewLastSignedInfo()
+	require.Nil(info.Set(5, 0, stepPrevote, []byte("signbytes"), sig.Crypto()))
+	require.Nil(info.SaveAs(filePath))
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	require.Nil(loaded.Reset())
+	_, err = LoadLastSignedInfo(filePath)
+	assert.Nil(err)
+}
+
+func TestLoadCorruptSignature(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	state := `{"last_height":10,"last_round":0,"last_step":2,"last_signbytes":"7369676E6279746573"`
+	for i, c := range []string{
+		state + `,"last_signature":{"type":"tendermint/SignatureEd25519","value":"!!!!"}}`,        // bad base64
+		state + `,"last_signature":{"type":"tendermint/SignatureEd25519","value":"AQID"}}`,        // wrong length
+		state + `,"last_signature":{"type":"ed25519","data":"XYZ"}}`,                              // bad hex
+		state + `,"last_signature":{"type":"unknown","data":"0102"}}`,                             // unknown type
+		state + `,"last_signature":null}`,                                                         // missing
+		state + `,"last_signature":{"type":"ed25519","data":"` + strings.Repeat("00", 64) + `"}}`, // zeroed
+	} {
+		require.Nil(ioutil.WriteFile(filePath, []byte(c), 0600))
+		_, err := LoadLastSignedInfo(filePath)
+		assert.Equal(ErrCorruptState, err, "case %v", i)
+	}
+
+	// other errors are left as they are
+	require.Nil(ioutil.WriteFile(filePath, []byte(state+`,"last_signature":null,"last_round":"x"}`), 0600))
+	_, err := LoadLastSignedInfo(filePath)
+	assert.NotEqual(ErrCorruptState, err)
+	assert.Error(err)
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// SetDebugComparisons enables or disables logging the normalized sign bytes
+// when signing fails because they differ by more than the timestamp.
+// Both blobs are logged at debug level, with the timestamps set to the same
+// value, so the field that differs is easy to spot.
+// It's off by default since it writes sign bytes to the logs.
+func (info *LastSignedInfo) SetDebugComparisons(debug bool) {
+	info.debugComparisons = debug
+}
+
+// logConflict logs the sign bytes of votes or proposals, normalized by normalize.
+func (info *LastSignedInfo) logConflict(normalize func(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte),
+	lastSignBytes, newSignBytes []byte) {
+	if !info.debugComparisons {
+		return
+	}
+	lastNormalized, newNormalized := normalize(lastSignBytes, newSignBytes, info.now())
+	info.getLogger().Debug("Sign bytes differ by more than timestamp",
+		"last", string(lastNormalized), "new", string(newNormalized))
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestDebugComparisons(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetLogger(log.NewTMLogger(buf))
+	info.SetClock(NewManualClock(now))
+	signer, _ := newTestSigner()
+
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// off by default
+	assert.Error(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+	assert.Empty(buf.String())
+
+	info.SetDebugComparisons(true)
+	assert.Error(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+	logged := buf.String()
+	assert.Contains(logged, "Sign bytes differ by more than timestamp")
+	// both blobs, with the same timestamp
+	assert.Contains(logged, strings.Repeat("01", blockHashSize))
+	assert.Contains(logged, strings.Repeat("02", blockHashSize))
+	assert.Equal(2, strings.Count(logged, types.CanonicalTime(now)))
+
+	// nothing is logged when signing succeeds
+	buf.Reset()
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID2)))
+	assert.Empty(buf.String())
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// DebugDump returns the LastSignedInfo as a JSON-friendly map for ops scripts,
+// with the signature as hex and the LastSignBytes decoded into their canonical
+// vote or proposal. If the LastSignBytes can't be decoded, they're included as hex
+// under "last_signbytes_hex" instead. In hash-only mode, their hash is
+// included as hex under "last_signbytes_hash". It doesn't change anything.
+func (info *LastSignedInfo) DebugDump() (map[string]interface{}, error) {
+	dump := map[string]interface{}{
+		"last_height": info.LastHeight,
+		"last_round":  info.LastRound,
+		"last_step":   info.LastStep,
+	}
+
+	if !info.LastSignature.Empty() {
+		sigType, sigBytes, err := signatureBytes(info.LastSignature.Crypto())
+		if err != nil {
+			return nil, err
+		}
+		dump["last_signature_type"] = sigType
+		dump["last_signature"] = fmt.Sprintf("%X", sigBytes)
+	}
+
+	if info.LastSignBytes != nil {
+		if decoded, ok := decodeSignBytes(info.LastSignBytes); ok {
+			dump["last_signbytes"] = decoded
+		} else {
+			dump["last_signbytes_hex"] = fmt.Sprintf("%X", []byte(info.LastSignBytes))
+		}
+	}
+	if info.LastSignBytesHash != nil {
+		dump["last_signbytes_hash"] = fmt.Sprintf("%X", []byte(info.LastSignBytesHash))
+	}
+	return dump, nil
+}
+
+func signatureBytes(sig crypto.Signature) (string, []byte, error) {
+	switch inner := sig.Unwrap().(type) {
+	case crypto.SignatureEd25519:
+		return "ed25519", inner[:], nil
+	case crypto.SignatureSecp256k1:
+		return "secp256k1", []byte(inner), nil
+	default:
+		return "", nil, fmt.Errorf("Unknown signature type %T", inner)
+	}
+}
+
+// decodeSignBytes decodes the sign bytes of a vote or proposal
+func decodeSignBytes(signBytes []byte) (interface{}, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(signBytes, &fields); err != nil {
+		return nil, false
+	}
+	if _, ok := fields["vote"]; ok {
+		var vote types.CanonicalJSONOnceVote
+		if err := json.Unmarshal(signBytes, &vote); err != nil {
+			return nil, false
+		}
+		return vote, true
+	}
+	if _, ok := fields["proposal"]; ok {
+		var proposal types.CanonicalJSONOnceProposal
+		if err := json.Unmarshal(signBytes, &proposal); err != nil {
+			return nil, false
+		}
+		return proposal, true
+	}
+	return nil, false
+}
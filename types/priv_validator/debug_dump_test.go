@@ -0,0 +1,58 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestDebugDump(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	dump, err := info.DebugDump()
+	require.Nil(err)
+	assert.Equal(int64(10), dump["last_height"])
+	assert.Equal(1, dump["last_round"])
+	assert.Equal(stepPrevote, dump["last_step"])
+	assert.Equal("ed25519", dump["last_signature_type"])
+	assert.Len(dump["last_signature"], 128)
+	decoded, ok := dump["last_signbytes"].(types.CanonicalJSONOnceVote)
+	require.True(ok)
+	assert.Equal("mychainid", decoded.ChainID)
+	assert.EqualValues(blockID1.Hash, decoded.Vote.BlockID.Hash)
+
+	// it's JSON friendly
+	jsonBytes, err := json.Marshal(dump)
+	require.Nil(err)
+	assert.True(strings.Contains(string(jsonBytes), `"chain_id":"mychainid"`))
+
+	// a proposal
+	proposal := &types.Proposal{Height: 11, Round: 0, POLRound: -1}
+	require.Nil(info.Set(11, 0, stepPropose, types.SignBytes("mychainid", proposal), crypto.SignatureEd25519{1}.Wrap()))
+	dump, err = info.DebugDump()
+	require.Nil(err)
+	_, ok = dump["last_signbytes"].(types.CanonicalJSONOnceProposal)
+	assert.True(ok)
+
+	// garbage
+	require.Nil(info.Set(12, 0, stepPropose, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	dump, err = info.DebugDump()
+	require.Nil(err)
+	assert.Nil(dump["last_signbytes"])
+	assert.Equal("7369676E6279746573", dump["last_signbytes_hex"])
+
+	// nothing signed
+	dump, err = NewLastSignedInfo().DebugDump()
+	require.Nil(err)
+	assert.Len(dump, 3)
+}
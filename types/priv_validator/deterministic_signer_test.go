@@ -0,0 +1,53 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// DeterministicTestSigner is a types.Signer with an ed25519 key derived from
+// a seed. Ed25519 signatures only depend on the key and the message, so it
+// signs the same bytes the same way across runs and machines, eg. for golden
+// vectors. It's only built for tests: the key is as good as public.
+type DeterministicTestSigner struct {
+	privKey crypto.PrivKey
+}
+
+// NewDeterministicTestSigner returns a DeterministicTestSigner for the seed.
+func NewDeterministicTestSigner(seed string) *DeterministicTestSigner {
+	return &DeterministicTestSigner{crypto.GenPrivKeyEd25519FromSecret([]byte(seed)).Wrap()}
+}
+
+// Sign implements types.Signer.
+func (s *DeterministicTestSigner) Sign(msg []byte) (crypto.Signature, error) {
+	return s.privKey.Sign(msg), nil
+}
+
+// PubKey returns the public key, to verify the signatures.
+func (s *DeterministicTestSigner) PubKey() crypto.PubKey {
+	return s.privKey.PubKey()
+}
+
+func TestDeterministicTestSigner(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer := NewDeterministicTestSigner("seed")
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(NewLastSignedInfo().SignVote(signer, "mychainid", vote))
+	assert.True(signer.PubKey().VerifyBytes(types.SignBytes("mychainid", vote), vote.Signature))
+
+	// the same seed signs the same way
+	again := *vote
+	require.Nil(NewLastSignedInfo().SignVote(NewDeterministicTestSigner("seed"), "mychainid", &again))
+	assert.Equal(vote.Signature, again.Signature)
+
+	// and another seed doesn't
+	other := *vote
+	require.Nil(NewLastSignedInfo().SignVote(NewDeterministicTestSigner("other seed"), "mychainid", &other))
+	assert.NotEqual(vote.Signature, other.Signature)
+	assert.False(signer.PubKey().Equals(NewDeterministicTestSigner("other seed").PubKey()))
+}
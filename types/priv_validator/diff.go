@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// DiffSignBytes returns a readable, field-level diff of the sign bytes of two
+// votes or proposals, eg. those of a conflict, with one line per field that
+// differs, like:
+//
+//	Vote.BlockID.Hash: 0101... vs 0202...
+//
+// It returns an empty string if no field differs. If one is a vote and the
+// other a proposal, the diff says so instead of comparing their fields.
+// It returns an error if either can't be decoded.
+func DiffSignBytes(a, b []byte) (string, error) {
+	decodedA, ok := decodeSignBytes(a)
+	if !ok {
+		return "", fmt.Errorf("Cannot decode sign bytes %s", a)
+	}
+	decodedB, ok := decodeSignBytes(b)
+	if !ok {
+		return "", fmt.Errorf("Cannot decode sign bytes %s", b)
+	}
+
+	valueA, valueB := reflect.ValueOf(decodedA), reflect.ValueOf(decodedB)
+	if valueA.Type() != valueB.Type() {
+		return fmt.Sprintf("Type: %v vs %v", signBytesKind(decodedA), signBytesKind(decodedB)), nil
+	}
+	var lines []string
+	diffValues("", valueA, valueB, &lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+// diffValues appends a line to lines for each field of the structs a and b
+// that differs, recursively, named by its path from path.
+func diffValues(path string, a, b reflect.Value, lines *[]string) {
+	if a.Kind() == reflect.Struct {
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if path != "" {
+				name = path + "." + name
+			}
+			diffValues(name, a.Field(i), b.Field(i), lines)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		*lines = append(*lines, fmt.Sprintf("%v: %v vs %v", path, formatValue(a), formatValue(b)))
+	}
+}
+
+func formatValue(value reflect.Value) string {
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+		return fmt.Sprintf("%X", value.Bytes())
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
+
+func signBytesKind(decoded interface{}) string {
+	if _, ok := decoded.(types.CanonicalJSONOnceVote); ok {
+		return "vote"
+	}
+	return "proposal"
+}
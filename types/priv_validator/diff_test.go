@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestDiffSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	stamp := time.Date(2017, 12, 25, 3, 0, 1, 234000000, time.UTC)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	vote.Timestamp = stamp
+	other := *vote
+	other.BlockID = types.BlockID{Hash: blockID2.Hash, PartsHeader: types.PartSetHeader{Total: 2, Hash: []byte{0xAB}}}
+	other.Timestamp = stamp.Add(time.Second)
+	proposal := &types.Proposal{Height: 10, Round: 1, POLRound: -1, Timestamp: stamp}
+	otherProposal := *proposal
+	otherProposal.POLRound = 0
+
+	cases := []struct {
+		a, b []byte
+		diff string
+	}{
+		{types.SignBytes("mychainid", vote), types.SignBytes("mychainid", vote), ""},
+		{types.SignBytes("mychainid", vote), types.SignBytes("mychainid", &other),
+			"Vote.BlockID.Hash: 0101010101010101010101010101010101010101 vs 0202020202020202020202020202020202020202\n" +
+				"Vote.BlockID.PartsHeader.Hash:  vs AB\n" +
+				"Vote.BlockID.PartsHeader.Total: 0 vs 2\n" +
+				"Vote.Timestamp: 2017-12-25T03:00:01.234Z vs 2017-12-25T03:00:02.234Z"},
+		{types.SignBytes("mychainid", vote), types.SignBytes("otherchainid", vote),
+			"ChainID: mychainid vs otherchainid"},
+		{types.SignBytes("mychainid", proposal), types.SignBytes("mychainid", &otherProposal),
+			"Proposal.POLRound: -1 vs 0"},
+		{types.SignBytes("mychainid", vote), types.SignBytes("mychainid", proposal),
+			"Type: vote vs proposal"},
+	}
+	for i, c := range cases {
+		diff, err := DiffSignBytes(c.a, c.b)
+		assert.Nil(err, "case %d", i)
+		assert.Equal(c.diff, diff, "case %d", i)
+	}
+
+	_, err := DiffSignBytes(types.SignBytes("mychainid", vote), []byte("garbage"))
+	assert.Error(err)
+	_, err = DiffSignBytes([]byte("garbage"), types.SignBytes("mychainid", vote))
+	assert.Error(err)
+}
@@ -0,0 +1,35 @@
+package types
+
+import "github.com/tendermint/tendermint/types"
+
+// Flusher is implemented by SignerStates whose Save returns before the state
+// is on stable storage, eg. writing in the background.
+// FlushNow returns once everything saved so far is on stable storage.
+type Flusher interface {
+	FlushNow() error
+}
+
+// SignVoteDurable is like SignVote, but also returns whether the high-water
+// mark that covers the vote is on stable storage, so the caller can hold the
+// vote back until it is. The contract is:
+//
+//   - the state is saved before the signature is set on the vote, so a crash
+//     after broadcasting can't lose the high-water mark and lead to re-signing;
+//   - saving to the filePath, or to a SignInfoDB, is synchronous, so it's
+//     durable as soon as SignVote returns;
+//   - with a SignerState that implements Flusher, FlushNow is called before returning,
+//     and if it fails, the error is returned with durable false: the vote
+//     is signed, but must not be broadcast;
+//   - with no filePath or SignerState, nothing is persisted and durable is false.
+func (info *LastSignedInfo) SignVoteDurable(signer types.Signer, chainID string, vote *types.Vote) (durable bool, err error) {
+	if err := info.SignVote(signer, chainID, vote); err != nil {
+		return false, err
+	}
+	if flusher, ok := info.store.(Flusher); ok {
+		if err := flusher.FlushNow(); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return info.store != nil || info.filePath != "", nil
+}
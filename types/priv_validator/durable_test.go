@@ -0,0 +1,82 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// asyncState is a SignerState whose saves are only durable once flushed.
+type asyncState struct {
+	saved, flushed *LastSignedInfo
+	flushErr       error
+}
+
+func (as *asyncState) Load() (*LastSignedInfo, error) {
+	return as.flushed, nil
+}
+
+func (as *asyncState) Save(info *LastSignedInfo) error {
+	as.saved = info
+	return nil
+}
+
+func (as *asyncState) FlushNow() error {
+	if as.flushErr != nil {
+		return as.flushErr
+	}
+	as.flushed = as.saved
+	return nil
+}
+
+func TestSignVoteDurable(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, _ := newTestSigner()
+	vote := func(height int64) *types.Vote {
+		return newVote(height, 0, types.VoteTypePrevote, blockID1)
+	}
+
+	// in memory only
+	info := NewLastSignedInfo()
+	durable, err := info.SignVoteDurable(signer, "mychainid", vote(1))
+	assert.Nil(err)
+	assert.False(durable)
+
+	// synchronous file and db
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	assert.Nil(info.SetFilePath(filePath))
+	durable, err = info.SignVoteDurable(signer, "mychainid", vote(2))
+	assert.Nil(err)
+	assert.True(durable)
+	info.SetSignerState(NewSignInfoDB(dbm.NewMemDB()))
+	durable, err = info.SignVoteDurable(signer, "mychainid", vote(3))
+	assert.Nil(err)
+	assert.True(durable)
+
+	// asynchronous state is flushed
+	state := &asyncState{}
+	info.SetSignerState(state)
+	durable, err = info.SignVoteDurable(signer, "mychainid", vote(4))
+	assert.Nil(err)
+	assert.True(durable)
+	assert.EqualValues(4, state.flushed.LastHeight)
+
+	state.flushErr = errors.New("disk full")
+	v := vote(5)
+	durable, err = info.SignVoteDurable(signer, "mychainid", v)
+	assert.Equal(state.flushErr, err)
+	assert.False(durable)
+	assert.False(v.Signature.Empty())
+
+	// not signed at all
+	durable, err = info.SignVoteDurable(signer, "mychainid", vote(1))
+	assert.Equal(ErrHeightRegression, err)
+	assert.False(durable)
+}
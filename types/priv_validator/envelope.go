@@ -0,0 +1,154 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+)
+
+// envelopeVersion is the version of the Envelope ExportEnvelope writes,
+// and the only one ImportEnvelope reads.
+const envelopeVersion = 1
+
+var (
+	ErrEnvelopeMismatch = errors.New("Envelope does not match the state it carries")
+)
+
+// Envelope is a LastSignedInfo as exported by ExportEnvelope, with metadata
+// to validate it on import.
+type Envelope struct {
+	Version int `json:"version"`
+	// The chain signed for, if known, from the ChainID or the LastSignBytes.
+	ChainID string `json:"chain_id,omitempty"`
+	// The type of the key, "ed25519" or "secp256k1", if known,
+	// from the key bound by RotateKey or the LastSignature.
+	KeyType     string     `json:"key_type,omitempty"`
+	Seq         uint64     `json:"seq"`
+	Fingerprint data.Bytes `json:"fingerprint"`
+	// The LastSignedInfo, as LoadLastSignedInfo reads it.
+	State json.RawMessage `json:"state"`
+}
+
+// ExportEnvelope returns the LastSignedInfo in a versioned Envelope, as JSON,
+// eg. to move the validator to another machine with ImportEnvelope.
+func (info *LastSignedInfo) ExportEnvelope() ([]byte, error) {
+	state, err := encodeBytes(JSONCodec{}, info)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := info.signedChainID()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		Version:     envelopeVersion,
+		ChainID:     chainID,
+		KeyType:     info.keyType(),
+		Seq:         info.Seq,
+		Fingerprint: info.Fingerprint(),
+		State:       state,
+	})
+}
+
+// ImportEnvelope returns the LastSignedInfo exported with ExportEnvelope,
+// not persisted anywhere, once validated:
+//
+//   - the version must be known, and the state consistent and intact;
+//   - it must be for expectedChainID, by the envelope, the chain it's bound
+//     to (see Bind) and the LastSignBytes, otherwise it returns ErrChainMismatch;
+//   - the key type, Seq and fingerprint must be those of the state, otherwise
+//     it returns ErrEnvelopeMismatch, as must a SignHash without a Seq;
+//   - the signatures must verify against expectedPubKey, the key of the
+//     validator, and it must not be bound to another key (see RotateKey),
+//     see VerifyAgainstExpected.
+//
+// So nothing signed on the old machine is lost, the imported state should be
+// reconciled with any state already on the new one, see ReconcileSources,
+// before being saved.
+func ImportEnvelope(b []byte, expectedChainID string, expectedPubKey crypto.PubKey) (*LastSignedInfo, error) {
+	if expectedChainID == "" {
+		return nil, ErrEmptyChainID
+	}
+	if expectedPubKey.Empty() {
+		return nil, errors.New("Cannot import an envelope without the key of the validator")
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Version != envelopeVersion {
+		return nil, fmt.Errorf("Unknown envelope version %v, it may have been written by a newer version", envelope.Version)
+	}
+	info, err := decodeBytes(JSONCodec{}, envelope.State)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.ChainID != "" && envelope.ChainID != expectedChainID {
+		return nil, ErrChainMismatch
+	}
+	chainID, err := info.signedChainID()
+	if err != nil {
+		return nil, err
+	}
+	if chainID != "" && chainID != expectedChainID {
+		return nil, ErrChainMismatch
+	}
+	if chainID != envelope.ChainID || info.keyType() != envelope.KeyType ||
+		info.Seq != envelope.Seq || !bytes.Equal(info.Fingerprint(), envelope.Fingerprint) {
+		return nil, ErrEnvelopeMismatch
+	}
+	if info.SignHash != nil && info.Seq == 0 {
+		return nil, ErrEnvelopeMismatch
+	}
+	if err := info.VerifyAgainstExpected(expectedPubKey); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// signedChainID returns the chain the LastSignedInfo is bound to, or else the
+// one of the LastSignBytes, if any. They must agree.
+func (info *LastSignedInfo) signedChainID() (string, error) {
+	var signedFor string
+	decoded, _ := decodeSignBytes(info.LastSignBytes)
+	switch decoded := decoded.(type) {
+	case types.CanonicalJSONOnceVote:
+		signedFor = decoded.ChainID
+	case types.CanonicalJSONOnceProposal:
+		signedFor = decoded.ChainID
+	}
+	if info.ChainID != "" && signedFor != "" && signedFor != info.ChainID {
+		return "", ErrChainMismatch
+	}
+	if info.ChainID != "" {
+		return info.ChainID, nil
+	}
+	return signedFor, nil
+}
+
+// keyType returns the type of the key bound by RotateKey, or else of the
+// LastSignature, if any.
+func (info *LastSignedInfo) keyType() string {
+	if bound := info.boundKey(); !bound.Empty() {
+		switch bound.Unwrap().(type) {
+		case crypto.PubKeyEd25519:
+			return "ed25519"
+		case crypto.PubKeySecp256k1:
+			return "secp256k1"
+		}
+		return ""
+	}
+	switch info.LastSignature.Unwrap().(type) {
+	case crypto.SignatureEd25519:
+		return "ed25519"
+	case crypto.SignatureSecp256k1:
+		return "secp256k1"
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestEnvelope(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, pub := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	b, err := info.ExportEnvelope()
+	require.Nil(err)
+
+	var envelope Envelope
+	require.Nil(json.Unmarshal(b, &envelope))
+	assert.Equal(1, envelope.Version)
+	assert.Equal("mychainid", envelope.ChainID)
+	assert.Equal("ed25519", envelope.KeyType)
+	assert.EqualValues(1, envelope.Seq)
+
+	imported, err := ImportEnvelope(b, "mychainid", pub)
+	require.Nil(err)
+	assert.Equal(info.Snapshot(), imported.Snapshot())
+	assert.Equal(info.Fingerprint(), imported.Fingerprint())
+
+	_, err = ImportEnvelope(b, "otherchainid", pub)
+	assert.Equal(ErrChainMismatch, err)
+	_, err = ImportEnvelope(b, "", pub)
+	assert.Equal(ErrEmptyChainID, err)
+	_, err = ImportEnvelope(b, "mychainid", crypto.PubKey{})
+	assert.Error(err)
+
+	// the signatures must be by the key of the validator
+	_, otherPub := newTestSigner()
+	_, err = ImportEnvelope(b, "mychainid", otherPub)
+	assert.Equal(ErrBadSignature, err)
+
+	// metadata that doesn't match the state
+	tamper := func(change func(*Envelope)) []byte {
+		var tampered Envelope
+		require.Nil(json.Unmarshal(b, &tampered))
+		change(&tampered)
+		bz, err := json.Marshal(tampered)
+		require.Nil(err)
+		return bz
+	}
+	for name, change := range map[string]func(*Envelope){
+		"seq":         func(e *Envelope) { e.Seq = 7 },
+		"key type":    func(e *Envelope) { e.KeyType = "secp256k1" },
+		"fingerprint": func(e *Envelope) { e.Fingerprint = make([]byte, 32) },
+		"chain":       func(e *Envelope) { e.ChainID = "" },
+	} {
+		_, err = ImportEnvelope(tamper(change), "mychainid", pub)
+		assert.Equal(ErrEnvelopeMismatch, err, name)
+	}
+	_, err = ImportEnvelope(tamper(func(e *Envelope) { e.Version = 2 }), "mychainid", pub)
+	assert.Error(err)
+
+	// a state bound to another key is refused
+	require.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	info.KeyRotation = &KeyRotation{NewPubKey: otherPub}
+	b, err = info.ExportEnvelope()
+	require.Nil(err)
+	_, err = ImportEnvelope(b, "mychainid", pub)
+	assert.Equal(ErrKeyMismatch, err)
+	_, err = ImportEnvelope(b, "mychainid", otherPub)
+	assert.Equal(ErrBadSignature, err)
+}
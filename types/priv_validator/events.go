@@ -0,0 +1,127 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// eventBufferSize is how many SignerEvents the stream of Events holds
+// before dropping new ones.
+const eventBufferSize = 1024
+
+// SignerEventType tells the kinds of SignerEvent apart.
+type SignerEventType int
+
+const (
+	// EventSigned is a fresh signature, recorded.
+	EventSigned SignerEventType = iota
+	// EventReused is the LastSignature, reused.
+	EventReused
+	// EventRejected is a rejected attempt to sign, as passed to SetOnReject.
+	EventRejected
+	// EventConflict is an attempt to sign data conflicting with the data
+	// signed at the same height/round/step. It follows its EventRejected.
+	EventConflict
+	// EventFrozen is signing frozen, see Frozen.
+	EventFrozen
+	// EventReset is a Reset.
+	EventReset
+	// EventTombstoned is signing stopped for good, see Tombstone.
+	EventTombstoned
+)
+
+func (t SignerEventType) String() string {
+	switch t {
+	case EventSigned:
+		return "Signed"
+	case EventReused:
+		return "Reused"
+	case EventRejected:
+		return "Rejected"
+	case EventConflict:
+		return "Conflict"
+	case EventFrozen:
+		return "Frozen"
+	case EventReset:
+		return "Reset"
+	case EventTombstoned:
+		return "Tombstoned"
+	default:
+		return fmt.Sprintf("SignerEventType(%d)", int(t))
+	}
+}
+
+// SignerEvent is an event of the lifecycle of a LastSignedInfo, of the Type.
+// The fields that don't apply to the Type are left empty.
+type SignerEvent struct {
+	Type SignerEventType
+	// Number counts the events from 1, in the order they happened,
+	// so a gap means events were dropped, see Events.
+	Number uint64
+
+	// The height/round/step signed, or attempted for EventRejected and
+	// EventConflict, or the latest one for the others.
+	Height int64
+	Round  int
+	Step   int8
+
+	// The sign bytes signed, or attempted for EventConflict.
+	SignBytes []byte
+	// The signature for EventSigned and EventReused.
+	Signature crypto.Signature
+	// Why it was signed, for EventSigned and EventReused.
+	Reason SignReason
+	// Why it was rejected, for EventRejected.
+	Err error
+	// Why signing was frozen or tombstoned.
+	Cause string
+
+	Time time.Time
+}
+
+// Events returns a stream of all the SignerEvents, in the order they happen,
+// starting with the first call, which creates it. Later calls return the same
+// stream. The events are sent without ever blocking signing: if the consumer
+// falls more than 1024 events behind, new events are dropped, which shows as
+// a gap in their Number.
+// Like the rest of the LastSignedInfo, the first call must be serialized with
+// signing by the caller.
+func (info *LastSignedInfo) Events() <-chan SignerEvent {
+	if info.events == nil {
+		info.events = make(chan SignerEvent, eventBufferSize)
+	}
+	return info.events
+}
+
+// emitEvent numbers and sends the event, if there's a stream, or drops it.
+func (info *LastSignedInfo) emitEvent(event SignerEvent) {
+	if info.events == nil {
+		return
+	}
+	info.eventNumber++
+	event.Number = info.eventNumber
+	event.Time = info.now()
+	select {
+	case info.events <- event:
+	default:
+	}
+}
+
+// emitStateEvent emits an event about the latest height/round/step.
+func (info *LastSignedInfo) emitStateEvent(typ SignerEventType, cause string) {
+	info.emitEvent(SignerEvent{
+		Type:   typ,
+		Height: info.LastHeight,
+		Round:  info.LastRound,
+		Step:   info.LastStep,
+		Cause:  cause,
+	})
+}
+
+// freeze freezes signing, see Frozen.
+func (info *LastSignedInfo) freeze(cause string) {
+	info.frozen = true
+	info.emitStateEvent(EventFrozen, cause)
+}
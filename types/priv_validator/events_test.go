@@ -0,0 +1,120 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func drainEvents(events <-chan SignerEvent) []SignerEvent {
+	var drained []SignerEvent
+	for {
+		select {
+		case event := <-events:
+			drained = append(drained, event)
+		default:
+			return drained
+		}
+	}
+}
+
+func eventTypes(events []SignerEvent) []SignerEventType {
+	types := make([]SignerEventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestEvents(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := NewLastSignedInfo()
+	info.SetClock(NewManualClock(now))
+	info.SetConflictStrategy(ConflictTombstone)
+	signer, _ := newTestSigner()
+
+	// nothing is kept before the first call
+	require.Nil(info.SignVote(signer, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)))
+	events := info.Events()
+	assert.True(events == info.Events())
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Error(info.SignVote(signer, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)))
+	conflicting := newVote(10, 0, types.VoteTypePrevote, blockID2)
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", conflicting))
+	require.Nil(info.ClearTombstone(info.TombstoneInfo.Reason))
+	require.Nil(info.Reset())
+
+	got := drainEvents(events)
+	assert.Equal([]SignerEventType{EventSigned, EventReused, EventRejected,
+		EventRejected, EventConflict, EventTombstoned, EventReset}, eventTypes(got))
+	for i, event := range got {
+		assert.EqualValues(i+1, event.Number)
+		assert.Equal(now, event.Time)
+	}
+
+	signed := got[0]
+	assert.EqualValues(10, signed.Height)
+	assert.Equal(stepPrevote, signed.Step)
+	assert.Equal(HeightAdvanced, signed.Reason)
+	assert.Equal(vote.Signature, signed.Signature)
+	assert.Equal(types.SignBytes("mychainid", vote), signed.SignBytes)
+	assert.Equal(Reused, got[1].Reason)
+	assert.Equal(vote.Signature, got[1].Signature)
+	assert.EqualValues(9, got[2].Height)
+	assert.Equal(ErrHeightRegression, got[2].Err)
+	assert.Equal(ErrConflictingData, got[3].Err)
+	assert.Equal(types.SignBytes("mychainid", conflicting), got[4].SignBytes)
+	assert.NotEmpty(got[5].Cause)
+	assert.EqualValues(10, got[5].Height)
+	assert.EqualValues(0, got[6].Height)
+}
+
+func TestEventsFrozen(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictFreeze)
+	signer, _ := newTestSigner()
+	events := info.Events()
+
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Error(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+	assert.Equal(ErrFrozen, info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+	got := drainEvents(events)
+	assert.Equal([]SignerEventType{EventSigned, EventRejected, EventConflict, EventFrozen, EventRejected}, eventTypes(got))
+	assert.NotEmpty(got[3].Cause)
+	assert.Equal(ErrFrozen, got[4].Err)
+}
+
+func TestEventsNeverBlock(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	events := info.Events()
+	for i := 0; i < eventBufferSize+10; i++ {
+		require.Nil(info.Reset())
+	}
+	assert.Len(drainEvents(events), eventBufferSize)
+
+	// the dropped events show as a gap
+	require.Nil(info.Reset())
+	event := <-events
+	assert.EqualValues(eventBufferSize+11, event.Number)
+}
+
+func TestSignerEventTypeString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("Signed", EventSigned.String())
+	assert.Equal("Tombstoned", EventTombstoned.String())
+	assert.Equal("SignerEventType(42)", SignerEventType(42).String())
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// blockHashSize is the size of block hashes, made with ripemd160 (see merkle.SimpleHashFromMap).
+const blockHashSize = 20
+
+// BuildConflictingVotes turns the LastSignBytes and the attempted sign bytes of a
+// detected conflict back into votes, with their signatures lastSig and attemptedSig
+// attached, eg. to build a DuplicateVoteEvidence from them.
+// The ValidatorAddress of both votes is set from pub.
+// The sign bytes don't include the ValidatorIndex, so the caller must set it.
+// It returns an error if either side isn't a vote or has a malformed block hash,
+// they're not from the same chain, or a signature doesn't verify with pub.
+func BuildConflictingVotes(last []byte, lastSig crypto.Signature, attempted []byte, attemptedSig crypto.Signature,
+	pub crypto.PubKey) (*types.Vote, *types.Vote, error) {
+	lastChainID, voteA, err := parseCanonicalVote(last)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing last sign bytes: %v", err)
+	}
+	attemptedChainID, voteB, err := parseCanonicalVote(attempted)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing attempted sign bytes: %v", err)
+	}
+	if lastChainID != attemptedChainID {
+		return nil, nil, fmt.Errorf("Votes are from different chains: %v and %v", lastChainID, attemptedChainID)
+	}
+	if lastSig.Empty() || !pub.VerifyBytes(last, lastSig) {
+		return nil, nil, errors.New("Invalid signature of the last sign bytes")
+	}
+	if attemptedSig.Empty() || !pub.VerifyBytes(attempted, attemptedSig) {
+		return nil, nil, errors.New("Invalid signature of the attempted sign bytes")
+	}
+
+	voteA.ValidatorAddress = pub.Address()
+	voteB.ValidatorAddress = pub.Address()
+	voteA.Signature = lastSig
+	voteB.Signature = attemptedSig
+	return voteA, voteB, nil
+}
+
+// parseCanonicalVote parses sign bytes produced by canonicalVoteBytesV1
+func parseCanonicalVote(signBytes []byte) (string, *types.Vote, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(signBytes, &fields); err != nil {
+		return "", nil, err
+	}
+	if _, ok := fields["vote"]; !ok {
+		return "", nil, errors.New("Sign bytes are not a vote")
+	}
+
+	var canonical canonicalJSONOnceVoteV1
+	if err := json.Unmarshal(signBytes, &canonical); err != nil {
+		return "", nil, err
+	}
+	cv := canonical.Vote
+	if !types.IsVoteTypeValid(cv.Type) {
+		return "", nil, fmt.Errorf("Invalid vote type %v", cv.Type)
+	}
+	if err := validateBlockHash(cv.BlockID.Hash); err != nil {
+		return "", nil, err
+	}
+	timestamp, err := time.Parse(canonicalTimeFormatV1, cv.Timestamp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	vote := &types.Vote{
+		Height:    cv.Height,
+		Round:     cv.Round,
+		Timestamp: timestamp,
+		Type:      cv.Type,
+		BlockID:   cv.BlockID.blockID(),
+	}
+	return canonical.ChainID, vote, nil
+}
+
+// returns an error unless the hash is empty (a nil vote) or has the size of a block hash
+func validateBlockHash(hash []byte) error {
+	if len(hash) != 0 && len(hash) != blockHashSize {
+		return fmt.Errorf("Invalid block hash size %v, expected %v", len(hash), blockHashSize)
+	}
+	return nil
+}
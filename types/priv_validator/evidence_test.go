@@ -0,0 +1,110 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBuildConflictingVotes(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, pub := newTestSigner()
+	blockID := types.BlockID{Hash: []byte("hash_hash_hash_hash_"), PartsHeader: types.PartSetHeader{Total: 3, Hash: []byte("parts")}}
+
+	vote := newVote(10, 1, types.VoteTypePrecommit, blockID)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	conflicting := newVote(10, 1, types.VoteTypePrecommit, blockID2)
+	attempted := types.SignBytes("mychainid", conflicting)
+	_, err := info.SignVoteWithReason(signer, "mychainid", conflicting)
+	require.Error(err)
+
+	// the conflicting vote signed elsewhere, eg. by a second instance
+	sigB, err := signer.Sign(attempted)
+	require.Nil(err)
+	voteA, voteB, err := BuildConflictingVotes(info.LastSignBytes, info.LastSignature.Crypto(), attempted, sigB, pub)
+	require.Nil(err)
+
+	// the votes reproduce the exact sign bytes
+	assert.Equal([]byte(info.LastSignBytes), types.SignBytes("mychainid", voteA))
+	assert.Equal(attempted, types.SignBytes("mychainid", voteB))
+	assert.EqualValues(pub.Address(), voteA.ValidatorAddress)
+	assert.EqualValues(pub.Address(), voteB.ValidatorAddress)
+	assert.True(voteA.BlockID.Equals(blockID))
+	assert.True(voteB.BlockID.Equals(blockID2))
+
+	// the signatures are attached, so they make valid evidence
+	assert.Equal(info.LastSignature.Crypto(), voteA.Signature)
+	assert.Equal(sigB, voteB.Signature)
+	evidence := &types.DuplicateVoteEvidence{PubKey: pub, VoteA: voteA, VoteB: voteB}
+	assert.Nil(evidence.Verify("mychainid"))
+}
+
+func TestBuildConflictingVotesNilVote(t *testing.T) {
+	signer, pub := newTestSigner()
+	vote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	nilVote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, types.BlockID{}))
+	sigA, sigB := mustSign(t, signer, vote), mustSign(t, signer, nilVote)
+
+	voteA, voteB, err := BuildConflictingVotes(vote, sigA, nilVote, sigB, pub)
+	assert.Nil(t, err)
+	assert.True(t, voteB.BlockID.IsZero())
+	evidence := &types.DuplicateVoteEvidence{PubKey: pub, VoteA: voteA, VoteB: voteB}
+	assert.Nil(t, evidence.Verify("mychainid"))
+}
+
+func TestBuildConflictingVotesErrors(t *testing.T) {
+	signer, pub := newTestSigner()
+	vote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	otherChain := types.SignBytes("otherchainid", newVote(10, 1, types.VoteTypePrevote, blockID2))
+	proposal := types.SignBytes("mychainid", &types.Proposal{Height: 10, Round: 1})
+	truncated := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote,
+		types.BlockID{Hash: blockID2.Hash[:blockHashSize-1]}))
+	oversized := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote,
+		types.BlockID{Hash: append(blockID2.Hash, 0)}))
+
+	cases := []struct {
+		last, attempted []byte
+	}{
+		{vote, proposal},
+		{proposal, vote},
+		{vote, []byte("garbage")},
+		{vote, otherChain},
+		{vote, []byte(`{"chain_id":"mychainid","vote":{"type":7}}`)},
+		{vote, truncated},
+		{vote, oversized},
+		{truncated, vote},
+	}
+	for i, c := range cases {
+		_, _, err := BuildConflictingVotes(c.last, mustSign(t, signer, c.last), c.attempted, mustSign(t, signer, c.attempted), pub)
+		assert.Error(t, err, "case %d", i)
+	}
+
+	// the signatures must be over the sign bytes, by pub
+	nilVote := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, types.BlockID{}))
+	otherSigner, _ := newTestSigner()
+	sigA, sigB := mustSign(t, signer, vote), mustSign(t, signer, nilVote)
+	sigCases := []struct {
+		lastSig, attemptedSig crypto.Signature
+	}{
+		{crypto.Signature{}, sigB},
+		{sigA, crypto.Signature{}},
+		{sigB, sigA},
+		{sigA, mustSign(t, otherSigner, nilVote)},
+	}
+	for i, c := range sigCases {
+		_, _, err := BuildConflictingVotes(vote, c.lastSig, nilVote, c.attemptedSig, pub)
+		assert.Error(t, err, "case %d", i)
+	}
+}
+
+func mustSign(t *testing.T, signer types.Signer, signBytes []byte) crypto.Signature {
+	sig, err := signer.Sign(signBytes)
+	require.Nil(t, err)
+	return sig
+}
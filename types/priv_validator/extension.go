@@ -0,0 +1,65 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrNoPrecommit = errors.New("No precommit signed to extend")
+)
+
+// SignedExtension is the sign bytes and signature of the extension of a
+// precommit, for votes that carry signed extensions.
+type SignedExtension struct {
+	SignBytes data.Bytes `json:"sign_bytes"`
+	Signature Signature  `json:"signature"`
+}
+
+// SignExtension signs the extension of the last signed precommit, and records
+// it with it, so evidence can carry both. It returns ErrNoPrecommit unless the
+// latest state is a signed precommit.
+//
+// The extension is part of what's signed at that height/round/step: signing
+// the same extension bytes again reuses the signature, and different ones are
+// ErrConflictingData. When the precommit itself is reused, only its timestamp
+// may differ, so its extension still goes with it.
+// The extension is dropped with the precommit, once anything else is recorded.
+func (info *LastSignedInfo) SignExtension(signer types.Signer, extensionSignBytes []byte) (crypto.Signature, error) {
+	if info.Tombstoned() {
+		return crypto.Signature{}, ErrTombstoned
+	}
+	if info.LastStep != stepPrecommit || !info.hasSignBytes() {
+		return crypto.Signature{}, ErrNoPrecommit
+	}
+	if extension := info.LastExtension; extension != nil {
+		if !bytes.Equal(extension.SignBytes, extensionSignBytes) {
+			info.reject(info.LastHeight, info.LastRound, info.LastStep, ErrConflictingData)
+			return crypto.Signature{}, ErrConflictingData
+		}
+		return extension.Signature.Crypto(), nil
+	}
+
+	sig, err := signer.Sign(extensionSignBytes)
+	if err != nil {
+		return crypto.Signature{}, err
+	}
+	info.LastExtension = &SignedExtension{copyBytes(extensionSignBytes), SignatureFromCrypto(sig)}
+	if err := info.persist(); err != nil {
+		return crypto.Signature{}, err
+	}
+	return sig, nil
+}
+
+// LastSignedExtension is like LastSigned, for the extension of the last
+// precommit. ok is false if none was signed.
+func (info *LastSignedInfo) LastSignedExtension() (bytes []byte, sig crypto.Signature, ok bool) {
+	if info.LastExtension == nil {
+		return nil, crypto.Signature{}, false
+	}
+	return copyBytes(info.LastExtension.SignBytes), copySignature(info.LastExtension.Signature).Crypto(), true
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestSignExtension(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	info.SetConflictStrategy(ConflictError)
+	signer, pubKey := newTestSigner()
+
+	_, err := info.SignExtension(signer, []byte("extension"))
+	assert.Equal(ErrNoPrecommit, err)
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	_, err = info.SignExtension(signer, []byte("extension"))
+	assert.Equal(ErrNoPrecommit, err)
+
+	precommit := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", precommit))
+	sig, err := info.SignExtension(signer, []byte("extension"))
+	require.Nil(err)
+	assert.True(pubKey.VerifyBytes([]byte("extension"), sig))
+
+	// the same extension is reused, another one conflicts
+	again, err := info.SignExtension(signer, []byte("extension"))
+	require.Nil(err)
+	assert.Equal(sig, again)
+	_, err = info.SignExtension(signer, []byte("other extension"))
+	assert.Equal(ErrConflictingData, err)
+
+	// it's persisted, and goes with the precommit when it's reused
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	later := *precommit
+	later.Timestamp = precommit.Timestamp.Add(time.Second)
+	require.Nil(loaded.SignVote(signer, "mychainid", &later))
+	extensionBytes, extensionSig, ok := loaded.LastSignedExtension()
+	require.True(ok)
+	assert.Equal([]byte("extension"), extensionBytes)
+	assert.Equal(sig, extensionSig)
+	extensionBytes[0] = 'X'
+	assert.Equal([]byte("extension"), []byte(loaded.LastExtension.SignBytes))
+
+	// and is dropped with it
+	require.Nil(loaded.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	_, _, ok = loaded.LastSignedExtension()
+	assert.False(ok)
+
+	// an extension without its precommit is inconsistent
+	info.LastStep = stepPrevote
+	require.Nil(info.SaveAs(filePath))
+	_, err = LoadLastSignedInfo(filePath)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), ErrInconsistentState.Error())
+	}
+}
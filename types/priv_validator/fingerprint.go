@@ -0,0 +1,57 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Fingerprint returns a 32 byte digest of the signing history, for HA peers to
+// compare cheaply: if theirs differ, they diverged and must reconcile (see
+// ReconcileSources). It's the SHA-256 of, in order:
+//
+//	the Sequence, as 8 bytes big-endian
+//	the height (8 bytes), round (4 bytes) and step (1 byte), big-endian
+//	the SignHash, the rolling hash of the signatures recorded (32 bytes, or none)
+//
+// The SignHash starts empty, and each signature recorded with Set updates it to
+// the SHA-256 of the previous SignHash, the HRS as above, then the sign bytes
+// and the signature, each prefixed with its length as 4 bytes big-endian.
+// So peers that recorded the same signatures in the same order, from the same
+// start, have the same fingerprint.
+//
+// Like the Seq, the SignHash is persisted and kept on Reset. Restore only sets
+// it from the snapshot along with the Seq.
+func (info *LastSignedInfo) Fingerprint() []byte {
+	h := sha256.New()
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], info.Seq)
+	h.Write(seq[:])
+	h.Write(encodeHRS(info.LastHeight, info.LastRound, info.LastStep))
+	h.Write(info.SignHash)
+	return h.Sum(nil)
+}
+
+// recordSignHash updates the SignHash with a signature recorded with Set.
+func (info *LastSignedInfo) recordSignHash(height int64, round int, step int8, signBytes, sig []byte) {
+	h := sha256.New()
+	h.Write(info.SignHash)
+	h.Write(encodeHRS(height, round, step))
+	writeWithLength(h.Write, signBytes)
+	writeWithLength(h.Write, sig)
+	info.SignHash = h.Sum(nil)
+}
+
+func encodeHRS(height int64, round int, step int8) []byte {
+	bz := make([]byte, 8+4+1)
+	binary.BigEndian.PutUint64(bz, uint64(height))
+	binary.BigEndian.PutUint32(bz[8:], uint32(round))
+	bz[12] = byte(step)
+	return bz
+}
+
+func writeWithLength(write func([]byte) (int, error), bz []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(bz)))
+	write(length[:])
+	write(bz)
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestFingerprint(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer := NewDeterministicTestSigner("fingerprint")
+	timestamp := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	signVote := func(info *LastSignedInfo, height int64, typ byte, blockID types.BlockID) {
+		vote := newVote(height, 0, typ, blockID)
+		vote.Timestamp = timestamp
+		require.Nil(info.SignVote(signer, "mychainid", vote))
+	}
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	a := NewLastSignedInfo()
+	require.Nil(a.SetFilePath(filePath))
+	b := NewLastSignedInfo()
+	assert.Len(a.Fingerprint(), 32)
+	assert.Equal(a.Fingerprint(), b.Fingerprint())
+
+	// the same signatures, the same fingerprint
+	for _, info := range []*LastSignedInfo{a, b} {
+		signVote(info, 10, types.VoteTypePrevote, blockID1)
+		signVote(info, 10, types.VoteTypePrecommit, blockID1)
+	}
+	assert.Equal(a.Fingerprint(), b.Fingerprint())
+	assert.NotEqual(NewLastSignedInfo().Fingerprint(), a.Fingerprint())
+
+	// it survives a restart, and reuse doesn't change it
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.Equal(a.Fingerprint(), loaded.Fingerprint())
+	signVote(loaded, 10, types.VoteTypePrecommit, blockID1)
+	assert.Equal(a.Fingerprint(), loaded.Fingerprint())
+
+	// diverging histories that end at the same state differ
+	c := NewLastSignedInfo()
+	signVote(c, 10, types.VoteTypePrevote, blockID2)
+	signVote(c, 10, types.VoteTypePrecommit, blockID1)
+	assert.Equal(a.LastSignBytes, c.LastSignBytes)
+	assert.Equal(a.Seq, c.Seq)
+	assert.NotEqual(a.Fingerprint(), c.Fingerprint())
+
+	// advancing without signing changes it
+	fingerprint := a.Fingerprint()
+	require.Nil(a.AdvanceWithoutSigning(11, 0, stepPrevote))
+	assert.NotEqual(fingerprint, a.Fingerprint())
+
+	// reconciling keeps the history of the source that's ahead
+	signVote(b, 12, types.VoteTypePrevote, blockID1)
+	reconciled, err := ReconcileSources(a, b)
+	require.Nil(err)
+	assert.Equal(b.Fingerprint(), reconciled.Fingerprint())
+}
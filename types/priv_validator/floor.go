@@ -0,0 +1,17 @@
+package types
+
+import "errors"
+
+var (
+	ErrBelowFloor = errors.New("Height below floor")
+)
+
+// SetFloorHeight makes Verify reject any height below the given one with
+// ErrBelowFloor, whatever the latest height/round/step. It's meant for
+// validators that state-synced from a trusted height, since signing anything
+// before it is always wrong, even with a fresh file.
+// The floor is persisted if a filePath is set.
+func (info *LastSignedInfo) SetFloorHeight(height int64) error {
+	info.FloorHeight = height
+	return info.persist()
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestFloorHeight(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	require.Nil(info.SetFloorHeight(100))
+
+	// even on a fresh file
+	_, err := info.Verify(99, 0, stepPropose)
+	assert.Equal(ErrBelowFloor, err)
+	signer, _ := newTestSigner()
+	assert.Equal(ErrBelowFloor, info.SignVote(signer, "mychainid", newVote(1, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(int64(0), info.LastHeight)
+
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(100, 0, types.VoteTypePrevote, blockID1)))
+
+	// kept on restart
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(int64(100), loaded.FloorHeight)
+	require.Nil(loaded.Reset())
+	assert.Equal(int64(100), loaded.FloorHeight, "kept on Reset")
+	_, err = loaded.Verify(99, 0, stepPropose)
+	assert.Equal(ErrBelowFloor, err)
+	require.Nil(loaded.Restore(info.Snapshot()))
+	_, err = loaded.Verify(99, 5, stepPrecommit)
+	assert.Equal(ErrBelowFloor, err)
+}
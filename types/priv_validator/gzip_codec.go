@@ -0,0 +1,65 @@
+package types
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// GzipCodec is a StateCodec that compresses the encoding of Inner with gzip,
+// eg. to shrink a state file with long sign bytes. Only the persisted form is
+// compressed: the LastSignBytes are decompressed on load, and compared
+// uncompressed as usual.
+//
+// Decode also reads the uncompressed encoding of Inner, so a file or db can
+// switch to it without migration, and back by decoding it with Inner once.
+// For a single vote it roughly halves the size, at the cost of about ten
+// times the CPU and far more allocations per Save and load; see BenchmarkGzipCodec.
+type GzipCodec struct {
+	// Inner is the encoding that's compressed, JSONCodec if nil.
+	Inner StateCodec
+
+	// Level is the gzip compression level, gzip.DefaultCompression if 0.
+	Level int
+}
+
+// Encode implements StateCodec.
+func (c GzipCodec) Encode(w io.Writer, info *LastSignedInfo) error {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return err
+	}
+	if err := c.inner().Encode(zw, info); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode implements StateCodec.
+func (c GzipCodec) Decode(r io.Reader) (*LastSignedInfo, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return c.inner().Decode(br)
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return c.inner().Decode(zr)
+}
+
+func (c GzipCodec) inner() StateCodec {
+	if c.Inner == nil {
+		return JSONCodec{}
+	}
+	return c.Inner
+}
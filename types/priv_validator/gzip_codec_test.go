@@ -0,0 +1,57 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestGzipCodec(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	signer, _ := newTestSigner()
+
+	// an uncompressed file is read as is
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(tempFilePath))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)))
+	file := NewSignInfoFile(tempFilePath)
+	file.SetCodec(GzipCodec{})
+	loaded, err := file.Load()
+	require.Nil(err)
+	assert.Equal(info.LastSignBytes, loaded.LastSignBytes)
+
+	// and compressed once saved again
+	vote := newVote(11, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(loaded.SignVote(signer, "mychainid", vote))
+	fileBytes, err := ioutil.ReadFile(tempFilePath)
+	require.Nil(err)
+	assert.True(bytes.HasPrefix(fileBytes, gzipMagic))
+	loaded, err = file.Load()
+	require.Nil(err)
+	assert.Equal(int64(11), loaded.LastHeight)
+	assert.Equal(types.SignBytes("mychainid", vote), []byte(loaded.LastSignBytes))
+	assert.True(loaded.HasSignature(vote.Signature))
+
+	// the sign bytes are compared uncompressed
+	reused := newVote(11, 0, types.VoteTypePrevote, blockID1)
+	reason, err := loaded.SignVoteWithReason(signer, "mychainid", reused)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+
+	// with another level
+	encoded, err := encodeBytes(GzipCodec{Level: gzip.BestCompression}, loaded)
+	require.Nil(err)
+	decoded, err := decodeBytes(GzipCodec{}, encoded)
+	require.Nil(err)
+	assert.Equal(loaded.LastSignBytes, decoded.LastSignBytes)
+	_, err = encodeBytes(GzipCodec{Level: 42}, loaded)
+	assert.Error(err)
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// SetHashOnly makes Set store only the SHA-256 of the sign bytes, as the
+// LastSignBytesHash, instead of the LastSignBytes, to save memory and disk.
+//
+// The tradeoff: at the same height/round/step, SignVote and SignProposal can
+// only tell whether the new sign bytes are exactly the signed ones. If they
+// are, the LastSignature is reused; anything else is a conflict, even if only
+// the timestamp differs, since that needs the bytes to compare. So re-signing
+// after a crash with a new timestamp is handled as set with SetConflictStrategy,
+// and the evidence handler gets nil for the LastSignBytes.
+// Also without the bytes, LastSigned and ReusableSignBytes return nothing,
+// the Health has no LastSignAge, and LoadStrict can't verify the LastSignature.
+//
+// It only affects what's recorded from then on. It's off by default.
+func (info *LastSignedInfo) SetHashOnly(hashOnly bool) {
+	info.hashOnly = hashOnly
+}
+
+// hasSignBytes returns true if there are LastSignBytes, or their hash.
+func (info *LastSignedInfo) hasSignBytes() bool {
+	return info.LastSignBytes != nil || info.LastSignBytesHash != nil
+}
+
+// matchesSignBytes returns true if signBytes are exactly the LastSignBytes,
+// or hash to the LastSignBytesHash if only that is recorded.
+func (info *LastSignedInfo) matchesSignBytes(signBytes []byte) bool {
+	if info.LastSignBytes != nil {
+		return bytes.Equal(signBytes, info.LastSignBytes)
+	}
+	return info.LastSignBytesHash != nil && bytes.Equal(hashSignBytes(signBytes), info.LastSignBytesHash)
+}
+
+// recordSignBytes records signBytes, or only their hash in hash-only mode.
+func (info *LastSignedInfo) recordSignBytes(signBytes []byte) {
+	if info.hashOnly {
+		info.LastSignBytes = nil
+		info.LastSignBytesHash = hashSignBytes(signBytes)
+		return
+	}
+	info.LastSignBytes = signBytes
+	info.LastSignBytesHash = nil
+}
+
+func hashSignBytes(signBytes []byte) []byte {
+	hash := sha256.Sum256(signBytes)
+	return hash[:]
+}
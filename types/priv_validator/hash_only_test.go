@@ -0,0 +1,105 @@
+package types
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestHashOnlyStoresHash(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	tempFile, err := ioutil.TempFile("", "last_signed_info")
+	require.Nil(err)
+	defer os.Remove(tempFile.Name())
+
+	info := NewLastSignedInfo()
+	info.SetHashOnly(true)
+	require.Nil(info.SetFilePath(tempFile.Name()))
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	signBytes := types.SignBytes("mychainid", vote)
+	hash := sha256.Sum256(signBytes)
+	assert.Nil(info.LastSignBytes)
+	assert.Equal(hash[:], []byte(info.LastSignBytesHash))
+	_, _, ok := info.LastSigned()
+	assert.False(ok)
+
+	// the hash is persisted, and enough to tell the HRS was signed
+	loaded, err := LoadLastSignedInfo(tempFile.Name())
+	require.Nil(err)
+	assert.Equal(info.LastSignBytesHash, loaded.LastSignBytesHash)
+	sameHRS, err := loaded.Verify(10, 1, stepPrevote)
+	assert.Nil(err)
+	assert.True(sameHRS)
+
+	// turned off, the bytes are recorded again
+	info.SetHashOnly(false)
+	require.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.NotNil(info.LastSignBytes)
+	assert.Nil(info.LastSignBytesHash)
+}
+
+func TestHashOnlyReuseAndConflict(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetHashOnly(true)
+	info.SetConflictStrategy(ConflictError)
+	signer, pub := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	// the exact same vote reuses the signature
+	same := *vote
+	same.Signature = crypto.Signature{}
+	reason, err := info.SignVoteWithReason(signer, "mychainid", &same)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+	assert.Equal(vote.Signature, same.Signature)
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", &same), same.Signature))
+
+	// only the timestamp differs, which can't be told from the hash
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Minute)
+	later.Signature = crypto.Signature{}
+	reason, err = info.SignVoteWithReason(signer, "mychainid", &later)
+	assert.Equal(ErrConflictingData, err)
+	assert.Equal(ContentDiffers, reason)
+
+	other := newVote(10, 1, types.VoteTypePrevote, blockID2)
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", other))
+	other.Signature, err = signer.Sign(types.SignBytes("mychainid", other))
+	require.Nil(err)
+	assert.Equal(ErrConflictingData, info.ValidateVote("mychainid", other, pub))
+}
+
+func TestHashOnlyReconcile(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	full, hashed := NewLastSignedInfo(), NewLastSignedInfo()
+	hashed.SetHashOnly(true)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(full.SignVote(signer, "mychainid", vote))
+	same := *vote
+	require.Nil(hashed.SignVote(signer, "mychainid", &same))
+
+	reconciled, err := ReconcileSources(hashed, full)
+	require.Nil(err)
+	assert.Equal(full.LastSignBytes, reconciled.LastSignBytes)
+
+	other := NewLastSignedInfo()
+	require.Nil(other.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2)))
+	_, err = ReconcileSources(hashed, other)
+	assert.Error(err)
+}
@@ -0,0 +1,71 @@
+package types
+
+import "time"
+
+// HealthReport summarizes whether a LastSignedInfo is ready to sign, eg. for a
+// readiness probe. It's serializable to JSON.
+type HealthReport struct {
+	// Ready is true if none of the below keeps it from signing.
+	Ready bool `json:"ready"`
+
+	// Persisted is true if signatures are persisted, to a filePath or a
+	// SignerState. Without it, a restart would forget what was signed.
+	Persisted bool `json:"persisted"`
+	// Frozen after conflicting data, see Unfreeze.
+	Frozen bool `json:"frozen"`
+	// Tombstoned for good, see ClearTombstone.
+	Tombstoned bool `json:"tombstoned"`
+	// ResetPending until a Reset is acknowledged, see SetRequireResetAck.
+	ResetPending bool `json:"reset_pending"`
+	// ReadOnly for a ReadOnlyReplica, which never signs.
+	ReadOnly bool `json:"read_only"`
+	// PendingSign is true if there's a PendingSign to Recover.
+	PendingSign bool `json:"pending_sign"`
+
+	// LastSignAge is how long ago the LastSignBytes were signed, by their
+	// timestamp and the Clock, or 0 if nothing was signed.
+	LastSignAge time.Duration `json:"last_sign_age"`
+	// Stale is true if the LastSignAge is over the max, see SetHealthMaxSignAge.
+	Stale bool `json:"stale"`
+}
+
+// SetHealthMaxSignAge makes Health report the LastSignedInfo as stale, and not
+// ready, if the last signature is older than maxAge, eg. as the validator
+// stopped being fed votes. 0, the default, disables it.
+func (info *LastSignedInfo) SetHealthMaxSignAge(maxAge time.Duration) {
+	info.healthMaxSignAge = maxAge
+}
+
+// Health returns a HealthReport of the LastSignedInfo. It doesn't change
+// anything; like the rest of the LastSignedInfo, it must be serialized with
+// signing by the caller.
+func (info *LastSignedInfo) Health() HealthReport {
+	report := HealthReport{
+		Persisted:    info.filePath != "" || info.store != nil,
+		Frozen:       info.frozen,
+		Tombstoned:   info.Tombstoned(),
+		ResetPending: info.UnacknowledgedReset,
+		PendingSign:  info.PendingSign != nil,
+	}
+	if info.LastSignBytes != nil {
+		// sign bytes that aren't a vote or proposal have no age
+		if timestamp, err := info.signedTimestamp(info.LastSignBytes); err == nil {
+			report.LastSignAge = info.now().Sub(timestamp)
+		}
+	}
+	report.Stale = info.healthMaxSignAge > 0 && report.LastSignAge > info.healthMaxSignAge
+	report.Ready = report.Persisted && !report.Frozen && !report.Tombstoned && !report.ResetPending &&
+		!report.PendingSign && !report.Stale
+	return report
+}
+
+// Health returns the HealthReport of the replica, which is never ready,
+// as it's read-only.
+func (r *ReadOnlyReplica) Health() HealthReport {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	report := r.view.Health()
+	report.ReadOnly = true
+	report.Ready = false
+	return report
+}
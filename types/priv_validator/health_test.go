@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestHealth(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(now)
+	info := NewLastSignedInfo()
+	info.SetClock(clock)
+	info.SetHealthMaxSignAge(time.Minute)
+
+	// nothing is persisted
+	assert.Equal(HealthReport{Ready: false}, info.Health())
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	require.Nil(info.SetFilePath(filePath))
+	assert.Equal(HealthReport{Ready: true, Persisted: true}, info.Health())
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	vote.Timestamp = now
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	clock.Advance(30 * time.Second)
+	report := info.Health()
+	assert.True(report.Ready)
+	assert.Equal(30*time.Second, report.LastSignAge)
+
+	clock.Advance(time.Minute)
+	report = info.Health()
+	assert.False(report.Ready)
+	assert.True(report.Stale)
+
+	info.SetHealthMaxSignAge(0)
+	assert.True(info.Health().Ready)
+	info.frozen = true
+	assert.False(info.Health().Ready)
+	info.frozen = false
+	info.PendingSign = &PendingSign{11, 0, stepPrevote}
+	assert.False(info.Health().Ready)
+
+	bz, err := json.Marshal(info.Health())
+	require.Nil(err)
+	assert.Contains(string(bz), `"pending_sign":true`)
+
+	// a replica is never ready
+	replica := NewReadOnlyReplica()
+	report = replica.Health()
+	assert.True(report.ReadOnly)
+	assert.False(report.Ready)
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+var (
+	ErrBackdatedConflict = errors.New("Conflicts with data signed before")
+)
+
+// signedRecord is what was signed at a height/round/step.
+type signedRecord struct {
+	height    int64
+	round     int
+	step      int8
+	signBytes []byte
+}
+
+// signHistory keeps the latest signedRecords, oldest first, bounded by
+// count (size) and/or by height (window). Zero means unbounded; with both
+// zero nothing is kept.
+type signHistory struct {
+	records []signedRecord
+	size    int
+	window  int64
+}
+
+func (h *signHistory) add(record signedRecord) {
+	if h.size == 0 && h.window == 0 {
+		return
+	}
+	h.records = append(h.records, record)
+	h.evict()
+}
+
+// evict drops the records over size, and those below the window
+// counted from the latest record. Records are added in HRS order,
+// so the ones to drop are always at the front.
+func (h *signHistory) evict() {
+	drop := 0
+	if h.size > 0 && len(h.records) > h.size {
+		drop = len(h.records) - h.size
+	}
+	if h.window > 0 && len(h.records) > 0 {
+		lowest := h.records[len(h.records)-1].height - h.window
+		for drop < len(h.records) && h.records[drop].height < lowest {
+			drop++
+		}
+	}
+	if drop > 0 {
+		n := copy(h.records, h.records[drop:])
+		h.records = h.records[:n]
+	}
+}
+
+func (h *signHistory) find(height int64, round int, step int8) ([]byte, bool) {
+	for _, record := range h.records {
+		if record.height == height && record.round == round && record.step == step {
+			return record.signBytes, true
+		}
+	}
+	return nil, false
+}
+
+// SetHistorySize keeps the sign bytes of the latest size signatures in memory,
+// so that SignVote can tell a back-dated vote with different content apart
+// from a plain regression. It's 0, ie. disabled, by default.
+// Changing the size drops the history. See also SetHistoryWindow.
+//
+// Without history, signing below the latest HRS is always rejected as a
+// regression. With it, if we signed something else at that HRS before,
+// ErrBackdatedConflict is returned instead, flagging a re-sign attempt
+// against a passed height.
+//
+// Only the exact HRS is compared. Within a height, content differing between
+// rounds is not a conflict: consensus may prevote or precommit another block,
+// or nil, in a later round, and DuplicateVoteEvidence requires the same height,
+// round and type. Such back-dated attempts are rejected as regressions.
+func (info *LastSignedInfo) SetHistorySize(size int) {
+	info.history = signHistory{size: size, window: info.history.window}
+}
+
+// SetHistoryWindow keeps the sign bytes signed at the latest heights heights
+// in memory: records below the latest signed height minus heights are dropped,
+// however many there are. Unlike a count, this keeps memory use in step with
+// the chain when the number of rounds per height varies. 0, the default,
+// disables the window.
+//
+// It combines with SetHistorySize: with both set, a record is dropped as soon
+// as either bound is exceeded, and with only the window set the count is
+// unbounded. Changing the window drops the records outside of it.
+func (info *LastSignedInfo) SetHistoryWindow(heights int64) {
+	info.history.window = heights
+	if heights == 0 && info.history.size == 0 {
+		info.history.records = nil
+		return
+	}
+	info.history.evict()
+}
+
+// RetainedHeights returns the heights with sign bytes in the history, in
+// ascending order, each once: at those, SignVote and SignProposal compare with
+// what was signed before, and below them the history has been compacted away.
+// It's empty if the history is disabled.
+//
+// The history keeps the sign bytes, not the signatures: only the latest
+// signature is kept, see LastSigned. So a vote at a retained height other than
+// the latest must be re-signed to be re-broadcast; it's checked against the
+// retained sign bytes when it is.
+func (info *LastSignedInfo) RetainedHeights() []int64 {
+	var heights []int64
+	for _, record := range info.history.records {
+		// records are in HRS order
+		if len(heights) == 0 || heights[len(heights)-1] != record.height {
+			heights = append(heights, record.height)
+		}
+	}
+	return heights
+}
+
+// checkHistory returns ErrBackdatedConflict if the content of the vote or
+// proposal signBytes differs from the retained ones at that height/round/step,
+// as compared by onlyDifferByTimestamp.
+func (info *LastSignedInfo) checkHistory(height int64, round int, step int8, signBytes []byte,
+	onlyDifferByTimestamp func(lastSignBytes, newSignBytes []byte, now time.Time) bool) error {
+	lastSignBytes, ok := info.history.find(height, round, step)
+	if !ok || bytes.Equal(lastSignBytes, signBytes) {
+		return nil
+	}
+	info.flagEmptyChainID(lastSignBytes)
+	if onlyDifferByTimestamp(lastSignBytes, signBytes, info.now()) {
+		return nil
+	}
+	return ErrBackdatedConflict
+}
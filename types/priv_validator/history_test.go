@@ -0,0 +1,191 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestSignHistoryRingBuffer(t *testing.T) {
+	assert := assert.New(t)
+
+	h := signHistory{size: 2}
+	for height := int64(1); height <= 3; height++ {
+		h.add(signedRecord{height, 0, stepPrevote, []byte{byte(height)}})
+	}
+	_, ok := h.find(1, 0, stepPrevote)
+	assert.False(ok, "oldest record should be evicted")
+	for height := int64(2); height <= 3; height++ {
+		signBytes, ok := h.find(height, 0, stepPrevote)
+		assert.True(ok)
+		assert.Equal([]byte{byte(height)}, signBytes)
+	}
+
+	// disabled
+	h = signHistory{}
+	h.add(signedRecord{1, 0, stepPrevote, nil})
+	_, ok = h.find(1, 0, stepPrevote)
+	assert.False(ok)
+}
+
+func TestSignHistoryWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	h := signHistory{window: 2}
+	add := func(height int64, rounds int) {
+		for round := 0; round < rounds; round++ {
+			h.add(signedRecord{height, round, stepPrevote, nil})
+		}
+	}
+	has := func(height int64) bool {
+		_, ok := h.find(height, 0, stepPrevote)
+		return ok
+	}
+
+	// many rounds don't evict anything within the window
+	add(1, 5)
+	add(2, 20)
+	add(3, 1)
+	assert.Len(h.records, 26)
+	assert.True(has(1))
+
+	// as the latest height advances, the heights below the window go
+	add(4, 1)
+	assert.False(has(1))
+	assert.True(has(2))
+	assert.Len(h.records, 22)
+	add(10, 1)
+	assert.False(has(4))
+	assert.Len(h.records, 1)
+
+	// with a size as well, whichever bound is hit first applies
+	h = signHistory{size: 3, window: 2}
+	add(1, 2)
+	add(2, 2)
+	assert.False(has(1))
+	assert.Len(h.records, 3)
+	add(5, 1)
+	assert.Equal([]signedRecord{{5, 0, stepPrevote, nil}}, h.records)
+}
+
+func TestSetHistoryWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetHistoryWindow(1)
+	signer, _ := newTestSigner()
+	for height := int64(1); height <= 4; height++ {
+		assert.NoError(info.SignVote(signer, "mychainid", newVote(height, 0, types.VoteTypePrevote, blockID1)))
+	}
+	assert.Len(info.history.records, 2)
+
+	// back-dated conflicts are caught within the window only
+	assert.Equal(ErrBackdatedConflict, info.SignVote(signer, "mychainid", newVote(3, 0, types.VoteTypePrevote, blockID2)))
+	assert.Equal(ErrHeightRegression, info.SignVote(signer, "mychainid", newVote(2, 0, types.VoteTypePrevote, blockID2)))
+
+	// disabling it, without a size, drops everything
+	info.SetHistoryWindow(0)
+	assert.Empty(info.history.records)
+}
+
+func TestRetainedHeights(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(1, 0, types.VoteTypePrevote, blockID1)))
+	assert.Empty(info.RetainedHeights(), "history disabled")
+
+	info.SetHistorySize(4)
+	for height := int64(2); height <= 4; height++ {
+		require.Nil(info.SignVote(signer, "mychainid", newVote(height, 0, types.VoteTypePrevote, blockID1)))
+		require.Nil(info.SignVote(signer, "mychainid", newVote(height, 0, types.VoteTypePrecommit, blockID1)))
+	}
+	// height 2 was compacted away
+	assert.Equal([]int64{3, 4}, info.RetainedHeights())
+}
+
+func TestSignVoteBackdatedConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetHistorySize(10)
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+	// different content at a passed HRS we still have
+	reason, err := info.SignVoteWithReason(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2))
+	assert.Equal(ErrBackdatedConflict, err)
+	assert.Equal(ContentDiffers, reason)
+
+	// same content, or only a different timestamp, is a plain regression
+	_, err = info.SignVoteWithReason(signer, "mychainid", vote)
+	assert.EqualError(err, "Height regression")
+	vote.Timestamp = vote.Timestamp.Add(1000)
+	_, err = info.SignVoteWithReason(signer, "mychainid", vote)
+	assert.EqualError(err, "Height regression")
+
+	// HRS we never signed
+	_, err = info.SignVoteWithReason(signer, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID2))
+	assert.EqualError(err, "Height regression")
+
+	// without history
+	info = NewLastSignedInfo()
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	_, err = info.SignVoteWithReason(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2))
+	assert.EqualError(err, "Height regression")
+}
+
+func TestSignVoteBackdatedConflictMatrix(t *testing.T) {
+	info := NewLastSignedInfo()
+	info.SetHistorySize(10)
+	info.SetConflictStrategy(ConflictError)
+	signer, pub := newTestSigner()
+
+	// round 0 for block 1, round 1 for block 2
+	signed := map[int]types.BlockID{0: blockID1, 1: blockID2}
+	for round := 0; round <= 1; round++ {
+		for _, voteType := range []byte{types.VoteTypePrevote, types.VoteTypePrecommit} {
+			require.Nil(t, info.SignVote(signer, "mychainid", newVote(10, round, voteType, signed[round])))
+		}
+	}
+
+	cases := []struct {
+		round    int
+		voteType byte
+		blockID  types.BlockID
+		err      error
+	}{
+		// same HRS, other content
+		{0, types.VoteTypePrevote, blockID2, ErrBackdatedConflict},
+		{0, types.VoteTypePrecommit, types.BlockID{}, ErrBackdatedConflict},
+		{1, types.VoteTypePrevote, blockID1, ErrBackdatedConflict},
+		{1, types.VoteTypePrecommit, blockID1, ErrConflictingData},
+		// same HRS, same content
+		{0, types.VoteTypePrevote, blockID1, ErrRoundRegression},
+		{0, types.VoteTypePrecommit, blockID1, ErrRoundRegression},
+		{1, types.VoteTypePrevote, blockID2, ErrStepRegression},
+		{1, types.VoteTypePrecommit, blockID2, nil},
+		// content of another round
+		{0, types.VoteTypePrevote, blockID2, ErrBackdatedConflict},
+		{2, types.VoteTypePrevote, blockID1, nil},
+	}
+	for i, c := range cases {
+		err := info.SignVote(signer, "mychainid", newVote(10, c.round, c.voteType, c.blockID))
+		assert.Equal(t, c.err, err, "case %d", i)
+	}
+
+	// differing across rounds is not evidence
+	voteA := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+	voteB := newVote(10, 1, types.VoteTypePrecommit, blockID2)
+	voteA.Signature, _ = signer.Sign(types.SignBytes("mychainid", voteA))
+	voteB.Signature, _ = signer.Sign(types.SignBytes("mychainid", voteB))
+	evidence := &types.DuplicateVoteEvidence{PubKey: pub, VoteA: voteA, VoteB: voteB}
+	assert.Error(t, evidence.Verify("mychainid"))
+}
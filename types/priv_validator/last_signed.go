@@ -0,0 +1,18 @@
+package types
+
+import (
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// LastSigned returns the LastSignBytes and LastSignature, eg. for the reactor
+// to re-broadcast the last vote verbatim on reconnect, without signing again.
+// ok is false if there is nothing to re-broadcast, ie. no sign bytes or no
+// signature (eg. after Reset or EnsureAtLeast).
+// Both are copies, so changing them doesn't affect the LastSignedInfo.
+// The extension of a precommit, if any, is returned by LastSignedExtension.
+func (info *LastSignedInfo) LastSigned() (bytes []byte, sig crypto.Signature, ok bool) {
+	if info.LastSignBytes == nil || info.LastSignature.Empty() {
+		return nil, crypto.Signature{}, false
+	}
+	return copyBytes(info.LastSignBytes), copySignature(info.LastSignature).Crypto(), true
+}
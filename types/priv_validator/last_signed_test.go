@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestLastSigned(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	_, _, ok := info.LastSigned()
+	assert.False(ok)
+
+	signer, pubKey := newTestSigner()
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	signBytes, sig, ok := info.LastSigned()
+	require.True(ok)
+	assert.Equal(types.SignBytes("mychainid", vote), signBytes)
+	assert.True(sig.Equals(vote.Signature))
+	assert.True(pubKey.VerifyBytes(signBytes, sig))
+
+	// they are copies
+	signBytes[0] = 'X'
+	assert.NotEqual(signBytes, []byte(info.LastSignBytes))
+
+	// nothing to re-broadcast without a signature
+	_, err := info.EnsureAtLeast(11, 0, stepPrevote)
+	require.Nil(err)
+	_, _, ok = info.LastSigned()
+	assert.False(ok)
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"github.com/tendermint/tmlibs/log"
+)
+
+// SetLogger sets the logger. Passing nil restores the default no-op logger.
+// Changes made outside of signing, eg. Tombstone or AcknowledgeReset, are
+// logged there too. See SignInfoFile.SetLogger to log what happens on load.
+func (info *LastSignedInfo) SetLogger(logger log.Logger) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	info.logger = logger
+}
+
+func (info *LastSignedInfo) getLogger() log.Logger {
+	if info.logger == nil {
+		return log.NewNopLogger()
+	}
+	return info.logger
+}
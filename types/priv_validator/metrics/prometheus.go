@@ -0,0 +1,119 @@
+// Package metrics exports the signing of a LastSignedInfo as Prometheus
+// metrics, so that the priv_validator package itself doesn't depend on Prometheus.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	privval "github.com/tendermint/tendermint/types/priv_validator"
+)
+
+const (
+	namespace = "tendermint"
+	subsystem = "priv_validator"
+)
+
+// span names of the signing helpers, see LastSignedInfo.SetTracer
+var signSpans = map[string]bool{
+	"LastSignedInfo.SignVote":     true,
+	"LastSignedInfo.SignProposal": true,
+}
+
+type prometheusMetrics struct {
+	signatures       prometheus.Counter
+	reuses           prometheus.Counter
+	rejections       *prometheus.CounterVec
+	lastSignedHeight prometheus.Gauge
+	lastSignTime     prometheus.Gauge
+}
+
+// PrometheusMetrics registers these metrics against registerer, and returns
+// a Tracer that updates them, to set with LastSignedInfo.SetTracer:
+//
+//	signatures_total        fresh signatures of votes and proposals
+//	reuses_total            LastSignatures reused instead
+//	rejections_total        refusals to sign, by reason
+//	last_signed_height      the height of the latest fresh signature
+//	last_sign_timestamp     when it was made, by the Clock of the
+//	                        LastSignedInfo, in seconds since the epoch
+//
+// All are prefixed with tendermint_priv_validator_.
+// It returns an error if they can't be registered, eg. because they already are.
+func PrometheusMetrics(registerer prometheus.Registerer) (privval.Tracer, error) {
+	m := &prometheusMetrics{
+		signatures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "signatures_total",
+			Help:      "Number of fresh signatures of votes and proposals.",
+		}),
+		reuses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "reuses_total",
+			Help:      "Number of times the last signature was reused instead of signing.",
+		}),
+		rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rejections_total",
+			Help:      "Number of refusals to sign, by reason.",
+		}, []string{"reason"}),
+		lastSignedHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "last_signed_height",
+			Help:      "Height of the latest fresh signature.",
+		}),
+		lastSignTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "last_sign_timestamp",
+			Help:      "Time of the latest fresh signature, in seconds since the epoch.",
+		}),
+	}
+	for _, collector := range []prometheus.Collector{
+		m.signatures, m.reuses, m.rejections, m.lastSignedHeight, m.lastSignTime,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// StartSpan implements privval.Tracer.
+func (m *prometheusMetrics) StartSpan(name string, keyvals ...interface{}) func(keyvals ...interface{}) {
+	if !signSpans[name] {
+		return func(...interface{}) {}
+	}
+	height, _ := value(keyvals, "height").(int64)
+	return func(keyvals ...interface{}) {
+		switch outcome, _ := value(keyvals, "outcome").(string); outcome {
+		case "signed":
+			m.signatures.Inc()
+			m.lastSignedHeight.Set(float64(height))
+			if signedAt, ok := value(keyvals, "time").(time.Time); ok {
+				m.lastSignTime.Set(float64(signedAt.Unix()))
+			}
+		case "reused":
+			m.reuses.Inc()
+		case "rejected", "denied":
+			reason, _ := value(keyvals, "error").(string)
+			m.rejections.WithLabelValues(reason).Inc()
+		case "conflict":
+			m.rejections.WithLabelValues(outcome).Inc()
+		}
+	}
+}
+
+// value returns the value for key in keyvals, or nil.
+func value(keyvals []interface{}, key string) interface{} {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == key {
+			return keyvals[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	privval "github.com/tendermint/tendermint/types/priv_validator"
+)
+
+func newVote(height int64, round int, voteType byte, hash byte) *types.Vote {
+	return &types.Vote{
+		Height:    height,
+		Round:     round,
+		Type:      voteType,
+		Timestamp: time.Now().UTC(),
+		BlockID:   types.BlockID{Hash: []byte{hash}},
+	}
+}
+
+// gather returns the value of each metric, by name and reason label if any
+func gather(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	require.Nil(t, err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range metric.GetLabel() {
+				name += "/" + label.GetValue()
+			}
+			values[name] = metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := prometheus.NewRegistry()
+	tracer, err := PrometheusMetrics(registry)
+	require.Nil(t, err)
+	_, err = PrometheusMetrics(registry)
+	assert.Error(err, "already registered")
+
+	info := privval.NewLastSignedInfo()
+	info.SetTracer(tracer)
+	info.SetConflictStrategy(privval.ConflictError)
+	signer := types.NewDefaultSigner(crypto.GenPrivKeyEd25519().Wrap())
+
+	signedAt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	info.SetClock(privval.NewManualClock(signedAt))
+	vote := newVote(10, 0, types.VoteTypePrevote, 1)
+	assert.Nil(info.SignVote(signer, "mychainid", vote))
+	assert.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, 1)))
+	assert.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, 1)))
+	assert.Error(info.SignVote(signer, "mychainid", vote))
+	assert.Error(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, 2)))
+
+	values := gather(t, registry)
+	assert.EqualValues(2, values["tendermint_priv_validator_signatures_total"])
+	assert.EqualValues(1, values["tendermint_priv_validator_reuses_total"])
+	assert.EqualValues(1, values["tendermint_priv_validator_rejections_total/"+privval.ErrHeightRegression.Error()])
+	assert.EqualValues(1, values["tendermint_priv_validator_rejections_total/conflict"])
+	assert.EqualValues(11, values["tendermint_priv_validator_last_signed_height"])
+	assert.EqualValues(signedAt.Unix(), values["tendermint_priv_validator_last_sign_timestamp"])
+}
@@ -0,0 +1,55 @@
+package types
+
+import "github.com/tendermint/tmlibs/log"
+
+// AddMirror makes every save also write the LastSignedInfo to path,
+// eg. on another disk, after the primary file.
+// The primary is authoritative: failing to write a mirror is logged,
+// but doesn't fail the save, nor the signing that caused it.
+// See LoadLastSignedInfoMirrored to load from the mirrors.
+func (info *LastSignedInfo) AddMirror(path string) {
+	info.mirrors = append(info.mirrors, path)
+}
+
+// saveMirrors writes the encoded LastSignedInfo to each mirror.
+func (info *LastSignedInfo) saveMirrors(infoBytes []byte) {
+	for _, mirror := range info.mirrors {
+		if err := writeFileAtomic(mirror, infoBytes); err != nil {
+			info.getLogger().Error("Failed to write LastSignedInfo mirror", "mirror", mirror, "err", err)
+		}
+	}
+}
+
+// LoadLastSignedInfoMirrored is like LoadLastSignedInfo, with the given mirrors
+// added. If the primary filePath is missing or can't be loaded, it falls back
+// to the mirror with the highest height/round/step among those that can.
+// Either way, subsequent saves go to filePath and the mirrors.
+// It returns the error of the primary if none of them can be loaded.
+// The fallback is logged to logger, which the LastSignedInfo keeps, along with
+// what's logged on load, see SignInfoFile.SetLogger.
+func LoadLastSignedInfoMirrored(filePath string, logger log.Logger, mirrors ...string) (*LastSignedInfo, error) {
+	info, err := loadLastSignedInfo(filePath, JSONCodec{}, logger)
+	if err != nil {
+		var mirror string
+		for _, path := range mirrors {
+			loaded, mirrorErr := loadLastSignedInfo(path, JSONCodec{}, logger)
+			if mirrorErr != nil {
+				logger.Error("Cannot load LastSignedInfo mirror", "mirror", path, "err", mirrorErr)
+				continue
+			}
+			if info == nil || compareHRS(loaded.LastHeight, loaded.LastRound, loaded.LastStep,
+				info.LastHeight, info.LastRound, info.LastStep) > 0 {
+				info, mirror = loaded, path
+			}
+		}
+		if info == nil {
+			return nil, err
+		}
+		logger.Error("Loaded LastSignedInfo from mirror", "file", filePath, "err", err, "mirror", mirror, "info", info)
+		info.filePath = filePath
+	}
+	for _, path := range mirrors {
+		info.AddMirror(path)
+	}
+	return info, nil
+}
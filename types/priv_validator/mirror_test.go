@@ -0,0 +1,92 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestAddMirror(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir, err := ioutil.TempDir("", "sign_info_")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	primary := filepath.Join(dir, "primary.json")
+	mirror := filepath.Join(dir, "mirror.json")
+	unwritable := filepath.Join(dir, "missing", "mirror.json")
+
+	info := NewLastSignedInfo()
+	info.SetLogger(log.TestingLogger())
+	require.Nil(info.SetFilePath(primary))
+	info.AddMirror(unwritable)
+	info.AddMirror(mirror)
+
+	// a failing mirror doesn't fail the save, nor keep the others from being written
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	primaryBytes, err := ioutil.ReadFile(primary)
+	require.Nil(err)
+	mirrorBytes, err := ioutil.ReadFile(mirror)
+	require.Nil(err)
+	assert.Equal(primaryBytes, mirrorBytes)
+	_, err = os.Stat(unwritable)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestLoadLastSignedInfoMirrored(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	logger := log.TestingLogger()
+
+	dir, err := ioutil.TempDir("", "sign_info_")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	primary := filepath.Join(dir, "primary.json")
+	mirrorA := filepath.Join(dir, "a.json")
+	mirrorB := filepath.Join(dir, "b.json")
+	missing := filepath.Join(dir, "missing.json")
+	save := func(path string, height int64) {
+		info := NewLastSignedInfo()
+		require.Nil(info.Set(height, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+		require.Nil(info.SaveAs(path))
+	}
+	save(primary, 12)
+	save(mirrorA, 10)
+	save(mirrorB, 11)
+
+	// the primary is authoritative, even if a mirror is ahead
+	save(mirrorA, 13)
+	info, err := LoadLastSignedInfoMirrored(primary, logger, mirrorA, mirrorB)
+	require.Nil(err)
+	assert.EqualValues(12, info.LastHeight)
+	save(mirrorA, 10)
+
+	// corrupt primary: the freshest valid mirror is used,
+	// and saves go to the primary and the mirrors again
+	require.Nil(ioutil.WriteFile(primary, []byte(`{"last_height":`), 0600))
+	info, err = LoadLastSignedInfoMirrored(primary, logger, missing, mirrorA, mirrorB)
+	require.Nil(err)
+	assert.EqualValues(11, info.LastHeight)
+	require.Nil(info.Set(14, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	for _, path := range []string{primary, missing, mirrorA, mirrorB} {
+		loaded, err := LoadLastSignedInfo(path)
+		require.Nil(err, path)
+		assert.EqualValues(14, loaded.LastHeight, path)
+	}
+
+	// missing primary
+	require.Nil(os.Remove(primary))
+	info, err = LoadLastSignedInfoMirrored(primary, logger, mirrorA)
+	require.Nil(err)
+	assert.EqualValues(14, info.LastHeight)
+
+	// nothing to load
+	_, err = LoadLastSignedInfoMirrored(primary, logger, filepath.Join(dir, "none.json"))
+	assert.True(os.IsNotExist(err))
+}
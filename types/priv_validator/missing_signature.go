@@ -0,0 +1,42 @@
+package types
+
+// MissingSignatureStrategy is how SignVote and SignProposal react at the
+// latest height/round/step if there are LastSignBytes, but no LastSignature.
+// Set always records both, and loading such a state fails with ErrCorruptState,
+// so it means the state in memory is corrupt; Verify panics on it.
+type MissingSignatureStrategy int
+
+const (
+	// MissingSignatureReject returns ErrCorruptState, whatever is to be signed.
+	// It's the default: the state can't be trusted, so an operator should look.
+	MissingSignatureReject MissingSignatureStrategy = iota
+	// MissingSignatureResign signs the sign bytes again if they're exactly the
+	// LastSignBytes, with the SignatureMissing reason, to record the missing
+	// signature; signing the same bytes can't conflict. Anything else returns
+	// ErrCorruptState, as it can't be told whether it conflicts.
+	MissingSignatureResign
+)
+
+// SetMissingSignatureStrategy sets how to react to LastSignBytes without a
+// LastSignature at the same height/round/step.
+func (info *LastSignedInfo) SetMissingSignatureStrategy(strategy MissingSignatureStrategy) {
+	info.missingSignature = strategy
+}
+
+// checkMissingSignature returns ErrCorruptState if the LastSignature is missing
+// at the height/round/step, or resign true if signBytes are to be signed again
+// per MissingSignatureResign. With a pending signature, it's left to Verify.
+func (info *LastSignedInfo) checkMissingSignature(height int64, round int, step int8, signBytes []byte) (resign bool, err error) {
+	if info.PendingSign != nil || !info.hasSignBytes() || !info.LastSignature.Empty() {
+		return false, nil
+	}
+	if height != info.LastHeight || round != info.LastRound || step != info.LastStep {
+		return false, nil
+	}
+	if info.missingSignature == MissingSignatureResign && info.matchesSignBytes(signBytes) {
+		info.getLogger().Error("Signing again to recover the missing LastSignature",
+			"height", height, "round", round, "step", step)
+		return true, nil
+	}
+	return false, ErrCorruptState
+}
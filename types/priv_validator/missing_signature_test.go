@@ -0,0 +1,65 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// returns an info that signed vote, with the LastSignature lost
+func newInfoMissingSignature(t *testing.T, signer types.Signer, vote *types.Vote) *LastSignedInfo {
+	info := NewLastSignedInfo()
+	require.Nil(t, info.SignVote(signer, "mychainid", vote))
+	info.LastSignature = Signature{}
+	return info
+}
+
+func TestMissingSignatureReject(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, _ := newTestSigner()
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	info := newInfoMissingSignature(t, signer, vote)
+	var rejected []error
+	info.SetOnReject(func(event RejectEvent) { rejected = append(rejected, event.Err) })
+
+	same := *vote
+	same.Signature = crypto.Signature{}
+	assert.Equal(ErrCorruptState, info.SignVote(signer, "mychainid", &same))
+	assert.True(same.Signature.Empty())
+	assert.Equal([]error{ErrCorruptState}, rejected)
+
+	// other HRS are up to Verify as usual
+	assert.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+}
+
+func TestMissingSignatureResign(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, pub := newTestSigner()
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	info := newInfoMissingSignature(t, signer, vote)
+	info.SetMissingSignatureStrategy(MissingSignatureResign)
+
+	// the same bytes are signed again
+	same := *vote
+	same.Signature = crypto.Signature{}
+	reason, err := info.SignVoteWithReason(signer, "mychainid", &same)
+	require.Nil(err)
+	assert.Equal(SignatureMissing, reason)
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", &same), same.Signature))
+	assert.Equal(same.Signature, info.LastSignature.Crypto())
+	assert.EqualValues(2, info.Seq)
+
+	// but not another timestamp, let alone another block
+	info = newInfoMissingSignature(t, signer, vote)
+	info.SetMissingSignatureStrategy(MissingSignatureResign)
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	assert.Equal(ErrCorruptState, info.SignVote(signer, "mychainid", &later))
+	assert.Equal(ErrCorruptState, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+}
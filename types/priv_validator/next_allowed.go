@@ -0,0 +1,29 @@
+package types
+
+// NextAllowed returns the lowest height/round/step that Verify allows signing
+// next, without reusing the LastSignature, eg. for a scheduler or to display
+// where the signer is.
+//
+// It's the next step of the same round, and after the precommit step, the
+// propose step of the next round: the round is incremented and the step wraps
+// around. Nothing is signed at the none step, so it's never returned.
+// If the HRS was advanced to with AdvanceWithoutSigning, it's the HRS itself,
+// as nothing was signed there. It's never below the floor height, see
+// SetFloorHeight.
+//
+// It doesn't account for what blocks all signing: a PendingSign to Recover,
+// or a freeze after a conflict.
+func (info *LastSignedInfo) NextAllowed() (height int64, round int, step int8) {
+	height, round, step = info.LastHeight, info.LastRound, info.LastStep
+	switch {
+	case info.Unsigned:
+	case step < stepMax:
+		step++
+	default:
+		round, step = round+1, stepPropose
+	}
+	if height < info.FloorHeight {
+		height, round, step = info.FloorHeight, 0, stepPropose
+	}
+	return height, round, step
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestNextAllowed(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	type hrs struct {
+		height int64
+		round  int
+		step   int8
+	}
+	nextAllowed := func(info *LastSignedInfo) hrs {
+		height, round, step := info.NextAllowed()
+		// it's always allowed, and as a fresh signature
+		sameHRS, err := info.Verify(height, round, step)
+		assert.Nil(err)
+		assert.False(sameHRS)
+		return hrs{height, round, step}
+	}
+
+	info := NewLastSignedInfo()
+	assert.Equal(hrs{0, 0, stepPropose}, nextAllowed(info))
+
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)))
+	assert.Equal(hrs{10, 1, stepPrecommit}, nextAllowed(info))
+
+	// the round wraps around after the precommit
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID1)))
+	assert.Equal(hrs{10, 2, stepPropose}, nextAllowed(info))
+
+	// nothing was signed there
+	require.Nil(info.AdvanceWithoutSigning(11, 0, stepPrevote))
+	assert.Equal(hrs{11, 0, stepPrevote}, nextAllowed(info))
+
+	// but something may have been
+	_, err := info.EnsureAtLeast(11, 0, stepPrecommit)
+	require.Nil(err)
+	assert.Equal(hrs{11, 1, stepPropose}, nextAllowed(info))
+
+	require.Nil(info.SetFloorHeight(20))
+	assert.Equal(hrs{20, 0, stepPropose}, nextAllowed(info))
+}
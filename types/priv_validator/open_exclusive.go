@@ -0,0 +1,46 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+var (
+	ErrLocked = errors.New("LastSignedInfo is locked by another process")
+)
+
+// OpenExclusive loads the LastSignedInfo from filePath like LoadLastSignedInfo,
+// holding an OS-level advisory lock (flock) on filePath+".lock" until release
+// is called, or the process exits. If another process holds the lock,
+// it fails right away with ErrLocked. This prevents two processes from ever
+// signing with the same state file.
+//
+// The lock is on a separate file since Save replaces the state file
+// atomically, which would leave a lock on the file itself behind.
+// release unlocks and closes the lock file; it's safe to call more than once.
+func OpenExclusive(filePath string) (info *LastSignedInfo, release func(), err error) {
+	lockFile, err := os.OpenFile(filePath+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := lock(lockFile); err != nil {
+		lockFile.Close()
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			unlock(lockFile)
+			lockFile.Close()
+		})
+	}
+
+	info, err = LoadLastSignedInfo(filePath)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return info, release, nil
+}
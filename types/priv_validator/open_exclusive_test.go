@@ -0,0 +1,47 @@
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestOpenExclusive(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(tempFilePath + ".lock")
+	require.Nil(NewLastSignedInfo().SaveAs(tempFilePath))
+
+	info, release, err := OpenExclusive(tempFilePath)
+	require.Nil(err)
+	require.NotNil(info)
+
+	// flock is per open file, so this conflicts like another process would
+	_, _, err = OpenExclusive(tempFilePath)
+	assert.Equal(ErrLocked, err)
+
+	// still locked after saving, which replaces the state file
+	require.Nil(info.Save())
+	_, _, err = OpenExclusive(tempFilePath)
+	assert.Equal(ErrLocked, err)
+
+	release()
+	release()
+	info, release, err = OpenExclusive(tempFilePath)
+	require.Nil(err)
+	release()
+
+	// the lock isn't kept if loading fails
+	require.Nil(os.Remove(tempFilePath))
+	_, _, err = OpenExclusive(tempFilePath)
+	assert.Error(err)
+	assert.NotEqual(ErrLocked, err)
+	require.Nil(NewLastSignedInfo().SaveAs(tempFilePath))
+	_, release, err = OpenExclusive(tempFilePath)
+	assert.Nil(err)
+	release()
+}
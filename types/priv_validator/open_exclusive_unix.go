@@ -0,0 +1,22 @@
+// +build !windows
+
+package types
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive flock on the file without blocking,
+// or returns ErrLocked if it's held elsewhere.
+func lock(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return ErrLocked
+	}
+	return err
+}
+
+func unlock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
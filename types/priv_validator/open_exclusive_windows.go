@@ -0,0 +1,16 @@
+// +build windows
+
+package types
+
+import (
+	"errors"
+	"os"
+)
+
+func lock(file *os.File) error {
+	return errors.New("OpenExclusive is not supported on windows")
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
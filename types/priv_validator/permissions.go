@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"runtime"
+
+	"github.com/tendermint/tmlibs/log"
+)
+
+var (
+	ErrInsecurePermissions = errors.New("LastSignedInfo file is accessible by others, expected permissions 0600")
+)
+
+// checkPermissions returns ErrInsecurePermissions if the file can be
+// accessed by anyone but its owner. Permissions aren't checked on windows.
+func checkPermissions(filePath string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if stat.Mode().Perm()&0077 != 0 {
+		return ErrInsecurePermissions
+	}
+	return nil
+}
+
+// warnPermissions logs if the file can be accessed by anyone but its owner.
+func warnPermissions(filePath string, logger log.Logger) {
+	if err := checkPermissions(filePath); err == ErrInsecurePermissions {
+		logger.Error("LastSignedInfo file is accessible by others, should be 0600", "file", filePath)
+	}
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't checked on windows")
+	}
+	assert, require := assert.New(t), require.New(t)
+
+	buf := new(bytes.Buffer)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	_, pubKey := newTestSigner()
+	state := NewSignInfoFile(tempFilePath)
+	state.SetLogger(log.NewTMLogger(buf))
+
+	// always saved as 0600
+	require.Nil(os.Chmod(tempFilePath, 0644))
+	require.Nil(NewLastSignedInfo().SaveAs(tempFilePath))
+	stat, err := os.Stat(tempFilePath)
+	require.Nil(err)
+	assert.Equal(os.FileMode(0600), stat.Mode().Perm())
+	_, err = state.Load()
+	assert.Nil(err)
+	_, err = LoadLastSignedInfoStrict(tempFilePath, pubKey)
+	assert.Nil(err)
+	assert.Empty(buf.String())
+
+	for _, mode := range []os.FileMode{0644, 0640, 0604, 0660} {
+		buf.Reset()
+		require.Nil(os.Chmod(tempFilePath, mode))
+
+		// a warning
+		_, err = state.Load()
+		assert.Nil(err, "%v", mode)
+		assert.Contains(buf.String(), "accessible by others", "%v", mode)
+		buf.Reset()
+		_, err = LoadLastSignedInfo(tempFilePath)
+		assert.Nil(err, "%v", mode)
+		assert.Empty(buf.String(), "only to the logger of the SignInfoFile")
+
+		// an error in strict mode
+		_, err = LoadLastSignedInfoStrict(tempFilePath, pubKey)
+		assert.Equal(ErrInsecurePermissions, err, "%v", mode)
+	}
+}
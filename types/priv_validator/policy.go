@@ -0,0 +1,63 @@
+package types
+
+import (
+	"errors"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrPolicyDenied = errors.New("Signing denied by policy")
+)
+
+// SignPolicy has the final say on signing a vote or a proposal, eg. to plug
+// in an external policy engine that refuses to sign during a governance freeze.
+// Allow returns an error to veto the vote, AllowProposal the proposal.
+type SignPolicy interface {
+	Allow(chainID string, vote *types.Vote) error
+	AllowProposal(chainID string, proposal *types.Proposal) error
+}
+
+// AllowAllPolicy is the default SignPolicy, which never vetoes.
+type AllowAllPolicy struct{}
+
+// Allow implements SignPolicy.
+func (AllowAllPolicy) Allow(string, *types.Vote) error {
+	return nil
+}
+
+// AllowProposal implements SignPolicy.
+func (AllowAllPolicy) AllowProposal(string, *types.Proposal) error {
+	return nil
+}
+
+// SetSignPolicy sets the SignPolicy consulted by SignVote and SignProposal
+// once the vote or proposal passed the double sign checks, before any
+// signature is returned. On a veto, they return ErrPolicyDenied and nothing
+// is recorded.
+// Passing nil restores the default AllowAllPolicy.
+func (info *LastSignedInfo) SetSignPolicy(policy SignPolicy) {
+	info.policy = policy
+}
+
+func (info *LastSignedInfo) allow(chainID string, vote *types.Vote) error {
+	if info.policy == nil {
+		return nil
+	}
+	if err := info.policy.Allow(chainID, vote); err != nil {
+		info.getLogger().Info("Signing denied by policy", "vote", vote, "err", err)
+		return ErrPolicyDenied
+	}
+	return nil
+}
+
+func (info *LastSignedInfo) allowProposal(chainID string, proposal *types.Proposal) error {
+	if info.policy == nil {
+		return nil
+	}
+	if err := info.policy.AllowProposal(chainID, proposal); err != nil {
+		info.getLogger().Info("Signing denied by policy", "proposal", proposal, "err", err)
+		return ErrPolicyDenied
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+// freezePolicy denies everything from a height on.
+type freezePolicy struct {
+	height int64
+}
+
+func (fp freezePolicy) Allow(chainID string, vote *types.Vote) error {
+	if vote.Height >= fp.height {
+		return errors.New("governance freeze")
+	}
+	return nil
+}
+
+func (fp freezePolicy) AllowProposal(chainID string, proposal *types.Proposal) error {
+	if proposal.Height >= fp.height {
+		return errors.New("governance freeze")
+	}
+	return nil
+}
+
+func TestSignPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	var events []RejectEvent
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetOnReject(func(event RejectEvent) { events = append(events, event) })
+	info.SetSignPolicy(freezePolicy{11})
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+
+	// the high-water mark doesn't advance on a veto
+	vote = newVote(11, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(ErrPolicyDenied, info.SignVote(signer, "mychainid", vote))
+	assert.True(vote.Signature.Empty())
+	assert.Equal(int64(10), info.LastHeight)
+	if assert.Len(events, 1) {
+		assert.Equal(ErrPolicyDenied, events[0].Err)
+	}
+
+	// conflicts are still reported as such
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+
+	info.SetSignPolicy(nil)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.Equal(int64(11), info.LastHeight)
+
+	info.SetSignPolicy(AllowAllPolicy{})
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(12, 0, types.VoteTypePrevote, blockID1)))
+}
+
+func TestSignPolicyProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetSignPolicy(freezePolicy{11})
+	signer, _ := newTestSigner()
+
+	assert.NoError(info.SignProposal(signer, "mychainid", &types.Proposal{Height: 10, POLRound: -1}))
+	proposal := &types.Proposal{Height: 11, POLRound: -1}
+	assert.Equal(ErrPolicyDenied, info.SignProposal(signer, "mychainid", proposal))
+	assert.True(proposal.Signature.Empty())
+	assert.Equal(int64(10), info.LastHeight)
+
+	// the same for precomputed sign bytes
+	_, _, err := info.SignPrecomputed(signer, types.SignBytes("mychainid", proposal), 11, 0, stepPropose)
+	assert.Equal(ErrPolicyDenied, err)
+	assert.Equal(int64(10), info.LastHeight)
+}
@@ -0,0 +1,47 @@
+package types
+
+import "fmt"
+
+// RestorePolicy is what PrepareForSigning accepts of a restored LastSignedInfo.
+type RestorePolicy struct {
+	// MaxGap is how many heights the restored high-water mark may be behind
+	// the tip. A larger gap suggests a stale backup, which may miss signatures
+	// made since. 0 means no limit.
+	MaxGap int64
+
+	// AllowAhead accepts a high-water mark above the tip, eg. if the tip
+	// comes from a node that is still catching up.
+	AllowAhead bool
+}
+
+// PrepareForSigning is the check to run after restoring the LastSignedInfo
+// (eg. from a backup), before signing again, given the height of the chain tip.
+// It checks the state is sane, ie. a valid height/round/step without a
+// PendingSign to Recover, and not ahead of the tip, and that it's not more
+// than policy.MaxGap heights behind it. If so, it raises the floor height to
+// the tip, so nothing before it is ever signed, and persists it.
+// Otherwise it returns an error saying what to fix, and changes nothing.
+func (info *LastSignedInfo) PrepareForSigning(tipHeight int64, policy RestorePolicy) error {
+	if tipHeight < 0 {
+		return fmt.Errorf("Invalid tip height %v", tipHeight)
+	}
+	if err := validateHRS(info.LastHeight, info.LastRound, info.LastStep); err != nil {
+		return fmt.Errorf("Restored state %v is invalid (%v): restore another copy", info, err)
+	}
+	if info.PendingSign != nil {
+		return fmt.Errorf("Restored state %v has a pending signature at %v/%v/%v: call Recover first",
+			info, info.PendingSign.Height, info.PendingSign.Round, info.PendingSign.Step)
+	}
+	if info.LastHeight > tipHeight && !policy.AllowAhead {
+		return fmt.Errorf("Restored state %v is ahead of the tip at height %v: "+
+			"check the tip and the chain, or allow it with AllowAhead", info, tipHeight)
+	}
+	if gap := tipHeight - info.LastHeight; policy.MaxGap > 0 && gap > policy.MaxGap {
+		return fmt.Errorf("Restored state %v is %v heights behind the tip at height %v, more than %v: "+
+			"restore a fresher copy, or raise MaxGap if none is missing signatures", info, gap, tipHeight, policy.MaxGap)
+	}
+	if tipHeight <= info.FloorHeight {
+		return nil
+	}
+	return info.SetFloorHeight(tipHeight)
+}
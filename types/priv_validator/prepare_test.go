@@ -0,0 +1,67 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+func TestPrepareForSigning(t *testing.T) {
+	restored := func(height int64) *LastSignedInfo {
+		info := NewLastSignedInfo()
+		if height > 0 {
+			require.Nil(t, info.Set(height, 0, stepPrecommit, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+		}
+		return info
+	}
+
+	cases := []struct {
+		height int64
+		tip    int64
+		policy RestorePolicy
+		ok     bool
+	}{
+		{0, 100, RestorePolicy{}, true},
+		{90, 100, RestorePolicy{}, true},
+		{90, 100, RestorePolicy{MaxGap: 10}, true},
+		{89, 100, RestorePolicy{MaxGap: 10}, false},
+		{100, 100, RestorePolicy{MaxGap: 10}, true},
+		{101, 100, RestorePolicy{}, false},
+		{101, 100, RestorePolicy{AllowAhead: true}, true},
+		{90, -1, RestorePolicy{}, false},
+	}
+	for i, c := range cases {
+		info := restored(c.height)
+		err := info.PrepareForSigning(c.tip, c.policy)
+		if !c.ok {
+			assert.Error(t, err, "case %d", i)
+			assert.EqualValues(t, 0, info.FloorHeight, "case %d", i)
+			continue
+		}
+		assert.Nil(t, err, "case %d", i)
+		assert.Equal(t, c.tip, info.FloorHeight, "case %d", i)
+		_, err = info.Verify(c.tip-1, 0, stepPrevote)
+		assert.Equal(t, ErrBelowFloor, err, "case %d", i)
+	}
+}
+
+func TestPrepareForSigningSanity(t *testing.T) {
+	assert := assert.New(t)
+
+	// the floor is never lowered
+	info := NewLastSignedInfo()
+	require.Nil(t, info.SetFloorHeight(200))
+	assert.Nil(info.PrepareForSigning(100, RestorePolicy{}))
+	assert.EqualValues(200, info.FloorHeight)
+
+	info = NewLastSignedInfo()
+	info.PendingSign = &PendingSign{Height: 10, Step: stepPrevote}
+	assert.Error(info.PrepareForSigning(100, RestorePolicy{}))
+
+	info = NewLastSignedInfo()
+	info.LastHeight, info.LastStep = 10, 42
+	assert.Error(info.PrepareForSigning(100, RestorePolicy{}))
+	assert.EqualValues(0, info.FloorHeight)
+}
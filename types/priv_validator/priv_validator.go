@@ -0,0 +1,240 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+// PrivValidatorFS implements PrivValidator using data persisted to disk
+// to prevent double signing. The Signer itself can be mutated to use
+// something besides the default, for instance a hardware signer.
+//
+// The double sign protection is its LastSignedInfo, which is saved in the
+// same file as the keys, so the options of the LastSignedInfo, eg. SetTracer
+// or SetConflictStrategy, apply to SignVote and SignProposal.
+//
+// It uses the same file format as types.PrivValidatorFS, which stays as is
+// for its existing callers.
+type PrivValidatorFS struct {
+	Address data.Bytes    `json:"address"`
+	PubKey  crypto.PubKey `json:"pub_key"`
+
+	*LastSignedInfo
+
+	// PrivKey should be empty if a Signer other than the default is being used.
+	PrivKey      crypto.PrivKey `json:"priv_key"`
+	types.Signer `json:"-"`
+
+	// For persistence.
+	// Overloaded for testing.
+	filePath string
+	mtx      sync.Mutex
+}
+
+// GetAddress returns the address of the validator.
+// Implements PrivValidator.
+func (pv *PrivValidatorFS) GetAddress() data.Bytes {
+	return pv.Address
+}
+
+// GetPubKey returns the public key of the validator.
+// Implements PrivValidator.
+func (pv *PrivValidatorFS) GetPubKey() crypto.PubKey {
+	return pv.PubKey
+}
+
+// GenPrivValidatorFS generates a new validator with randomly generated private key
+// and sets the filePath, but does not call Save().
+// Without a filePath, the LastSignedInfo is only kept in memory.
+func GenPrivValidatorFS(filePath string) *PrivValidatorFS {
+	privKey := crypto.GenPrivKeyEd25519().Wrap()
+	privVal := &PrivValidatorFS{
+		Address:        privKey.PubKey().Address(),
+		PubKey:         privKey.PubKey(),
+		LastSignedInfo: NewLastSignedInfo(),
+		PrivKey:        privKey,
+		Signer:         types.NewDefaultSigner(privKey),
+		filePath:       filePath,
+	}
+	privVal.init()
+	return privVal
+}
+
+// LoadPrivValidatorFS loads a PrivValidatorFS from the filePath.
+func LoadPrivValidatorFS(filePath string) *PrivValidatorFS {
+	return LoadPrivValidatorFSWithSigner(filePath, func(privVal types.PrivValidator) types.Signer {
+		return types.NewDefaultSigner(privVal.(*PrivValidatorFS).PrivKey)
+	})
+}
+
+// LoadOrGenPrivValidatorFS loads a PrivValidatorFS from the given filePath
+// or else generates a new one and saves it to the filePath.
+func LoadOrGenPrivValidatorFS(filePath string) *PrivValidatorFS {
+	var privVal *PrivValidatorFS
+	if _, err := os.Stat(filePath); err == nil {
+		privVal = LoadPrivValidatorFS(filePath)
+	} else {
+		privVal = GenPrivValidatorFS(filePath)
+		privVal.Save()
+	}
+	return privVal
+}
+
+// LoadPrivValidatorFSWithSigner loads a PrivValidatorFS with a custom
+// signer object. The PrivValidatorFS handles double signing prevention by persisting
+// data to the filePath, while the Signer handles the signing.
+// If the filePath does not exist, the PrivValidatorFS must be created manually and saved.
+func LoadPrivValidatorFSWithSigner(filePath string, signerFunc func(types.PrivValidator) types.Signer) *PrivValidatorFS {
+	privVal, err := loadPrivValidatorFS(filePath)
+	if err != nil {
+		cmn.Exit(err.Error())
+	}
+	privVal.Signer = signerFunc(privVal)
+	return privVal
+}
+
+// loadPrivValidatorFS is LoadLastSignedInfo for the file of a PrivValidatorFS:
+// the LastSignedInfo is checked the same way.
+func loadPrivValidatorFS(filePath string) (*PrivValidatorFS, error) {
+	if err := recoverTempFile(filePath, JSONCodec{}, log.NewNopLogger()); err != nil {
+		return nil, err
+	}
+	privValJSONBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := decodeBytes(JSONCodec{}, privValJSONBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading PrivValidator from %v: %v", filePath, err)
+	}
+	privVal := &PrivValidatorFS{LastSignedInfo: info}
+	if err := json.Unmarshal(privValJSONBytes, privVal); err != nil {
+		return nil, fmt.Errorf("Error reading PrivValidator from %v: %v", filePath, err)
+	}
+	warnPermissions(filePath, log.NewNopLogger())
+
+	privVal.filePath = filePath
+	privVal.init()
+	return privVal, nil
+}
+
+// init makes the LastSignedInfo persist to the file, if any,
+// serialized with signing by the mutex.
+func (pv *PrivValidatorFS) init() {
+	if pv.filePath != "" {
+		pv.SetSignerState(privValidatorFile{pv})
+	}
+	pv.SetLocker(&pv.mtx)
+}
+
+// Save persists the PrivValidatorFS to disk.
+func (pv *PrivValidatorFS) Save() {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	pv.save()
+}
+
+func (pv *PrivValidatorFS) save() {
+	if pv.filePath == "" {
+		cmn.PanicSanity("Cannot save PrivValidator: filePath not set")
+	}
+	if err := pv.LastSignedInfo.Save(); err != nil {
+		// `@; BOOM!!!
+		cmn.PanicCrisis(err)
+	}
+}
+
+// Reset resets the LastSignedInfo, see LastSignedInfo.Reset, and saves it.
+// NOTE: Unsafe!
+func (pv *PrivValidatorFS) Reset() {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	if err := pv.LastSignedInfo.Reset(); err != nil {
+		// `@; BOOM!!!
+		cmn.PanicCrisis(err)
+	}
+}
+
+// SignVote signs a canonical representation of the vote, along with the
+// chainID, see LastSignedInfo.SignVote. Implements PrivValidator.
+func (pv *PrivValidatorFS) SignVote(chainID string, vote *types.Vote) error {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	if err := pv.LastSignedInfo.SignVote(pv.Signer, chainID, vote); err != nil {
+		return fmt.Errorf("Error signing vote: %v", err)
+	}
+	return nil
+}
+
+// SignProposal signs a canonical representation of the proposal, along with
+// the chainID, see LastSignedInfo.SignProposal. Implements PrivValidator.
+func (pv *PrivValidatorFS) SignProposal(chainID string, proposal *types.Proposal) error {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	if err := pv.LastSignedInfo.SignProposal(pv.Signer, chainID, proposal); err != nil {
+		return fmt.Errorf("Error signing proposal: %v", err)
+	}
+	return nil
+}
+
+// SignHeartbeat signs a canonical representation of the heartbeat, along with the chainID.
+// Implements PrivValidator.
+func (pv *PrivValidatorFS) SignHeartbeat(chainID string, heartbeat *types.Heartbeat) error {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	var err error
+	heartbeat.Signature, err = pv.Sign(types.SignBytes(chainID, heartbeat))
+	return err
+}
+
+// String returns a string representation of the PrivValidatorFS.
+func (pv *PrivValidatorFS) String() string {
+	return fmt.Sprintf("PrivValidator{%v LH:%v, LR:%v, LS:%v}", pv.GetAddress(), pv.LastHeight, pv.LastRound, pv.LastStep)
+}
+
+//-------------------------------------
+
+// privValidatorFile is the SignerState of a PrivValidatorFS, its file,
+// where the LastSignedInfo is saved along with the keys.
+type privValidatorFile struct {
+	privVal *PrivValidatorFS
+}
+
+// Load implements SignerState. The LastSignedInfo persists to the file.
+func (pvf privValidatorFile) Load() (*LastSignedInfo, error) {
+	loaded, err := loadPrivValidatorFS(pvf.privVal.filePath)
+	if err != nil {
+		return nil, err
+	}
+	loaded.SetSignerState(pvf)
+	return loaded.LastSignedInfo, nil
+}
+
+// Read implements SignerStateReader, without recovering a temp file.
+func (pvf privValidatorFile) Read() (*LastSignedInfo, error) {
+	return readLastSignedInfo(pvf.privVal.filePath, JSONCodec{})
+}
+
+// Save implements SignerState.
+func (pvf privValidatorFile) Save(info *LastSignedInfo) error {
+	privVal := pvf.privVal
+	jsonBytes, err := json.Marshal(&PrivValidatorFS{
+		Address:        privVal.Address,
+		PubKey:         privVal.PubKey,
+		LastSignedInfo: info,
+		PrivKey:        privVal.PrivKey,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(privVal.filePath, jsonBytes)
+}
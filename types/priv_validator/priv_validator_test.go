@@ -0,0 +1,227 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestGenLoadValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	_, tempFilePath := cmn.Tempfile("priv_validator_")
+	privVal := GenPrivValidatorFS(tempFilePath)
+
+	height := int64(100)
+	privVal.LastHeight = height
+	privVal.Save()
+	addr := privVal.GetAddress()
+
+	privVal = LoadPrivValidatorFS(tempFilePath)
+	assert.Equal(addr, privVal.GetAddress(), "expected privval addr to be the same")
+	assert.Equal(height, privVal.LastHeight, "expected privval.LastHeight to have been saved")
+}
+
+func TestLoadOrGenValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	_, tempFilePath := cmn.Tempfile("priv_validator_")
+	if err := os.Remove(tempFilePath); err != nil {
+		t.Error(err)
+	}
+	privVal := LoadOrGenPrivValidatorFS(tempFilePath)
+	addr := privVal.GetAddress()
+	privVal = LoadOrGenPrivValidatorFS(tempFilePath)
+	assert.Equal(addr, privVal.GetAddress(), "expected privval addr to be the same")
+}
+
+func TestUnmarshalValidator(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// create some fixed values
+	addrStr := "D028C9981F7A87F3093672BF0D5B0E2A1B3ED456"
+	pubStr := "3B3069C422E19688B45CBFAE7BB009FC0FA1B1EA86593519318B7214853803C8"
+	privStr := "27F82582AEFAE7AB151CFB01C48BB6C1A0DA78F9BDDA979A9F70A84D074EB07D3B3069C422E19688B45CBFAE7BB009FC0FA1B1EA86593519318B7214853803C8"
+	addrBytes, _ := hex.DecodeString(addrStr)
+	pubBytes, _ := hex.DecodeString(pubStr)
+	privBytes, _ := hex.DecodeString(privStr)
+
+	// prepend type byte
+	pubKey, err := crypto.PubKeyFromBytes(append([]byte{1}, pubBytes...))
+	require.Nil(err, "%+v", err)
+	privKey, err := crypto.PrivKeyFromBytes(append([]byte{1}, privBytes...))
+	require.Nil(err, "%+v", err)
+
+	serialized := fmt.Sprintf(`{
+  "address": "%s",
+  "pub_key": {
+    "type": "ed25519",
+    "data": "%s"
+  },
+  "last_height": 0,
+  "last_round": 0,
+  "last_step": 0,
+  "last_signature": null,
+  "priv_key": {
+    "type": "ed25519",
+    "data": "%s"
+  }
+}`, addrStr, pubStr, privStr)
+
+	val := PrivValidatorFS{LastSignedInfo: NewLastSignedInfo()}
+	err = json.Unmarshal([]byte(serialized), &val)
+	require.Nil(err, "%+v", err)
+
+	// make sure the values match
+	assert.EqualValues(addrBytes, val.GetAddress())
+	assert.EqualValues(pubKey, val.GetPubKey())
+	assert.EqualValues(privKey, val.PrivKey)
+
+	// export it and make sure it is the same
+	out, err := json.Marshal(&val)
+	require.Nil(err, "%+v", err)
+	assert.JSONEq(serialized, string(out))
+}
+
+func TestPrivValidatorSignVote(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("priv_validator_")
+	privVal := GenPrivValidatorFS(tempFilePath)
+
+	height, round := int64(10), 1
+	voteType := types.VoteTypePrevote
+
+	// sign a vote for first time
+	vote := newVote(height, round, voteType, blockID1)
+	err := privVal.SignVote("mychainid", vote)
+	assert.NoError(err, "expected no error signing vote")
+
+	// try to sign the same vote again; should be fine
+	err = privVal.SignVote("mychainid", vote)
+	assert.NoError(err, "expected no error on signing same vote")
+
+	// now try some bad votes
+	privVal.SetConflictStrategy(ConflictError)
+	cases := []*types.Vote{
+		newVote(height, round-1, voteType, blockID1),   // round regression
+		newVote(height-1, round, voteType, blockID1),   // height regression
+		newVote(height-2, round+4, voteType, blockID1), // height regression and different round
+		newVote(height, round, voteType, blockID2),     // different block
+	}
+
+	for _, c := range cases {
+		err = privVal.SignVote("mychainid", c)
+		assert.Error(err, "expected error on signing conflicting vote")
+	}
+
+	// try signing a vote with a different time stamp
+	sig := vote.Signature
+	vote.Timestamp = vote.Timestamp.Add(time.Duration(1000))
+	err = privVal.SignVote("mychainid", vote)
+	assert.NoError(err)
+	assert.Equal(sig, vote.Signature)
+
+	// the state was saved along with the keys
+	loaded := LoadPrivValidatorFS(tempFilePath)
+	assert.Equal(height, loaded.LastHeight)
+	assert.Equal(types.SignBytes("mychainid", vote), []byte(loaded.LastSignBytes))
+	assert.Equal(privVal.PrivKey, loaded.PrivKey)
+	err = loaded.SignVote("mychainid", newVote(height, round, voteType, blockID2))
+	require.Error(err)
+}
+
+func TestPrivValidatorSignProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	_, tempFilePath := cmn.Tempfile("priv_validator_")
+	privVal := GenPrivValidatorFS(tempFilePath)
+	privVal.SetConflictStrategy(ConflictError)
+
+	block1 := types.PartSetHeader{Total: 5, Hash: []byte{1, 2, 3}}
+	block2 := types.PartSetHeader{Total: 10, Hash: []byte{3, 2, 1}}
+	height, round := int64(10), 1
+
+	// sign a proposal for first time
+	proposal := newPrivValProposal(height, round, block1)
+	err := privVal.SignProposal("mychainid", proposal)
+	assert.NoError(err, "expected no error signing proposal")
+
+	// try to sign the same proposal again; should be fine
+	err = privVal.SignProposal("mychainid", proposal)
+	assert.NoError(err, "expected no error on signing same proposal")
+
+	// now try some bad Proposals
+	cases := []*types.Proposal{
+		newPrivValProposal(height, round-1, block1),   // round regression
+		newPrivValProposal(height-1, round, block1),   // height regression
+		newPrivValProposal(height-2, round+4, block1), // height regression and different round
+		newPrivValProposal(height, round, block2),     // different block
+	}
+
+	for _, c := range cases {
+		err = privVal.SignProposal("mychainid", c)
+		assert.Error(err, "expected error on signing conflicting proposal")
+	}
+
+	// try signing a proposal with a different time stamp
+	sig := proposal.Signature
+	proposal.Timestamp = proposal.Timestamp.Add(time.Duration(1000))
+	err = privVal.SignProposal("mychainid", proposal)
+	assert.NoError(err)
+	assert.Equal(sig, proposal.Signature)
+}
+
+func TestPrivValidatorWithoutFile(t *testing.T) {
+	assert := assert.New(t)
+
+	privVal := GenPrivValidatorFS("")
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.NoError(privVal.SignVote("mychainid", vote))
+	assert.EqualValues(10, privVal.LastHeight)
+	assert.Panics(func() { privVal.Save() })
+}
+
+func TestPrivValidatorReadsTypesFile(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// a file saved by types.PrivValidatorFS keeps protecting what it signed
+	_, tempFilePath := cmn.Tempfile("priv_validator_")
+	old := types.GenPrivValidatorFS(tempFilePath)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(old.SignVote("mychainid", vote))
+
+	privVal := LoadPrivValidatorFS(tempFilePath)
+	assert.Equal(old.PubKey, privVal.PubKey)
+	assert.Equal(old.LastHeight, privVal.LastHeight)
+	assert.Equal(old.LastRound, privVal.LastRound)
+	assert.Equal(old.LastStep, privVal.LastStep)
+	assert.Equal(old.LastSignBytes, privVal.LastSignBytes)
+	privVal.SetConflictStrategy(ConflictError)
+	assert.Error(privVal.SignVote("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2)))
+
+	// and the other way around
+	require.Nil(privVal.SignVote("mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	old = types.LoadPrivValidatorFS(tempFilePath)
+	assert.EqualValues(11, old.LastHeight)
+	assert.Error(old.SignVote("mychainid", newVote(11, 0, types.VoteTypePrevote, blockID2)))
+}
+
+func newPrivValProposal(height int64, round int, partsHeader types.PartSetHeader) *types.Proposal {
+	return &types.Proposal{
+		Height:           height,
+		Round:            round,
+		BlockPartsHeader: partsHeader,
+		POLRound:         -1,
+		Timestamp:        time.Now().UTC(),
+	}
+}
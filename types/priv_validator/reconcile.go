@@ -0,0 +1,142 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// ReconcileSources combines copies of the LastSignedInfo kept in several places
+// (eg. a local file, a KMS and a peer) to start from, after a failover.
+// It returns a new LastSignedInfo, not persisted anywhere, with the highest
+// height/round/step among the sources, and the highest floor height and Seq,
+// with the SignHash of the source with that Seq.
+//
+// Sources at the same HRS must agree: if they signed data that differs
+// by more than the timestamp, it returns an error, as one of them must be wrong.
+// A source at that HRS without a signature (see EnsureAtLeast) agrees with any.
+// A source in hash-only mode (see SetHashOnly) only agrees with the exact
+// same sign bytes, and the one with the bytes is kept.
+// Sources bound to different chains (see Bind) return ErrChainMismatch, and
+// to different keys (see RotateKey) ErrKeyMismatch; a source that's bound
+// binds the result, while the high-water mark of the old key is kept if a
+// source has it, to be safe. Likewise, if a source is tombstoned, so is the
+// result, with the first tombstone, and if a source has an unacknowledged
+// Reset, so has the result.
+// Nil sources are skipped.
+func ReconcileSources(sources ...*LastSignedInfo) (*LastSignedInfo, error) {
+	var best *LastSignedInfo
+	var floorHeight int64
+	var seq uint64
+	var chainID string
+	var signHash []byte
+	var rotation *KeyRotation
+	var tombstone *Tombstone
+	var unacknowledgedReset bool
+	for _, source := range sources {
+		if source == nil {
+			continue
+		}
+		if source.ChainID != "" {
+			if chainID != "" && source.ChainID != chainID {
+				return nil, ErrChainMismatch
+			}
+			chainID = source.ChainID
+		}
+		if source.KeyRotation != nil {
+			if rotation != nil && !rotation.NewPubKey.Equals(source.KeyRotation.NewPubKey) {
+				return nil, ErrKeyMismatch
+			}
+			rotation = source.KeyRotation
+		}
+		if tombstone == nil {
+			tombstone = source.TombstoneInfo
+		}
+		unacknowledgedReset = unacknowledgedReset || source.UnacknowledgedReset
+		if source.FloorHeight > floorHeight {
+			floorHeight = source.FloorHeight
+		}
+		if source.Seq > seq || (source.Seq == seq && signHash == nil) {
+			seq, signHash = source.Seq, source.SignHash
+		}
+		if best == nil {
+			best = source
+			continue
+		}
+
+		switch compareHRS(source.LastHeight, source.LastRound, source.LastStep,
+			best.LastHeight, best.LastRound, best.LastStep) {
+		case 1:
+			best = source
+		case 0:
+			if !best.hasSignBytes() {
+				best = source
+			} else if source.hasSignBytes() {
+				if !sameRecordedData(best, source) {
+					return nil, fmt.Errorf("Sources conflict at %v/%v/%v", source.LastHeight, source.LastRound, source.LastStep)
+				}
+				// keep the bytes over their hash
+				if best.LastSignBytes == nil {
+					best = source
+				}
+			}
+		}
+	}
+	if best == nil {
+		return nil, errors.New("No sources to reconcile")
+	}
+
+	info := NewLastSignedInfo()
+	if err := info.Restore(best.Snapshot()); err != nil {
+		return nil, err
+	}
+	info.FloorHeight = floorHeight
+	info.Seq = seq
+	info.SignHash = copyBytes(signHash)
+	info.ChainID = chainID
+	info.KeyRotation = copyKeyRotation(rotation)
+	info.TombstoneInfo = copyTombstone(tombstone)
+	info.UnacknowledgedReset = unacknowledgedReset
+	return info, nil
+}
+
+// returns true if the sign bytes recorded by a and b are the same data,
+// comparing hashes unless both have the bytes
+func sameRecordedData(a, b *LastSignedInfo) bool {
+	if a.LastSignBytes != nil && b.LastSignBytes != nil {
+		return sameSignedData(a.LastSignBytes, b.LastSignBytes, a.now())
+	}
+	if a.LastSignBytes != nil {
+		return b.matchesSignBytes(a.LastSignBytes)
+	}
+	if b.LastSignBytes != nil {
+		return a.matchesSignBytes(b.LastSignBytes)
+	}
+	return bytes.Equal(a.LastSignBytesHash, b.LastSignBytesHash)
+}
+
+// returns true if the sign bytes are equal, or the same vote or proposal
+// with different timestamps. now is used to normalize the timestamps
+func sameSignedData(signBytesA, signBytesB []byte, now time.Time) bool {
+	if bytes.Equal(signBytesA, signBytesB) {
+		return true
+	}
+	decodedA, okA := decodeSignBytes(signBytesA)
+	decodedB, okB := decodeSignBytes(signBytesB)
+	if !okA || !okB {
+		return false
+	}
+	switch decodedA.(type) {
+	case types.CanonicalJSONOnceVote:
+		_, ok := decodedB.(types.CanonicalJSONOnceVote)
+		return ok && checkVotesOnlyDifferByTimestamp(signBytesA, signBytesB, now)
+	case types.CanonicalJSONOnceProposal:
+		_, ok := decodedB.(types.CanonicalJSONOnceProposal)
+		return ok && checkProposalsOnlyDifferByTimestamp(signBytesA, signBytesB, now)
+	default:
+		return false
+	}
+}
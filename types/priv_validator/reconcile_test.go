@@ -0,0 +1,62 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestReconcileSources(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	signed := func(vote *types.Vote) *LastSignedInfo {
+		info := NewLastSignedInfo()
+		require.Nil(info.SignVote(signer, "mychainid", vote))
+		return info
+	}
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	local := signed(vote)
+	kms := signed(newVote(10, 0, types.VoteTypePrecommit, blockID1))
+	peer := signed(newVote(9, 3, types.VoteTypePrecommit, blockID1))
+	peer.FloorHeight = 5
+	peer.Seq = 40
+
+	info, err := ReconcileSources(kms, nil, local, peer)
+	require.Nil(err)
+	assert.Equal(int64(10), info.LastHeight)
+	assert.Equal(1, info.LastRound)
+	assert.Equal(local.LastSignBytes, info.LastSignBytes)
+	assert.True(info.HasSignature(vote.Signature))
+	assert.Equal(int64(5), info.FloorHeight)
+	assert.EqualValues(40, info.Seq)
+
+	// the result doesn't share memory with the sources
+	info.LastSignBytes[0] = 'X'
+	assert.NotEqual(info.LastSignBytes, local.LastSignBytes)
+
+	// same HRS, only the timestamp differs
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	_, err = ReconcileSources(local, signed(&later))
+	assert.Nil(err)
+
+	// same HRS, one without signature
+	ensured := NewLastSignedInfo()
+	_, err = ensured.EnsureAtLeast(10, 1, stepPrevote)
+	require.Nil(err)
+	info, err = ReconcileSources(ensured, local)
+	require.Nil(err)
+	assert.True(info.HasSignature(vote.Signature))
+
+	// same HRS, different block
+	_, err = ReconcileSources(kms, local, signed(newVote(10, 1, types.VoteTypePrevote, blockID2)))
+	assert.Error(err)
+
+	_, err = ReconcileSources()
+	assert.Error(err)
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ReconstructVoteSignBytes rebuilds the canonical sign bytes of the vote for
+// blockID at the given height/round/step and timestamp, for storage that keeps
+// those instead of the LastSignBytes.
+// The validator address and index are not part of the sign bytes.
+func ReconstructVoteSignBytes(chainID string, height int64, round int, step int8,
+	blockID types.BlockID, timestamp time.Time) ([]byte, error) {
+	var voteType byte
+	switch step {
+	case stepPrevote:
+		voteType = types.VoteTypePrevote
+	case stepPrecommit:
+		voteType = types.VoteTypePrecommit
+	default:
+		return nil, fmt.Errorf("Step %v is not a vote", step)
+	}
+	vote := &types.Vote{
+		Height:    height,
+		Round:     round,
+		Type:      voteType,
+		BlockID:   blockID,
+		Timestamp: timestamp,
+	}
+	return canonicalVoteBytesV1(chainID, vote), nil
+}
+
+// ReconstructedSignBytes rebuilds the sign bytes of the vote at the latest
+// height/round/step for blockID and the candidate timestamp, and returns them
+// if the LastSignature verifies for them with pubKey, so it can be reused.
+//
+// NOTE: the signature only verifies if the candidate timestamp is the one that
+// was signed (to the millisecond), so reusing it for a vote with a different
+// timestamp also needs the signed timestamp to be stored.
+func (info *LastSignedInfo) ReconstructedSignBytes(pubKey crypto.PubKey, chainID string,
+	blockID types.BlockID, timestamp time.Time) ([]byte, bool) {
+	if info.LastSignature.Empty() {
+		return nil, false
+	}
+	signBytes, err := ReconstructVoteSignBytes(chainID, info.LastHeight, info.LastRound, info.LastStep, blockID, timestamp)
+	if err != nil {
+		return nil, false
+	}
+	if !pubKey.VerifyBytes(signBytes, info.LastSignature.Crypto()) {
+		return nil, false
+	}
+	return signBytes, true
+}
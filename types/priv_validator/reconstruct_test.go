@@ -0,0 +1,57 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestReconstructVoteSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, voteType := range []byte{types.VoteTypePrevote, types.VoteTypePrecommit} {
+		vote := newVote(10, 1, voteType, blockID1)
+		vote.ValidatorIndex = 3
+		signBytes, err := ReconstructVoteSignBytes("mychainid", 10, 1, voteToStep(vote), blockID1, vote.Timestamp)
+		assert.Nil(err)
+		assert.Equal(types.SignBytes("mychainid", vote), signBytes)
+	}
+
+	_, err := ReconstructVoteSignBytes("mychainid", 10, 1, stepPropose, blockID1, time.Now())
+	assert.Error(err)
+}
+
+func TestReconstructedSignBytes(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, pub := newTestSigner()
+	_, ok := info.ReconstructedSignBytes(pub, "mychainid", blockID1, time.Now())
+	assert.False(ok)
+
+	vote := newVote(10, 1, types.VoteTypePrecommit, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	lastSignBytes := []byte(info.LastSignBytes)
+	// compacted: only the HRS and signature are kept
+	info.LastSignBytes = nil
+
+	signBytes, ok := info.ReconstructedSignBytes(pub, "mychainid", blockID1, vote.Timestamp)
+	assert.True(ok)
+	assert.Equal(lastSignBytes, signBytes)
+
+	// sub-millisecond differences don't matter, others do
+	_, ok = info.ReconstructedSignBytes(pub, "mychainid", blockID1, vote.Timestamp.Truncate(time.Millisecond).Add(time.Microsecond))
+	assert.True(ok)
+	_, ok = info.ReconstructedSignBytes(pub, "mychainid", blockID1, vote.Timestamp.Add(time.Second))
+	assert.False(ok)
+	_, ok = info.ReconstructedSignBytes(pub, "mychainid", blockID2, vote.Timestamp)
+	assert.False(ok)
+	_, ok = info.ReconstructedSignBytes(pub, "otherchainid", blockID1, vote.Timestamp)
+	assert.False(ok)
+	_, other := newTestSigner()
+	_, ok = info.ReconstructedSignBytes(other, "mychainid", blockID1, vote.Timestamp)
+	assert.False(ok)
+}
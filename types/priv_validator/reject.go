@@ -0,0 +1,42 @@
+package types
+
+// RejectEvent describes a rejected attempt to sign, for auditing.
+type RejectEvent struct {
+	// The height/round/step that was to be signed.
+	Height int64
+	Round  int
+	Step   int8
+
+	// The latest height/round/step at the time.
+	LastHeight int64
+	LastRound  int
+	LastStep   int8
+
+	// Why it was rejected, eg. ErrHeightRegression or ErrConflictingData.
+	Err error
+}
+
+// SetOnReject sets a callback that's called with every rejection by Verify or
+// SignVote, exactly once per rejected call, and never when signing succeeds.
+// Unlike metrics, it reports each event, eg. to feed an audit log.
+// It's called synchronously, so it should return quickly.
+// Passing nil removes it.
+func (info *LastSignedInfo) SetOnReject(onReject func(RejectEvent)) {
+	info.onReject = onReject
+}
+
+func (info *LastSignedInfo) reject(height int64, round int, step int8, err error) {
+	info.emitEvent(SignerEvent{Type: EventRejected, Height: height, Round: round, Step: step, Err: err})
+	if info.onReject == nil {
+		return
+	}
+	info.onReject(RejectEvent{
+		Height:     height,
+		Round:      round,
+		Step:       step,
+		LastHeight: info.LastHeight,
+		LastRound:  info.LastRound,
+		LastStep:   info.LastStep,
+		Err:        err,
+	})
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestOnReject(t *testing.T) {
+	assert := assert.New(t)
+
+	var events []RejectEvent
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetOnReject(func(event RejectEvent) { events = append(events, event) })
+	info.SetHistorySize(10)
+	signer, _ := newTestSigner()
+
+	// successes don't fire
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.Empty(events)
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(10, 2, types.VoteTypePrevote, blockID1)))
+	assert.Empty(events)
+
+	cases := []struct {
+		vote *types.Vote
+		err  error
+	}{
+		{newVote(9, 1, types.VoteTypePrevote, blockID1), ErrHeightRegression},
+		{newVote(10, 0, types.VoteTypePrevote, blockID1), ErrRoundRegression},
+		{newVote(10, 2, types.VoteTypePrevote, blockID2), ErrConflictingData},
+		{newVote(10, 1, types.VoteTypePrevote, blockID2), ErrBackdatedConflict},
+	}
+	for i, c := range cases {
+		events = nil
+		assert.Equal(c.err, info.SignVote(signer, "mychainid", c.vote), "case %d", i)
+		if assert.Len(events, 1, "case %d", i) {
+			assert.Equal(RejectEvent{
+				Height: c.vote.Height, Round: c.vote.Round, Step: stepPrevote,
+				LastHeight: 10, LastRound: 2, LastStep: stepPrevote,
+				Err: c.err,
+			}, events[0], "case %d", i)
+		}
+	}
+
+	// Verify on its own
+	events = nil
+	_, err := info.Verify(10, 2, stepPropose)
+	assert.Equal(ErrStepRegression, err)
+	assert.Len(events, 1)
+	_, err = info.Verify(10, 2, stepPrecommit)
+	assert.Nil(err)
+	assert.Len(events, 1)
+
+	info.SetOnReject(nil)
+	assert.Error(info.SignVote(signer, "mychainid", newVote(9, 1, types.VoteTypePrevote, blockID1)))
+	assert.Len(events, 1)
+}
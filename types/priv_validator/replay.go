@@ -0,0 +1,144 @@
+package types
+
+import (
+	"context"
+	"crypto/sha512"
+	"fmt"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+)
+
+// SignOp is a logged sign operation: the sign bytes of a vote or proposal,
+// and the signature that was made for them, if it was logged.
+type SignOp struct {
+	SignBytes data.Bytes `json:"sign_bytes"`
+	Signature Signature  `json:"signature,omitempty"`
+}
+
+// ReplayOutcome is what the signer would have done with a SignOp.
+type ReplayOutcome struct {
+	Index  int // of the SignOp
+	Height int64
+	Round  int
+	Step   int8
+	Logged bool // the SignOp has a signature
+
+	Signed bool       // a fresh signature would have been made
+	Reused bool       // the LastSignature would have been reused
+	Reason SignReason // as returned by SignVoteWithReason
+	Err    error      // why it would have been refused, nil otherwise
+}
+
+// ReplayReport lists the outcome of each SignOp, in order.
+type ReplayReport struct {
+	Outcomes []ReplayOutcome
+}
+
+// Refused returns the outcomes of the SignOps that would have been refused,
+// eg. where a double-sign would have been caught.
+func (report ReplayReport) Refused() []ReplayOutcome {
+	var refused []ReplayOutcome
+	for _, outcome := range report.Outcomes {
+		if outcome.Err != nil {
+			refused = append(refused, outcome)
+		}
+	}
+	return refused
+}
+
+// Missed returns the outcomes of the SignOps that were logged with a
+// signature, but would have been refused: the signature was made anyway,
+// eg. by a signer without these checks or a misconfigured one.
+func (report ReplayReport) Missed() []ReplayOutcome {
+	var missed []ReplayOutcome
+	for _, outcome := range report.Refused() {
+		if outcome.Logged {
+			missed = append(missed, outcome)
+		}
+	}
+	return missed
+}
+
+// ReplayOperations feeds ops, in order, through the decisions of SignVote and
+// SignProposal (Verify, the comparisons, Set) from the start state, for
+// forensics: the report tells what the signer would have done with each,
+// and final is the state it would have ended in. start isn't changed, and
+// nothing is persisted or signed.
+//
+// The kind, height/round/step and chain ID of each op are read from its sign
+// bytes; an op whose sign bytes can't be decoded is refused. The signature
+// recorded for a fresh signature is the logged one, or a placeholder, the
+// SHA-512 of the sign bytes, that doesn't verify.
+// Unlike the default ConflictFreeze, conflicts are refused without freezing,
+// so the replay goes on; and there's no SignPolicy.
+func ReplayOperations(start LastSignedInfo, ops []SignOp) (final LastSignedInfo, report ReplayReport) {
+	info := NewLastSignedInfo()
+	info.restore(start)
+	info.SetConflictStrategy(ConflictError)
+
+	for i, op := range ops {
+		req, err := replayRequest(op.SignBytes)
+		outcome := ReplayOutcome{Index: i, Height: req.height, Round: req.round, Step: req.step,
+			Logged: !op.Signature.Empty(), Reason: NotSigned, Err: err}
+		if err == nil {
+			seq := info.Seq
+			outcome.Reason, outcome.Err = info.sign(context.Background(), replaySigner{op}, req)
+			outcome.Signed = outcome.Err == nil && info.Seq != seq
+			outcome.Reused = outcome.Err == nil && info.Seq == seq
+		}
+		report.Outcomes = append(report.Outcomes, outcome)
+	}
+	return info.Snapshot(), report
+}
+
+// replayRequest is the signRequest of SignVote or SignProposal for signBytes.
+func replayRequest(signBytes []byte) (signRequest, error) {
+	decoded, ok := decodeSignBytes(signBytes)
+	if !ok {
+		return signRequest{}, fmt.Errorf("Cannot decode sign bytes %s", signBytes)
+	}
+	req := signRequest{
+		signBytes:    signBytes,
+		allow:        func() error { return nil },
+		setSignature: func(sig crypto.Signature) {},
+		setTimestamp: func(timestamp time.Time) {},
+	}
+	switch decoded := decoded.(type) {
+	case types.CanonicalJSONOnceVote:
+		req.span = "LastSignedInfo.SignVote"
+		req.chainID, req.height, req.round = decoded.ChainID, decoded.Vote.Height, decoded.Vote.Round
+		req.onlyDifferByTimestamp, req.normalize = checkVotesOnlyDifferByTimestamp, normalizeVotes
+		switch decoded.Vote.Type {
+		case types.VoteTypePrevote:
+			req.step = stepPrevote
+		case types.VoteTypePrecommit:
+			req.step = stepPrecommit
+		default:
+			return req, fmt.Errorf("Unknown vote type %v", decoded.Vote.Type)
+		}
+	case types.CanonicalJSONOnceProposal:
+		req.span = "LastSignedInfo.SignProposal"
+		req.chainID, req.height, req.round = decoded.ChainID, decoded.Proposal.Height, decoded.Proposal.Round
+		req.step = stepPropose
+		req.onlyDifferByTimestamp, req.normalize = checkProposalsOnlyDifferByTimestamp, normalizeProposals
+	}
+	return req, nil
+}
+
+// replaySigner "signs" with the logged signature, or a placeholder.
+type replaySigner struct {
+	op SignOp
+}
+
+func (s replaySigner) Sign(msg []byte) (crypto.Signature, error) {
+	if !s.op.Signature.Empty() {
+		return s.op.Signature.Crypto(), nil
+	}
+	var sig crypto.SignatureEd25519
+	hash := sha512.Sum512(msg)
+	copy(sig[:], hash[:])
+	return sig.Wrap(), nil
+}
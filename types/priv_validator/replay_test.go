@@ -0,0 +1,69 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestReplayOperations(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	logged := func(vote *types.Vote) SignOp {
+		signBytes := types.SignBytes("mychainid", vote)
+		sig, err := signer.Sign(signBytes)
+		require.Nil(err)
+		return SignOp{SignBytes: signBytes, Signature: SignatureFromCrypto(sig)}
+	}
+	prevote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	later := *prevote
+	later.Timestamp = prevote.Timestamp.Add(time.Second)
+
+	start := NewLastSignedInfo()
+	require.Nil(start.SignVote(signer, "mychainid", newVote(9, 0, types.VoteTypePrecommit, blockID1)))
+	startSnapshot := start.Snapshot()
+	ops := []SignOp{
+		logged(prevote),
+		{SignBytes: types.SignBytes("mychainid", &later)},       // reused
+		logged(newVote(10, 0, types.VoteTypePrevote, blockID2)), // double-sign, signed anyway
+		{SignBytes: types.SignBytes("mychainid", newVote(8, 0, types.VoteTypePrevote, blockID1))},
+		{SignBytes: []byte("garbage")},
+		{SignBytes: types.SignBytes("mychainid", &types.Proposal{Height: 11, POLRound: -1})},
+	}
+
+	final, report := ReplayOperations(start.Snapshot(), ops)
+	require.Len(report.Outcomes, len(ops))
+	assert.True(report.Outcomes[0].Signed)
+	assert.Equal(HeightAdvanced, report.Outcomes[0].Reason)
+	assert.True(report.Outcomes[1].Reused)
+	assert.Equal(Reused, report.Outcomes[1].Reason)
+	assert.Equal(ErrConflictingData, report.Outcomes[2].Err)
+	assert.Equal(ContentDiffers, report.Outcomes[2].Reason)
+	assert.Equal(ErrHeightRegression, report.Outcomes[3].Err)
+	assert.Equal(NotSigned, report.Outcomes[3].Reason)
+	assert.Error(report.Outcomes[4].Err)
+	assert.Equal(NotSigned, report.Outcomes[4].Reason)
+	assert.True(report.Outcomes[5].Signed)
+	assert.Equal(stepPropose, report.Outcomes[5].Step)
+
+	assert.Len(report.Refused(), 3)
+	missed := report.Missed()
+	require.Len(missed, 1)
+	assert.Equal(2, missed[0].Index)
+
+	// the final state, with the logged signature; start is left alone
+	assert.EqualValues(11, final.LastHeight)
+	assert.Equal(stepPropose, final.LastStep)
+	assert.Equal(ops[0].Signature, mustReplay(t, start, ops[:1]).LastSignature)
+	assert.Equal(startSnapshot, start.Snapshot())
+}
+
+func mustReplay(t *testing.T, start *LastSignedInfo, ops []SignOp) LastSignedInfo {
+	final, report := ReplayOperations(start.Snapshot(), ops)
+	require.Empty(t, report.Refused())
+	return final
+}
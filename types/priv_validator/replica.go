@@ -0,0 +1,106 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrReadOnly = errors.New("LastSignedInfo replica is read-only")
+)
+
+// HRSUpdate is a change of the LastSignedInfo of a validator, as seen by
+// a ReadOnlyReplica, and as emitted by the signing helpers, see SetOnUpdate.
+type HRSUpdate struct {
+	Height    int64
+	Round     int
+	Step      int8
+	Signature crypto.Signature // empty if advanced without signing
+	SignBytes []byte
+
+	// Why it was signed, and whether the LastSignature was reused
+	// rather than a fresh one produced. Not used by Apply.
+	Reason SignReason
+	Reused bool
+
+	// When it was signed, by the wall clock. Not used by Apply.
+	Time time.Time
+}
+
+// ReadOnlyReplica follows the LastSignedInfo of a validator from its updates,
+// eg. on an observability node, and can't be used to sign: Set, SignVote and
+// Save return ErrReadOnly. It implements SignerState, so the latest state can be
+// read the same way as from a file or a DB.
+// It's safe for concurrent use.
+type ReadOnlyReplica struct {
+	mtx  sync.Mutex
+	view *LastSignedInfo
+}
+
+var _ SignerState = (*ReadOnlyReplica)(nil)
+
+// NewReadOnlyReplica returns a replica in the initial state.
+func NewReadOnlyReplica() *ReadOnlyReplica {
+	return &ReadOnlyReplica{view: NewLastSignedInfo()}
+}
+
+// Apply advances the replica to the update.
+// It returns an error for an update behind the replica, eg. out of order,
+// and for an invalid height/round/step. An update at the same HRS replaces it.
+func (r *ReadOnlyReplica) Apply(update HRSUpdate) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if err := validateHRS(update.Height, update.Round, update.Step); err != nil {
+		return err
+	}
+	if compareHRS(update.Height, update.Round, update.Step,
+		r.view.LastHeight, r.view.LastRound, r.view.LastStep) < 0 {
+		return fmt.Errorf("Update to %v/%v/%v is behind %v", update.Height, update.Round, update.Step, r.view)
+	}
+	r.view.LastHeight = update.Height
+	r.view.LastRound = update.Round
+	r.view.LastStep = update.Step
+	r.view.LastSignature = SignatureFromCrypto(update.Signature)
+	r.view.LastSignBytes = copyBytes(update.SignBytes)
+	r.view.LastSignBytesHash = nil
+	r.view.LastExtension = nil
+	r.view.Unsigned = update.SignBytes == nil
+	return nil
+}
+
+// Load implements SignerState. It returns a copy of the state,
+// which doesn't follow later updates.
+func (r *ReadOnlyReplica) Load() (*LastSignedInfo, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	info := NewLastSignedInfo()
+	if err := info.Restore(r.view.Snapshot()); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Read implements SignerStateReader, the same as Load.
+func (r *ReadOnlyReplica) Read() (*LastSignedInfo, error) {
+	return r.Load()
+}
+
+// Save implements SignerState. It always returns ErrReadOnly.
+func (r *ReadOnlyReplica) Save(*LastSignedInfo) error {
+	return ErrReadOnly
+}
+
+// Set always returns ErrReadOnly. Use Apply to advance the replica.
+func (r *ReadOnlyReplica) Set(height int64, round int, step int8, signBytes []byte, sig crypto.Signature) error {
+	return ErrReadOnly
+}
+
+// SignVote always returns ErrReadOnly, without signing.
+func (r *ReadOnlyReplica) SignVote(signer types.Signer, chainID string, vote *types.Vote) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestReadOnlyReplica(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// the validator, and the replica following it
+	validator := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	replica := NewReadOnlyReplica()
+	follow := func() {
+		require.Nil(replica.Apply(HRSUpdate{
+			Height:    validator.LastHeight,
+			Round:     validator.LastRound,
+			Step:      validator.LastStep,
+			Signature: validator.LastSignature.Crypto(),
+			SignBytes: validator.LastSignBytes,
+		}))
+	}
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(validator.SignVote(signer, "mychainid", vote))
+	follow()
+	info, err := replica.Load()
+	require.Nil(err)
+	assert.EqualValues(10, info.LastHeight)
+	assert.Equal(stepPrevote, info.LastStep)
+	assert.True(info.HasSignature(vote.Signature))
+	assert.Equal(validator.LastSignBytes, info.LastSignBytes)
+
+	// the copy doesn't follow, nor affect, the replica
+	info.LastHeight = 100
+	require.Nil(validator.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	follow()
+	assert.EqualValues(100, info.LastHeight)
+	info, err = replica.Load()
+	require.Nil(err)
+	assert.Equal(stepPrecommit, info.LastStep)
+
+	// without a signature
+	require.Nil(replica.Apply(HRSUpdate{Height: 11, Step: stepPrevote}))
+	info, err = replica.Load()
+	require.Nil(err)
+	assert.True(info.Unsigned)
+
+	// out of order, or invalid
+	assert.Error(replica.Apply(HRSUpdate{Height: 10, Step: stepPrecommit}))
+	assert.Error(replica.Apply(HRSUpdate{Height: 12, Step: 42}))
+
+	// it never signs
+	next := newVote(12, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(ErrReadOnly, replica.SignVote(signer, "mychainid", next))
+	assert.True(next.Signature.Empty())
+	assert.Equal(ErrReadOnly, replica.Set(12, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	assert.Equal(ErrReadOnly, replica.Save(validator))
+	info, err = replica.Load()
+	require.Nil(err)
+	assert.EqualValues(11, info.LastHeight)
+}
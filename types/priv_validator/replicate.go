@@ -0,0 +1,62 @@
+package types
+
+import (
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// HRS is a height/round/step.
+type HRS struct {
+	Height int64
+	Round  int
+	Step   int8
+}
+
+// SetOnSign sets a callback that's called with each fresh signature of
+// SignVote or SignProposal, once it's recorded with Set and before it's set on
+// the vote or proposal, eg. to push it to a remote store for HA.
+// Reused signatures aren't pushed again. The sign bytes are a copy.
+//
+// It's called synchronously. The LastSignedInfo holds no lock of its own, but
+// the caller may, eg. PrivValidatorFS holds its mutex while signing, so it
+// should return quickly. Passing nil removes it.
+//
+// Nothing waits for the push to succeed; see SetReplicator for that.
+func (info *LastSignedInfo) SetOnSign(onSign func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature)) {
+	info.onSign = onSign
+}
+
+// SetReplicator sets a callback that replicates each signature of SignVote or
+// SignProposal to a durable store before it's returned. The contract is:
+//
+//   - it's called once the signature is recorded with Set (and so persisted,
+//     if a filePath or SignerState is set), after OnSign;
+//   - if it returns an error, SignVote and SignProposal return it without
+//     setting the signature on the vote or proposal, so it can't be broadcast;
+//   - the signature stays recorded, so signing the same vote or proposal again
+//     reuses it, and it's then replicated again: it's also called for reused
+//     signatures, so a retry can't broadcast a signature that never got
+//     replicated. It must be idempotent;
+//   - like OnSign, it's called synchronously.
+//
+// Passing nil removes it.
+func (info *LastSignedInfo) SetReplicator(replicate func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature) error) {
+	info.replicate = replicate
+}
+
+// pushSignature calls the OnSign callback for a fresh signature, and the
+// replicator for any signature, with the LastSignedInfo and the signBytes the
+// LastSignature covers, as only their hash may be recorded.
+func (info *LastSignedInfo) pushSignature(chainID string, signBytes []byte, fresh bool) error {
+	if info.onSign == nil && info.replicate == nil {
+		return nil
+	}
+	hrs := HRS{info.LastHeight, info.LastRound, info.LastStep}
+	sig := info.LastSignature.Crypto()
+	if fresh && info.onSign != nil {
+		info.onSign(chainID, hrs, copyBytes(signBytes), sig)
+	}
+	if info.replicate == nil {
+		return nil
+	}
+	return info.replicate(chainID, hrs, copyBytes(signBytes), sig)
+}
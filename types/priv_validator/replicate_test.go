@@ -0,0 +1,75 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+type pushed struct {
+	chainID   string
+	hrs       HRS
+	signBytes []byte
+	sig       crypto.Signature
+}
+
+func TestOnSign(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	var pushes []pushed
+	info.SetOnSign(func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature) {
+		pushes = append(pushes, pushed{chainID, hrs, signBytes, sig})
+	})
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	// reused, not pushed again
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	proposal := &types.Proposal{Height: 11, POLRound: -1}
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+
+	require.Len(pushes, 2)
+	assert.Equal(pushed{"mychainid", HRS{10, 0, stepPrevote}, types.SignBytes("mychainid", vote), vote.Signature}, pushes[0])
+	assert.Equal(HRS{11, 0, stepPropose}, pushes[1].hrs)
+	assert.Equal(proposal.Signature, pushes[1].sig)
+}
+
+func TestReplicator(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	errReplication := errors.New("replication failed")
+	var replicated []pushed
+	fail := true
+	info.SetReplicator(func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature) error {
+		if fail {
+			return errReplication
+		}
+		replicated = append(replicated, pushed{chainID, hrs, signBytes, sig})
+		return nil
+	})
+	signer, _ := newTestSigner()
+
+	// the signature is recorded, but not set on the vote
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(errReplication, info.SignVote(signer, "mychainid", vote))
+	assert.True(vote.Signature.Empty())
+	assert.EqualValues(10, info.LastHeight)
+	assert.False(info.LastSignature.Empty())
+
+	// the retry reuses it, and replicates it
+	fail = false
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	assert.True(info.HasSignature(vote.Signature))
+	require.Len(replicated, 1)
+	assert.Equal(pushed{"mychainid", HRS{10, 0, stepPrevote}, types.SignBytes("mychainid", vote), vote.Signature}, replicated[0])
+
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	assert.Len(replicated, 2)
+}
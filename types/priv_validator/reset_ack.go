@@ -0,0 +1,38 @@
+package types
+
+import (
+	"errors"
+)
+
+var (
+	ErrResetNotAcknowledged = errors.New("LastSignedInfo was reset, signing needs AcknowledgeReset")
+)
+
+// SetRequireResetAck makes Reset leave the LastSignedInfo unable to sign,
+// with ErrResetNotAcknowledged, until AcknowledgeReset is called, so an
+// automated restart can't sign right after an accidental Reset cleared the
+// high-water mark. This is persisted with the reset, so a restart doesn't
+// clear it, whatever the option is set to then. It's off by default.
+func (info *LastSignedInfo) SetRequireResetAck(require bool) {
+	info.requireResetAck = require
+}
+
+// ResetPending returns true if a Reset is waiting for AcknowledgeReset.
+func (info *LastSignedInfo) ResetPending() bool {
+	return info.UnacknowledgedReset
+}
+
+// AcknowledgeReset confirms the Reset was meant, so signing can resume.
+// It's logged, and a no-op if there's no Reset to acknowledge.
+func (info *LastSignedInfo) AcknowledgeReset() error {
+	if !info.UnacknowledgedReset {
+		return nil
+	}
+	info.UnacknowledgedReset = false
+	if err := info.persist(); err != nil {
+		info.UnacknowledgedReset = true
+		return err
+	}
+	info.getLogger().Info("Reset acknowledged, signing resumes from the cleared height/round/step")
+	return nil
+}
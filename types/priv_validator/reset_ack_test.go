@@ -0,0 +1,50 @@
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestRequireResetAck(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// off by default
+	require.Nil(info.Reset())
+	assert.False(info.ResetPending())
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	info.SetRequireResetAck(true)
+	require.Nil(info.Reset())
+	assert.True(info.ResetPending())
+	assert.False(info.Health().Ready)
+	assert.Equal(ErrResetNotAcknowledged, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrResetNotAcknowledged, info.SignProposal(signer, "mychainid", &types.Proposal{Height: 10, POLRound: -1}))
+
+	// a restart doesn't clear it, even without the option
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.True(loaded.ResetPending())
+	assert.Equal(ErrResetNotAcknowledged, loaded.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	reconciled, err := ReconcileSources(loaded, NewLastSignedInfo())
+	require.Nil(err)
+	assert.True(reconciled.ResetPending())
+
+	require.Nil(loaded.AcknowledgeReset())
+	require.Nil(loaded.AcknowledgeReset())
+	require.Nil(loaded.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	loaded, err = LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.False(loaded.ResetPending())
+}
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrTimestampRewrite        = errors.New("Reusing the signature needs the timestamp it was signed with")
+	ErrReusedSignBytesMismatch = errors.New("Reused signature does not cover the vote or proposal with the signed timestamp")
+)
+
+// ReusableSignBytes returns the LastSignBytes if the LastSignature can be reused
+// for candidate, ie. reuse isn't disabled, and both are canonical and the same
+// vote or proposal except for the timestamp. The signature covers the returned
+// bytes, not candidate: whatever is sent with it must carry the timestamp of
+// the returned bytes, or it won't verify.
+func (info *LastSignedInfo) ReusableSignBytes(candidate []byte) ([]byte, bool) {
+	if info.noReuse || info.LastSignBytes == nil || info.LastSignature.Empty() {
+		return nil, false
+	}
+	if err := info.checkCanonical(candidate); err != nil {
+		return nil, false
+	}
+	if !info.sameSignedData(info.LastSignBytes, candidate) {
+		return nil, false
+	}
+	return info.LastSignBytes, true
+}
+
+// VerifyAndReuse is Verify for candidate, the sign bytes about to be signed at
+// the height/round/step, in one call: if it's ahead, it returns no signature
+// and false, so candidate must be signed; at the same HRS, if the LastSignature
+// can be reused for candidate (see ReusableSignBytes), it returns it and true.
+// Otherwise, at the same HRS, it returns ErrConflictingData, unless reuse is
+// disabled and candidate is exactly the LastSignBytes, which is signed again.
+// Like Verify, it changes nothing, and the conflict isn't handled as set with
+// SetConflictStrategy.
+//
+// NOTE: the signature covers the LastSignBytes: whatever is sent with it must
+// carry their timestamp, not the one of candidate.
+func (info *LastSignedInfo) VerifyAndReuse(height int64, round int, step int8, candidate []byte) (crypto.Signature, bool, error) {
+	sameHRS, err := info.Verify(height, round, step)
+	if err != nil || !sameHRS {
+		return crypto.Signature{}, false, err
+	}
+	if _, ok := info.ReusableSignBytes(candidate); ok {
+		return info.LastSignature.Crypto(), true, nil
+	}
+	if info.matchesSignBytes(candidate) {
+		if info.noReuse {
+			return crypto.Signature{}, false, nil
+		}
+		// in hash-only mode, only the exact sign bytes are reused
+		return info.LastSignature.Crypto(), true, nil
+	}
+	info.reject(height, round, step, ErrConflictingData)
+	return crypto.Signature{}, false, ErrConflictingData
+}
+
+// SetDisableSignatureReuse makes SignVote and SignProposal never reuse the
+// LastSignature, for operators who'd rather keep the logic as simple as
+// possible. At the same height/round/step, the same sign bytes are signed
+// again (with the ReuseDisabled reason), and anything else, even if only the
+// timestamp differs, is a conflict, handled as set with SetConflictStrategy.
+// So re-signing after a crash with a new timestamp freezes the signer by default.
+// Reuse is enabled by default.
+func (info *LastSignedInfo) SetDisableSignatureReuse(disable bool) {
+	info.noReuse = disable
+}
+
+// SetStrictTimestamps makes SignVote and SignProposal return
+// ErrTimestampRewrite instead of reusing the LastSignature for a vote or
+// proposal with another timestamp, as that means setting its timestamp back
+// to the one signed, for callers that can't broadcast it with that timestamp.
+// The exact same sign bytes still reuse it.
+func (info *LastSignedInfo) SetStrictTimestamps(strict bool) {
+	info.strictTimestamps = strict
+}
+
+// checkReused returns ErrReusedSignBytesMismatch unless the vote or proposal
+// of req, once its timestamp is set back, is exactly signedBytes, which the
+// reused signature covers. Otherwise what's broadcast wouldn't verify.
+func checkReused(req signRequest, signedBytes []byte) error {
+	if req.encode == nil {
+		return nil
+	}
+	if !bytes.Equal(req.encode(), signedBytes) {
+		return ErrReusedSignBytesMismatch
+	}
+	return nil
+}
+
+// signedTimestamp returns the timestamp in the sign bytes of a vote or proposal.
+func signedTimestamp(signBytes []byte) (time.Time, error) {
+	decoded, ok := decodeSignBytes(signBytes)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Cannot decode sign bytes %s", signBytes)
+	}
+	var timestamp string
+	switch decoded := decoded.(type) {
+	case types.CanonicalJSONOnceVote:
+		timestamp = decoded.Vote.Timestamp
+	case types.CanonicalJSONOnceProposal:
+		timestamp = decoded.Proposal.Timestamp
+	}
+	return time.Parse(time.RFC3339Nano, timestamp)
+}
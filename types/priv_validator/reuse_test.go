@@ -0,0 +1,223 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestSignVoteReuseRestoresTimestamp(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, pub := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Minute)
+	later.Signature = crypto.Signature{}
+	reason, err := info.SignVoteWithReason(signer, "mychainid", &later)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+
+	// the reused signature is valid for the vote as returned
+	assert.Equal(types.CanonicalTime(vote.Timestamp), types.CanonicalTime(later.Timestamp))
+	assert.Equal([]byte(info.LastSignBytes), types.SignBytes("mychainid", &later))
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", &later), later.Signature))
+
+	proposal := &types.Proposal{Height: 11, POLRound: -1, Timestamp: time.Now().UTC()}
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+	laterProposal := *proposal
+	laterProposal.Timestamp = proposal.Timestamp.Add(time.Minute)
+	laterProposal.Signature = crypto.Signature{}
+	require.Nil(info.SignProposal(signer, "mychainid", &laterProposal))
+	assert.Equal([]byte(info.LastSignBytes), types.SignBytes("mychainid", &laterProposal))
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", &laterProposal), laterProposal.Signature))
+}
+
+func TestStrictTimestamps(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetStrictTimestamps(true)
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Minute)
+	later.Signature = crypto.Signature{}
+	assert.Equal(ErrTimestampRewrite, info.SignVote(signer, "mychainid", &later))
+	assert.True(later.Signature.Empty())
+	assert.Equal(vote.Timestamp.Add(time.Minute), later.Timestamp)
+
+	same := *vote
+	same.Signature = crypto.Signature{}
+	require.Nil(info.SignVote(signer, "mychainid", &same))
+	assert.Equal(vote.Signature, same.Signature)
+}
+
+func TestCheckReused(t *testing.T) {
+	assert := assert.New(t)
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	req := signRequest{encode: func() []byte { return types.SignBytes("mychainid", vote) }}
+	signed := types.SignBytes("mychainid", vote)
+	assert.Nil(checkReused(req, signed))
+	vote.Timestamp = vote.Timestamp.Add(time.Minute)
+	assert.Equal(ErrReusedSignBytesMismatch, checkReused(req, signed))
+	assert.Nil(checkReused(signRequest{}, signed))
+}
+
+func TestReusableSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+
+	// nothing signed yet
+	_, ok := info.ReusableSignBytes(types.SignBytes("mychainid", vote))
+	assert.False(ok)
+
+	assert.Nil(info.SignVote(signer, "mychainid", vote))
+	lastSignBytes := []byte(info.LastSignBytes)
+
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Minute)
+	for _, candidate := range []*types.Vote{vote, &later} {
+		reusable, ok := info.ReusableSignBytes(types.SignBytes("mychainid", candidate))
+		assert.True(ok)
+		assert.Equal(lastSignBytes, reusable)
+	}
+
+	other := *vote
+	other.BlockID = blockID2
+	var indented bytes.Buffer
+	assert.Nil(json.Indent(&indented, types.SignBytes("mychainid", vote), "", " "))
+	for _, candidate := range [][]byte{
+		types.SignBytes("mychainid", &other),
+		types.SignBytes("otherchainid", vote),
+		types.SignBytes("mychainid", &types.Proposal{Height: 10, Round: 1, POLRound: -1}),
+		indented.Bytes(),
+	} {
+		_, ok := info.ReusableSignBytes(candidate)
+		assert.False(ok, "%s", candidate)
+	}
+}
+
+func TestSignedTimestamp(t *testing.T) {
+	assert := assert.New(t)
+
+	stamp := time.Date(2017, 12, 25, 3, 0, 1, 234000000, time.UTC)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	vote.Timestamp = stamp
+	proposal := &types.Proposal{Height: 10, Round: 1, POLRound: -1, Timestamp: stamp}
+	for _, signBytes := range [][]byte{types.SignBytes("mychainid", vote), types.SignBytes("mychainid", proposal)} {
+		timestamp, err := signedTimestamp(signBytes)
+		assert.Nil(err)
+		assert.True(stamp.Equal(timestamp), "%v", timestamp)
+	}
+
+	_, err := signedTimestamp([]byte("signbytes"))
+	assert.Error(err)
+}
+
+func TestDisableSignatureReuse(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetDisableSignatureReuse(true)
+	info.SetConflictStrategy(ConflictError)
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	seq := info.Sequence()
+
+	// the same vote is signed again
+	again := *vote
+	reason, err := info.SignVoteWithReason(signer, "mychainid", &again)
+	require.Nil(err)
+	assert.Equal(ReuseDisabled, reason)
+	assert.Equal(seq+1, info.Sequence())
+	assert.Equal(vote.Signature, again.Signature) // ed25519 is deterministic
+
+	// a new timestamp is a conflict
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	_, ok := info.ReusableSignBytes(types.SignBytes("mychainid", &later))
+	assert.False(ok)
+	reason, err = info.SignVoteWithReason(signer, "mychainid", &later)
+	assert.Equal(ErrConflictingData, err)
+	assert.Equal(ContentDiffers, reason)
+
+	// reuse is enabled by default
+	info.SetDisableSignatureReuse(false)
+	reason, err = info.SignVoteWithReason(signer, "mychainid", &later)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+}
+
+func TestVerifyAndReuse(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	var rejected []error
+	info.SetOnReject(func(event RejectEvent) { rejected = append(rejected, event.Err) })
+	signer, pub := newTestSigner()
+
+	// nothing signed at the HRS yet
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	sig, reusable, err := info.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", vote))
+	require.Nil(err)
+	assert.False(reusable)
+	assert.True(sig.Empty())
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	// only the timestamp differs: the signature covers the LastSignBytes
+	later := *vote
+	later.Timestamp = vote.Timestamp.Add(time.Minute)
+	sig, reusable, err = info.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", &later))
+	require.Nil(err)
+	assert.True(reusable)
+	assert.Equal(vote.Signature, sig)
+	assert.True(pub.VerifyBytes(info.LastSignBytes, sig))
+
+	// conflicts and regressions
+	other := newVote(10, 1, types.VoteTypePrevote, blockID2)
+	_, reusable, err = info.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", other))
+	assert.Equal(ErrConflictingData, err)
+	assert.False(reusable)
+	_, _, err = info.VerifyAndReuse(10, 0, stepPrevote, types.SignBytes("mychainid", vote))
+	assert.Equal(ErrRoundRegression, err)
+	assert.Equal([]error{ErrConflictingData, ErrRoundRegression}, rejected)
+	assert.False(info.Frozen())
+
+	// with reuse disabled, the exact same sign bytes are signed again
+	info.SetDisableSignatureReuse(true)
+	sig, reusable, err = info.VerifyAndReuse(10, 1, stepPrevote, info.LastSignBytes)
+	require.Nil(err)
+	assert.False(reusable)
+	assert.True(sig.Empty())
+	_, _, err = info.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", &later))
+	assert.Equal(ErrConflictingData, err)
+
+	// in hash-only mode, only the exact sign bytes are reused
+	hashOnly := NewLastSignedInfo()
+	hashOnly.SetHashOnly(true)
+	require.Nil(hashOnly.SignVote(signer, "mychainid", vote))
+	sig, reusable, err = hashOnly.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", vote))
+	require.Nil(err)
+	assert.True(reusable)
+	assert.Equal(vote.Signature, sig)
+	_, _, err = hashOnly.VerifyAndReuse(10, 1, stepPrevote, types.SignBytes("mychainid", &later))
+	assert.Equal(ErrConflictingData, err)
+}
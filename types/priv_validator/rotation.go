@@ -0,0 +1,126 @@
+package types
+
+import (
+	"errors"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+var (
+	ErrKeyMismatch = errors.New("Signature is not by the key the LastSignedInfo is bound to")
+)
+
+// KeyRotation records the last rotation of the validator key, see RotateKey.
+type KeyRotation struct {
+	OldPubKey crypto.PubKey `json:"old_pub_key"` // empty if no key was bound
+	NewPubKey crypto.PubKey `json:"new_pub_key"`
+
+	// The height/round/step of the old key when it was rotated.
+	Height int64 `json:"height"`
+	Round  int   `json:"round"`
+	Step   int8  `json:"step"`
+}
+
+// RotateKey switches the LastSignedInfo to newPub, the new validator key.
+// The height/round/step signed with the old key doesn't apply to the new one,
+// but the old key may still be live, so it goes in two steps:
+//
+//   - the rotation is recorded with the old key and its height/round/step,
+//     and the state bound to newPub, and persisted, flushing a SignerState
+//     that implements Flusher;
+//   - only once that's durable, the height/round/step is reset, with the
+//     FloorHeight raised to the height of the rotation.
+//
+// From then on, Set (and so SignVote and SignProposal) returns ErrKeyMismatch
+// for signatures that aren't by newPub, and nothing below the rotation height
+// is signed (ErrBelowFloor), so the new key doesn't sign what the old one may have.
+//
+// If the first step fails, nothing changes. If the second one does, the old
+// height/round/step is kept with the new key, which is safe, and the error
+// returned. Rotating to the key already bound is a no-op, and rotating with a
+// pending signature returns ErrPendingSign. Reset keeps the key, and the floor.
+func (info *LastSignedInfo) RotateKey(newPub crypto.PubKey) error {
+	if newPub.Empty() {
+		return errors.New("Cannot rotate to an empty key")
+	}
+	oldPub := info.boundKey()
+	if !oldPub.Empty() && oldPub.Equals(newPub) {
+		return nil
+	}
+	if info.PendingSign != nil {
+		return ErrPendingSign
+	}
+
+	previous := info.KeyRotation
+	info.KeyRotation = &KeyRotation{
+		OldPubKey: oldPub,
+		NewPubKey: newPub,
+		Height:    info.LastHeight,
+		Round:     info.LastRound,
+		Step:      info.LastStep,
+	}
+	if err := info.persistDurable(); err != nil {
+		info.KeyRotation = previous
+		return err
+	}
+
+	rotated := info.Snapshot()
+	info.LastHeight = 0
+	info.LastRound = 0
+	info.LastStep = stepNone
+	info.LastSignature = Signature{}
+	info.LastSignBytes = nil
+	info.LastSignBytesHash = nil
+	info.LastExtension = nil
+	info.LastSignedByVersion = ""
+	info.Unsigned = false
+	if info.KeyRotation.Height > info.FloorHeight {
+		info.FloorHeight = info.KeyRotation.Height
+	}
+	if err := info.persist(); err != nil {
+		info.restore(rotated)
+		return err
+	}
+	info.history = signHistory{size: info.history.size, window: info.history.window}
+	return nil
+}
+
+// persistDurable persists, and flushes a SignerState that implements Flusher.
+func (info *LastSignedInfo) persistDurable() error {
+	if err := info.persist(); err != nil {
+		return err
+	}
+	if flusher, ok := info.store.(Flusher); ok {
+		return flusher.FlushNow()
+	}
+	return nil
+}
+
+// boundKey returns the key the LastSignedInfo is bound to by RotateKey, if any.
+func (info *LastSignedInfo) boundKey() crypto.PubKey {
+	if info.KeyRotation == nil {
+		return crypto.PubKey{}
+	}
+	return info.KeyRotation.NewPubKey
+}
+
+// checkBoundKey returns ErrKeyMismatch if the LastSignedInfo is bound to a key
+// and sig isn't by it.
+func (info *LastSignedInfo) checkBoundKey(signBytes []byte, sig crypto.Signature) error {
+	pubKey := info.boundKey()
+	if pubKey.Empty() {
+		return nil
+	}
+	if sig.Empty() || !pubKey.VerifyBytes(signBytes, sig) {
+		return ErrKeyMismatch
+	}
+	return nil
+}
+
+func copyKeyRotation(rotation *KeyRotation) *KeyRotation {
+	if rotation == nil {
+		return nil
+	}
+	cpy := *rotation
+	return &cpy
+}
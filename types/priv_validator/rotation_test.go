@@ -0,0 +1,106 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestRotateKey(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	oldSigner, oldPub := newTestSigner()
+	newSigner, newPub := newTestSigner()
+	require.Nil(info.SignVote(oldSigner, "mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID1)))
+
+	require.Nil(info.RotateKey(newPub))
+	require.NotNil(info.KeyRotation)
+	assert.True(info.KeyRotation.OldPubKey.Empty())
+	assert.Equal(newPub, info.KeyRotation.NewPubKey)
+	assert.Equal(KeyRotation{NewPubKey: newPub, Height: 10, Round: 1, Step: stepPrecommit}, *info.KeyRotation)
+	assert.EqualValues(0, info.LastHeight)
+	assert.Equal(stepNone, info.LastStep)
+	assert.True(info.LastSignature.Empty())
+	assert.EqualValues(10, info.FloorHeight)
+
+	// nothing below the rotation height, and nothing by the old key
+	assert.Equal(ErrBelowFloor, info.SignVote(newSigner, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrKeyMismatch, info.SignVote(oldSigner, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Nil(info.PendingSign)
+	assert.Nil(info.SignVote(newSigner, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// kept on restart
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(info.KeyRotation, loaded.KeyRotation)
+	assert.EqualValues(10, loaded.LastHeight)
+	_, err = LoadLastSignedInfoStrict(tempFilePath, oldPub)
+	assert.Equal(ErrKeyMismatch, err)
+	_, err = LoadLastSignedInfoStrict(tempFilePath, newPub)
+	assert.Nil(err)
+
+	// kept on Reset
+	require.Nil(loaded.Reset())
+	assert.Equal(info.KeyRotation, loaded.KeyRotation)
+	assert.Equal(ErrKeyMismatch, loaded.SignVote(oldSigner, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)))
+
+	// rotating to the same key is a no-op, and again records the old one
+	require.Nil(info.RotateKey(newPub))
+	assert.EqualValues(10, info.LastHeight)
+	require.Nil(info.RotateKey(oldPub))
+	assert.Equal(newPub, info.KeyRotation.OldPubKey)
+	assert.Equal(oldPub, info.KeyRotation.NewPubKey)
+}
+
+func TestRotateKeyNotDurable(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	state := &asyncState{flushErr: errors.New("disk full")}
+	info := NewLastSignedInfo()
+	info.SetSignerState(state)
+	signer, _ := newTestSigner()
+	_, newPub := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// the high-water mark is kept, and the old key still signs
+	assert.Error(info.RotateKey(newPub))
+	assert.Nil(info.KeyRotation)
+	assert.EqualValues(10, info.LastHeight)
+	assert.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+	state.flushErr = nil
+	require.Nil(info.RotateKey(newPub))
+	assert.EqualValues(11, info.FloorHeight)
+	assert.Equal(info.KeyRotation, state.flushed.KeyRotation)
+}
+
+func TestReconcileRotatedKey(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	_, newPub := newTestSigner()
+	_, otherPub := newTestSigner()
+	rotated, stale := NewLastSignedInfo(), NewLastSignedInfo()
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(rotated.SignVote(signer, "mychainid", vote))
+	require.Nil(stale.SignVote(signer, "mychainid", vote))
+	require.Nil(rotated.RotateKey(newPub))
+
+	// the old high-water mark is kept, bound to the new key
+	reconciled, err := ReconcileSources(rotated, stale)
+	require.Nil(err)
+	assert.Equal(rotated.KeyRotation, reconciled.KeyRotation)
+	assert.EqualValues(10, reconciled.LastHeight)
+	assert.EqualValues(10, reconciled.FloorHeight)
+
+	require.Nil(stale.RotateKey(otherPub))
+	_, err = ReconcileSources(rotated, stale)
+	assert.Equal(ErrKeyMismatch, err)
+}
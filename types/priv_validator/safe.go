@@ -0,0 +1,61 @@
+package types
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// PanicError is returned by the Safe methods for a panic they recovered.
+type PanicError struct {
+	Value interface{} // what was passed to panic
+	Stack []byte      // where it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("LastSignedInfo panicked: %v", e.Value)
+}
+
+// The Safe methods are like the originals, but recover their panics, eg. on a
+// broken invariant or ConflictPanic, and return them as a *PanicError, for
+// embedders that can't let a goroutine crash. As the state can't be trusted
+// after a panic, signing is frozen (see Frozen) until Unfreeze or a restart.
+// The originals panic, for callers that prefer to fail fast.
+
+// SafeVerify is Verify, recovering panics.
+func (info *LastSignedInfo) SafeVerify(height int64, round int, step int8) (sameHRS bool, err error) {
+	defer info.recoverPanic(&err)
+	return info.Verify(height, round, step)
+}
+
+// SafeSet is Set, recovering panics.
+func (info *LastSignedInfo) SafeSet(height int64, round int, step int8, signBytes []byte, sig crypto.Signature) (err error) {
+	defer info.recoverPanic(&err)
+	return info.Set(height, round, step, signBytes, sig)
+}
+
+// SafeSignVote is SignVote, recovering panics.
+func (info *LastSignedInfo) SafeSignVote(signer types.Signer, chainID string, vote *types.Vote) (err error) {
+	defer info.recoverPanic(&err)
+	return info.SignVote(signer, chainID, vote)
+}
+
+// SafeSignProposal is SignProposal, recovering panics.
+func (info *LastSignedInfo) SafeSignProposal(signer types.Signer, chainID string, proposal *types.Proposal) (err error) {
+	defer info.recoverPanic(&err)
+	return info.SignProposal(signer, chainID, proposal)
+}
+
+// recoverPanic must be deferred: it sets err to a *PanicError if there's a
+// panic to recover, and freezes signing.
+func (info *LastSignedInfo) recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	info.getLogger().Error("Freezing signing after a panic", "panic", r)
+	info.freeze(fmt.Sprintf("Panic: %v", r))
+	*err = &PanicError{Value: r, Stack: debug.Stack()}
+}
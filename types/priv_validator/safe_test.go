@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestSafeVerify(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	// breaks the signature invariant
+	info.LastSignature = Signature{}
+
+	assert.Panics(func() { info.Verify(10, 0, stepPrevote) })
+	_, err := info.SafeVerify(10, 0, stepPrevote)
+	require.IsType(&PanicError{}, err)
+	assert.Equal("info: LastSignature is nil but LastSignBytes is not!", err.(*PanicError).Value)
+	assert.Contains(err.Error(), "LastSignature is nil")
+	assert.NotEmpty(err.(*PanicError).Stack)
+
+	// no more signing until unfrozen
+	assert.True(info.Frozen())
+	assert.Equal(ErrFrozen, info.SafeSignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+	// no panic, no freeze
+	info.Unfreeze()
+	sameHRS, err := info.SafeVerify(11, 0, stepPrevote)
+	assert.Nil(err)
+	assert.False(sameHRS)
+	assert.False(info.Frozen())
+}
+
+func TestSafeSignVote(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictPanic)
+	signer, _ := newTestSigner()
+	require.Nil(info.SafeSignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	err := info.SafeSignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2))
+	require.IsType(&PanicError{}, err)
+	assert.Equal("Conflicting data at 10/0/2", err.(*PanicError).Value)
+	assert.True(info.Frozen())
+}
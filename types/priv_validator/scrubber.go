@@ -0,0 +1,138 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+var (
+	ErrScrubMismatch = errors.New("Persisted LastSignedInfo differs from memory")
+)
+
+// SetLocker sets the lock that callers hold to serialize access to the
+// LastSignedInfo, eg. the mutex of PrivValidatorFS, for StartScrubber to hold
+// while it reads the memory.
+func (info *LastSignedInfo) SetLocker(locker sync.Locker) {
+	info.locker = locker
+}
+
+// StartScrubber checks every interval, until ctx is done, that the persisted
+// state (the filePath, or the SignerState) is the one in memory, and that its
+// LastSignature verifies against the LastSignBytes under pub, to catch silent
+// corruption early. Anomalies are logged and reported to the OnReject callback
+// at the latest height/round/step, with ErrScrubMismatch, ErrBadSignature,
+// or the error reading the state.
+//
+// It never gets in the way of signing: it only reads, and only holds the
+// Locker to copy the memory, not while reading the persisted state. If the
+// memory changed meanwhile, that check is skipped, as the state read may be
+// older or newer. A SignerState that saves asynchronously may lag behind,
+// and be reported.
+//
+// It never changes the persisted state either: unlike LoadLastSignedInfo, a
+// temp file left by an interrupted write is neither completed nor deleted, as
+// that would race with Save. So a SignerState must implement SignerStateReader.
+// The interval is measured by the Clock.
+//
+// It returns an error if there's no Locker (see SetLocker), as reading the
+// memory while signing would be a race, if nothing is persisted, or if the
+// SignerState doesn't implement SignerStateReader.
+// Otherwise done is closed once it stopped.
+func (info *LastSignedInfo) StartScrubber(ctx context.Context, interval time.Duration, pub crypto.PubKey) (done <-chan struct{}, err error) {
+	if info.locker == nil {
+		return nil, errors.New("Cannot scrub without a Locker, see SetLocker")
+	}
+	info.locker.Lock()
+	store, filePath, clock := info.store, info.filePath, info.getClock()
+	info.locker.Unlock()
+	if store == nil && filePath == "" {
+		return nil, errors.New("Cannot scrub a LastSignedInfo that isn't persisted")
+	}
+	if _, ok := store.(SignerStateReader); store != nil && !ok {
+		return nil, errors.New("Cannot scrub a SignerState that isn't a SignerStateReader")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clock.After(interval):
+				if err := info.scrub(pub); err != nil {
+					info.reportScrub(err)
+				}
+			}
+		}
+	}()
+	return stopped, nil
+}
+
+// scrub checks the persisted state once.
+func (info *LastSignedInfo) scrub(pub crypto.PubKey) error {
+	memory, store, filePath, err := info.lockedEncode()
+	if err != nil {
+		return err
+	}
+	var stored *LastSignedInfo
+	if reader, ok := store.(SignerStateReader); ok {
+		stored, err = reader.Read()
+	} else if store != nil {
+		err = errors.New("Cannot scrub a SignerState that isn't a SignerStateReader")
+	} else {
+		stored, err = readLastSignedInfo(filePath, JSONCodec{})
+	}
+	if err != nil {
+		return err
+	}
+	if again, _, _, err := info.lockedEncode(); err != nil || !bytes.Equal(memory, again) {
+		// changed while reading
+		return nil
+	}
+
+	storedBytes, err := encodeBytes(JSONCodec{}, stored)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(storedBytes, memory) {
+		return ErrScrubMismatch
+	}
+	if !pub.Empty() && !stored.LastSignature.Empty() && stored.LastSignBytes != nil &&
+		!pub.VerifyBytes(stored.LastSignBytes, stored.LastSignature.Crypto()) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// lockedEncode encodes the memory under the Locker,
+// and returns where it's persisted.
+func (info *LastSignedInfo) lockedEncode() ([]byte, SignerState, string, error) {
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	memory, err := encodeBytes(JSONCodec{}, info)
+	return memory, info.store, info.filePath, err
+}
+
+func (info *LastSignedInfo) reportScrub(err error) {
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	info.getLogger().Error("Scrubbing the persisted LastSignedInfo failed", "err", err)
+	info.reject(info.LastHeight, info.LastRound, info.LastStep, err)
+}
+
+// readLastSignedInfo reads the LastSignedInfo in filePath, unlike
+// LoadLastSignedInfo without recovering a temp file or warning.
+func readLastSignedInfo(filePath string, codec StateCodec) (*LastSignedInfo, error) {
+	infoBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBytes(codec, infoBytes)
+}
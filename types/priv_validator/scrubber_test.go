@@ -0,0 +1,126 @@
+package types
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestScrubber(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(tempFilePath))
+	signer, pub := newTestSigner()
+	_, otherPub := newTestSigner()
+
+	_, err := info.StartScrubber(context.Background(), time.Millisecond, pub)
+	assert.Error(err, "no locker")
+
+	var mtx sync.Mutex
+	info.SetLocker(&mtx)
+	events := make(chan RejectEvent, 100)
+	info.SetOnReject(func(event RejectEvent) { events <- event })
+	mtx.Lock()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	mtx.Unlock()
+
+	// all good
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := info.StartScrubber(ctx, time.Millisecond, pub)
+	require.Nil(err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(events)
+
+	// the file changed behind our back
+	mtx.Lock()
+	changed := NewLastSignedInfo()
+	require.Nil(changed.Restore(info.Snapshot()))
+	changed.LastHeight = 9
+	require.Nil(changed.SaveAs(tempFilePath))
+	mtx.Unlock()
+	select {
+	case event := <-events:
+		assert.Equal(ErrScrubMismatch, event.Err)
+		assert.EqualValues(10, event.LastHeight)
+	case <-time.After(time.Second):
+		t.Fatal("No anomaly reported")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Scrubber didn't stop")
+	}
+
+	// the signature isn't by the key
+	mtx.Lock()
+	require.Nil(info.Save())
+	mtx.Unlock()
+	assert.Nil(info.scrub(pub))
+	assert.Equal(ErrBadSignature, info.scrub(otherPub))
+}
+
+func TestScrubberIsReadOnly(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(tempFilePath))
+	var mtx sync.Mutex
+	info.SetLocker(&mtx)
+	clock := NewManualClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	info.SetClock(clock)
+	events := make(chan RejectEvent, 100)
+	info.SetOnReject(func(event RejectEvent) { events <- event })
+	signer, pub := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// a write interrupted by a crash is left for the next load
+	pending := NewLastSignedInfo()
+	pending.LastHeight, pending.LastStep = 11, stepPrevote
+	pendingBytes, err := encodeBytes(JSONCodec{}, pending)
+	require.Nil(err)
+	require.Nil(ioutil.WriteFile(tempFilePath+".tmp", pendingBytes, 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = info.StartScrubber(ctx, time.Minute, pub)
+	require.Nil(err)
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		clock.Advance(time.Minute)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(events)
+	tmpBytes, err := ioutil.ReadFile(tempFilePath + ".tmp")
+	require.Nil(err)
+	assert.Equal(pendingBytes, tmpBytes)
+	assert.EqualValues(10, info.LastHeight)
+
+	// SignInfoFile.Read doesn't recover either, while Load does
+	read, err := NewSignInfoFile(tempFilePath).Read()
+	require.Nil(err)
+	assert.EqualValues(10, read.LastHeight)
+	loaded, err := NewSignInfoFile(tempFilePath).Load()
+	require.Nil(err)
+	assert.EqualValues(11, loaded.LastHeight)
+}
+
+func TestScrubberNeedsSignerStateReader(t *testing.T) {
+	info := NewLastSignedInfo()
+	info.SetLocker(&sync.Mutex{})
+	info.SetSignerState(&asyncState{})
+	_, pub := newTestSigner()
+	_, err := info.StartScrubber(context.Background(), time.Minute, pub)
+	assert.Error(t, err)
+}
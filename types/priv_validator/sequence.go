@@ -0,0 +1,27 @@
+package types
+
+import "fmt"
+
+// Sequence returns the number of signatures recorded with Set, persisted with
+// the rest of the LastSignedInfo. Each new signature gets the next number,
+// so a consumer (eg. an HA peer or an audit log) can tell gaps or reordering
+// apart, independently of the wall clock.
+// It never goes backwards, not even on Reset or Restore. A reused signature
+// isn't recorded again, so it keeps its number.
+func (info *LastSignedInfo) Sequence() uint64 {
+	return info.Seq
+}
+
+// DetectRollback returns an error if the Sequence is below expectedMinSeq,
+// the highest one seen elsewhere, eg. in a sidecar file or by an HA peer.
+// The Seq never goes backwards, so that means the state was rolled back,
+// eg. by restoring an old snapshot, and may have lost the high-water mark of
+// signatures made since: signing from it risks signing them again.
+// Run it after loading, before signing.
+func (info *LastSignedInfo) DetectRollback(expectedMinSeq uint64) error {
+	if info.Seq < expectedMinSeq {
+		return fmt.Errorf("State %v was rolled back: its sequence %v is below %v, "+
+			"%v signatures may be missing", info, info.Seq, expectedMinSeq, expectedMinSeq-info.Seq)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestSequence(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+	assert.EqualValues(0, info.Sequence())
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	assert.EqualValues(1, info.Sequence())
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	assert.EqualValues(2, info.Sequence())
+
+	// refused and reused signatures aren't counted
+	assert.Error(info.SignVote(signer, "mychainid", vote))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	assert.EqualValues(2, info.Sequence())
+
+	// it survives a restart
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.EqualValues(2, loaded.Sequence())
+	require.Nil(loaded.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.EqualValues(3, loaded.Sequence())
+
+	// and never goes backwards
+	snapshot := loaded.Snapshot()
+	snapshot.Seq = 1
+	require.Nil(loaded.Restore(snapshot))
+	assert.EqualValues(3, loaded.Sequence())
+	require.Nil(loaded.Reset())
+	assert.EqualValues(3, loaded.Sequence())
+	loaded, err = LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.EqualValues(3, loaded.Sequence())
+}
+
+func TestDetectRollback(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+	assert.Nil(info.DetectRollback(0))
+
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	snapshot, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	expectedMinSeq := info.Sequence()
+
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.Nil(loaded.DetectRollback(expectedMinSeq))
+	assert.Nil(loaded.DetectRollback(expectedMinSeq - 1))
+
+	// the file is restored from the snapshot
+	require.Nil(snapshot.Save())
+	loaded, err = LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.Error(loaded.DetectRollback(expectedMinSeq))
+}
@@ -0,0 +1,147 @@
+// +build shadow
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// ShadowSignInfo is a differential testing harness for LastSignedInfo.
+// It passes every SignVote and SignProposal to the wrapped LastSignedInfo,
+// and also to a naive reference that only knows signing must move forward,
+// and may only repeat itself when nothing but the timestamp changed.
+// If they disagree on whether signing was safe, the divergence is recorded
+// and, by default, it panics.
+//
+// It's only built with the shadow tag (make test_shadow), for tests.
+type ShadowSignInfo struct {
+	*LastSignedInfo
+
+	ref          shadowReference
+	divergences  []string
+	onDivergence func(string)
+}
+
+// NewShadowSignInfo wraps info, whose state the reference starts from.
+func NewShadowSignInfo(info *LastSignedInfo) *ShadowSignInfo {
+	return &ShadowSignInfo{
+		LastSignedInfo: info,
+		ref: shadowReference{
+			height:    info.LastHeight,
+			round:     info.LastRound,
+			step:      info.LastStep,
+			signBytes: info.LastSignBytes,
+		},
+		onDivergence: func(divergence string) { panic(divergence) },
+	}
+}
+
+// SetOnDivergence replaces the panic on divergence, eg. with t.Error.
+func (ssi *ShadowSignInfo) SetOnDivergence(onDivergence func(string)) {
+	ssi.onDivergence = onDivergence
+}
+
+// Divergences returns the divergences so far.
+func (ssi *ShadowSignInfo) Divergences() []string {
+	return ssi.divergences
+}
+
+// SignVote signs with the wrapped LastSignedInfo and checks the reference agrees.
+func (ssi *ShadowSignInfo) SignVote(signer types.Signer, chainID string, vote *types.Vote) error {
+	step := stepPrevote
+	if vote.Type == types.VoteTypePrecommit {
+		step = stepPrecommit
+	}
+	signBytes := ssi.LastSignedInfo.canonicalEncoder().VoteBytes(chainID, vote)
+	err := ssi.LastSignedInfo.SignVote(signer, chainID, vote)
+	ssi.compare(vote.Height, vote.Round, step, signBytes, err)
+	return err
+}
+
+// SignProposal signs with the wrapped LastSignedInfo and checks the reference agrees.
+func (ssi *ShadowSignInfo) SignProposal(signer types.Signer, chainID string, proposal *types.Proposal) error {
+	signBytes := ssi.LastSignedInfo.canonicalEncoder().ProposalBytes(chainID, proposal)
+	err := ssi.LastSignedInfo.SignProposal(signer, chainID, proposal)
+	ssi.compare(proposal.Height, proposal.Round, stepPropose, signBytes, err)
+	return err
+}
+
+// compare the outcome err of signing signBytes with what the reference allows.
+// Only the errors that claim signing was unsafe are compared: the others, eg.
+// from the policy or the floor, are refusals the reference knows nothing about.
+func (ssi *ShadowSignInfo) compare(height int64, round int, step int8, signBytes []byte, err error) {
+	allowed := ssi.ref.allows(height, round, step, signBytes)
+	switch {
+	case err == nil && !allowed:
+		ssi.diverge(fmt.Sprintf("Signed %v/%v/%v, which the reference refuses after %v/%v/%v",
+			height, round, step, ssi.ref.height, ssi.ref.round, ssi.ref.step))
+	case unsafeSignErr(err) && allowed:
+		ssi.diverge(fmt.Sprintf("Refused %v/%v/%v with %q, which the reference allows after %v/%v/%v",
+			height, round, step, err, ssi.ref.height, ssi.ref.round, ssi.ref.step))
+	}
+	if err == nil && allowed {
+		ssi.ref.record(height, round, step, signBytes)
+	}
+}
+
+func (ssi *ShadowSignInfo) diverge(divergence string) {
+	ssi.divergences = append(ssi.divergences, divergence)
+	ssi.onDivergence(divergence)
+}
+
+func unsafeSignErr(err error) bool {
+	switch err {
+	case ErrHeightRegression, ErrRoundRegression, ErrStepRegression, ErrConflictingData:
+		return true
+	default:
+		return false
+	}
+}
+
+// shadowReference is the naive reference, written independently of
+// LastSignedInfo.Verify and the comparisons of compare.go.
+type shadowReference struct {
+	height    int64
+	round     int
+	step      int8
+	signBytes []byte
+}
+
+func (ref *shadowReference) allows(height int64, round int, step int8, signBytes []byte) bool {
+	if height != ref.height {
+		return height > ref.height
+	}
+	if round != ref.round {
+		return round > ref.round
+	}
+	if step != ref.step {
+		return step > ref.step
+	}
+	return ref.signBytes == nil || reflect.DeepEqual(withoutTimestamp(ref.signBytes), withoutTimestamp(signBytes))
+}
+
+func (ref *shadowReference) record(height int64, round int, step int8, signBytes []byte) {
+	// a reused signature keeps the bytes it was made for
+	if height != ref.height || round != ref.round || step != ref.step || ref.signBytes == nil {
+		ref.height, ref.round, ref.step, ref.signBytes = height, round, step, signBytes
+	}
+}
+
+// withoutTimestamp decodes the sign bytes of a vote or proposal, and drops
+// their timestamp.
+func withoutTimestamp(signBytes []byte) map[string]interface{} {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(signBytes, &decoded); err != nil {
+		return nil
+	}
+	for _, key := range []string{"vote", "proposal"} {
+		if inner, ok := decoded[key].(map[string]interface{}); ok {
+			delete(inner, "timestamp")
+		}
+	}
+	return decoded
+}
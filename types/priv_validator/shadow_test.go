@@ -0,0 +1,53 @@
+// +build shadow
+
+package types
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestShadowSignInfoRandomWalk(t *testing.T) {
+	signer := NewDeterministicTestSigner("shadow")
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetHistorySize(5)
+	shadow := NewShadowSignInfo(info)
+	shadow.SetOnDivergence(func(divergence string) { t.Error(divergence) })
+
+	r := rand.New(rand.NewSource(1))
+	blockIDs := []types.BlockID{blockID1, blockID2}
+	for i := 0; i < 2000; i++ {
+		height, round := int64(1+r.Intn(5)+i/200), r.Intn(3)
+		if r.Intn(4) == 0 {
+			proposal := &types.Proposal{Height: height, Round: round, POLRound: -1,
+				BlockPartsHeader: types.PartSetHeader{Total: 1, Hash: blockIDs[r.Intn(2)].Hash},
+				Timestamp:        time.Unix(int64(r.Intn(3)), 0)}
+			shadow.SignProposal(signer, "mychainid", proposal)
+			continue
+		}
+		voteType := []byte{types.VoteTypePrevote, types.VoteTypePrecommit}[r.Intn(2)]
+		vote := newVote(height, round, voteType, blockIDs[r.Intn(2)])
+		vote.Timestamp = time.Unix(int64(r.Intn(3)), 0)
+		shadow.SignVote(signer, "mychainid", vote)
+	}
+	assert.Empty(t, shadow.Divergences())
+}
+
+func TestShadowSignInfoDetectsDivergence(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, _ := newTestSigner()
+	info := NewLastSignedInfo()
+	shadow := NewShadowSignInfo(info)
+	assert.Nil(shadow.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// a bug that forgets the high-water mark
+	info.LastHeight = 0
+	assert.Panics(func() { shadow.SignVote(signer, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)) })
+	assert.Len(shadow.Divergences(), 1)
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"context"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ContextSigner is a Signer that can be given a deadline, eg. a remote signer or HSM.
+// SignContext must not return before the signing is over, so that, if it
+// gives up, no signature is made afterwards.
+type ContextSigner interface {
+	SignContext(ctx context.Context, msg []byte) (crypto.Signature, error)
+}
+
+// SignVoteContext is like SignVote, but gives up when ctx is done, returning ctx.Err().
+// Only a ContextSigner can be given the deadline: a plain Signer can't be
+// stopped, so once it's called, it's waited for, however long it takes.
+// Otherwise, its signature could still be made after giving up, and another
+// vote signed at the same height/round/step in the meantime.
+//
+// If the signer gives up, it's handled like any signing error: in write-ahead
+// mode, the PendingSign stays, as it can't be known whether it signed, and the
+// height/round/step can only be signed again once Recover decided; otherwise
+// nothing is recorded.
+func (info *LastSignedInfo) SignVoteContext(ctx context.Context, signer types.Signer, chainID string, vote *types.Vote) error {
+	_, err := info.signVote(ctx, signer, chainID, vote)
+	return err
+}
+
+func signWithContext(ctx context.Context, signer types.Signer, msg []byte) (crypto.Signature, error) {
+	if err := ctx.Err(); err != nil {
+		return crypto.Signature{}, err
+	}
+	if ctxSigner, ok := signer.(ContextSigner); ok {
+		return ctxSigner.SignContext(ctx, msg)
+	}
+	return signer.Sign(msg)
+}
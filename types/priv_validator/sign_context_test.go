@@ -0,0 +1,117 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// slowSigner takes delay to sign.
+type slowSigner struct {
+	signer types.Signer
+	delay  time.Duration
+}
+
+func (ss slowSigner) Sign(msg []byte) (crypto.Signature, error) {
+	time.Sleep(ss.delay)
+	return ss.signer.Sign(msg)
+}
+
+// slowContextSigner takes delay to sign, unless ctx is done first.
+type slowContextSigner struct {
+	slowSigner
+}
+
+func (ss slowContextSigner) SignContext(ctx context.Context, msg []byte) (crypto.Signature, error) {
+	select {
+	case <-time.After(ss.delay):
+		return ss.signer.Sign(msg)
+	case <-ctx.Done():
+		return crypto.Signature{}, ctx.Err()
+	}
+}
+
+func TestSignVoteContextTimeout(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	slow := slowContextSigner{slowSigner{signer, 100 * time.Millisecond}}
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	info.SetWriteAhead(true)
+	require.NoError(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	vote := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	err := info.SignVoteContext(ctx, slow, "mychainid", vote)
+	cancel()
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.True(vote.Signature.Empty())
+
+	// it can't be known whether it was signed, so the marker stays
+	assert.Equal(stepPrevote, info.LastStep)
+	assert.Equal(&PendingSign{10, 0, stepPrecommit}, info.PendingSign)
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(&PendingSign{10, 0, stepPrecommit}, loaded.PendingSign)
+	assert.Equal(ErrPendingSign, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID2)))
+
+	// once recovered, the height/round/step is treated as signed
+	recovered, err := info.Recover()
+	require.Nil(err)
+	assert.True(recovered)
+	assert.Equal(ErrNoLastSignature, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID2)))
+	assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+}
+
+func TestSignVoteContextTimeoutWithoutWriteAhead(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, _ := newTestSigner()
+	slow := slowContextSigner{slowSigner{signer, 100 * time.Millisecond}}
+	info := NewLastSignedInfo()
+
+	vote := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	assert.Equal(context.DeadlineExceeded, info.SignVoteContext(ctx, slow, "mychainid", vote))
+	cancel()
+	assert.Equal(int64(0), info.LastHeight)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	assert.NoError(info.SignVoteContext(ctx, slow, "mychainid", vote))
+	cancel()
+	assert.False(vote.Signature.Empty())
+}
+
+func TestSignVoteContextWaitsForPlainSigner(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, pubKey := newTestSigner()
+	info := NewLastSignedInfo()
+
+	// it can't be stopped, so the deadline doesn't apply once it's called
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(info.SignVoteContext(ctx, slowSigner{signer, 100 * time.Millisecond}, "mychainid", vote))
+	assert.True(pubKey.VerifyBytes(types.SignBytes("mychainid", vote), vote.Signature))
+	assert.Equal(int64(10), info.LastHeight)
+}
+
+func TestSignVoteContextCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, _ := newTestSigner()
+	info := NewLastSignedInfo()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(context.Canceled, info.SignVoteContext(ctx, signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(int64(0), info.LastHeight)
+}
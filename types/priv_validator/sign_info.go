@@ -0,0 +1,770 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	data "github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+// TODO: type ?
+// Steps are ordered by their value, which is what Verify compares.
+// New steps must be appended with higher values and stepMax updated,
+// so files with a step this version doesn't know are refused on load
+// instead of being treated as lower than a known step.
+const (
+	stepNone      int8 = 0 // Used to distinguish the initial state
+	stepPropose   int8 = 1
+	stepPrevote   int8 = 2
+	stepPrecommit int8 = 3
+
+	stepMax = stepPrecommit
+)
+
+// The steps of the LastStep, eg. for the consensus to map its own steps to.
+const (
+	StepNone      = stepNone
+	StepPropose   = stepPropose
+	StepPrevote   = stepPrevote
+	StepPrecommit = stepPrecommit
+)
+
+func voteToStep(vote *types.Vote) int8 {
+	switch vote.Type {
+	case types.VoteTypePrevote:
+		return stepPrevote
+	case types.VoteTypePrecommit:
+		return stepPrecommit
+	default:
+		cmn.PanicSanity("Unknown vote type")
+		return 0
+	}
+}
+
+var (
+	ErrHeightRegression = errors.New("Height regression")
+	ErrRoundRegression  = errors.New("Round regression")
+	ErrStepRegression   = errors.New("Step regression")
+	ErrNoLastSignature  = errors.New("No LastSignature found")
+	ErrConflictingData  = errors.New("Conflicting data")
+	ErrAlreadySigned    = errors.New("Already signed at this height/round/step")
+)
+
+// SignerVersion is recorded with each signature, see LastSignedByVersion.
+var SignerVersion = version.Version
+
+//-------------------------------------
+
+// LastSignedInfo contains information about the latest
+// data signed by a validator to help prevent double signing.
+// It is not safe for concurrent use; callers must serialize access,
+// as PrivValidatorFS does with its mutex.
+type LastSignedInfo struct {
+	LastHeight    int64      `json:"last_height"`
+	LastRound     int        `json:"last_round"`
+	LastStep      int8       `json:"last_step"`
+	LastSignature Signature  `json:"last_signature,omitempty"` // so we dont lose signatures
+	LastSignBytes data.Bytes `json:"last_signbytes,omitempty"` // so we dont lose signatures
+
+	// The SHA-256 of the sign bytes, instead of the LastSignBytes. See SetHashOnly.
+	LastSignBytesHash data.Bytes `json:"last_signbytes_hash,omitempty"`
+
+	// The SignerVersion that made the LastSignature, for forensics.
+	// It's never used to decide whether to sign.
+	LastSignedByVersion string `json:"last_signed_by_version,omitempty"`
+
+	// Set before signing in write-ahead mode, cleared once the signature is recorded.
+	PendingSign *PendingSign `json:"pending_sign,omitempty"`
+
+	// Heights with a precommit for a block, if tracked. See CommittedHeights.
+	CommittedRanges []HeightRange `json:"committed_heights,omitempty"`
+
+	// Nothing below it is ever signed. See SetFloorHeight.
+	FloorHeight int64 `json:"floor_height,omitempty"`
+
+	// The HRS was advanced to without signing. See AdvanceWithoutSigning.
+	Unsigned bool `json:"unsigned,omitempty"`
+
+	// Counts the signatures recorded, and never goes backwards. See Sequence.
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Rolling hash of the signatures recorded. See Fingerprint.
+	SignHash data.Bytes `json:"sign_hash,omitempty"`
+
+	// The extension of the last precommit, if signed. See SignExtension.
+	LastExtension *SignedExtension `json:"last_extension,omitempty"`
+
+	// The only chain signed for, if bound. See Bind.
+	ChainID string `json:"chain_id,omitempty"`
+
+	// The last rotation of the key, which binds it to the new key. See RotateKey.
+	KeyRotation *KeyRotation `json:"key_rotation,omitempty"`
+
+	// Why signing was stopped for good, if it was. See Tombstone.
+	TombstoneInfo *Tombstone `json:"tombstone,omitempty"`
+
+	// Whether a Reset still needs AcknowledgeReset. See SetRequireResetAck.
+	UnacknowledgedReset bool `json:"unacknowledged_reset,omitempty"`
+
+	// For persistence.
+	// If both are empty, Set and Reset only update memory.
+	filePath string
+	mirrors  []string
+	store    SignerState
+
+	tracer Tracer
+	clock  Clock
+	logger log.Logger
+	locker sync.Locker
+
+	strictSteps      bool
+	writeAhead       bool
+	verifyOnSet      crypto.PubKey
+	debugComparisons bool
+	stepOrder        StepOrder
+	noReuse          bool
+	strictTimestamps bool
+	requireResetAck  bool
+	hashOnly         bool
+	encoder          CanonicalEncoder
+	onReject         func(RejectEvent)
+	missingSignature MissingSignatureStrategy
+	policy           SignPolicy
+
+	conflictStrategy   ConflictStrategy
+	onConflictEvidence func(lastSignBytes, signBytes []byte)
+	frozen             bool
+
+	onUpdate  func(HRSUpdate)
+	onSign    func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature)
+	replicate func(chainID string, hrs HRS, signBytes []byte, sig crypto.Signature) error
+
+	events      chan SignerEvent
+	eventNumber uint64
+
+	trackCommittedHeights bool
+
+	healthMaxSignAge time.Duration
+
+	history signHistory
+}
+
+// NewLastSignedInfo returns a LastSignedInfo in its initial state.
+func NewLastSignedInfo() *LastSignedInfo {
+	return &LastSignedInfo{
+		LastStep: stepNone,
+		tracer:   nopTracer{},
+		clock:    systemClock{},
+		logger:   log.NewNopLogger(),
+	}
+}
+
+// LoadLastSignedInfo loads a LastSignedInfo from the filePath,
+// first recovering from a crash in the middle of saving it, if any.
+// Subsequent calls to Set and Reset persist to the same file.
+// Nothing is logged; to log the recovery, and a file accessible by anyone
+// but its owner, load it with a SignInfoFile, see SignInfoFile.SetLogger.
+func LoadLastSignedInfo(filePath string) (*LastSignedInfo, error) {
+	return loadLastSignedInfo(filePath, JSONCodec{}, log.NewNopLogger())
+}
+
+// loadLastSignedInfo is LoadLastSignedInfo, logging to logger,
+// which the LastSignedInfo keeps.
+func loadLastSignedInfo(filePath string, codec StateCodec, logger log.Logger) (*LastSignedInfo, error) {
+	if err := recoverTempFile(filePath, codec, logger); err != nil {
+		return nil, err
+	}
+	infoBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := decodeBytes(codec, infoBytes)
+	if err == ErrCorruptState {
+		// returned as is, so it can be told apart
+		logger.Error("Corrupt LastSignature in LastSignedInfo", "file", filePath)
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("Error reading LastSignedInfo from %v: %v", filePath, err)
+	}
+	warnPermissions(filePath, logger)
+	info.filePath = filePath
+	info.SetLogger(logger)
+	return info, nil
+}
+
+func unmarshalLastSignedInfo(jsonBytes []byte) (*LastSignedInfo, error) {
+	// NOTE: an absent last_step is left as stepNone, the same as an explicit 0,
+	// since stepNone is the zero value.
+	info := NewLastSignedInfo()
+	if err := json.Unmarshal(jsonBytes, info); err != nil {
+		if checkSignatureDecodes(jsonBytes) == ErrCorruptState {
+			return nil, ErrCorruptState
+		}
+		return nil, err
+	}
+	if info.LastStep < stepNone || info.LastStep > stepMax {
+		return nil, fmt.Errorf("Unknown last_step %v, it may have been written by a newer version", info.LastStep)
+	}
+	return info, nil
+}
+
+// SetFilePath sets the file that Set and Reset persist to.
+// It returns an error if the directory of the file doesn't exist or isn't writable,
+// so that it fails before signing rather than while recording a signature,
+// and ErrChainMismatch if the file has a state bound to another chain, see Bind.
+// An empty filePath disables persistence.
+func (info *LastSignedInfo) SetFilePath(filePath string) error {
+	if filePath != "" {
+		if err := checkDirWritable(filepath.Dir(filePath)); err != nil {
+			return err
+		}
+	}
+	if err := checkFileChain(filePath, info.ChainID); err != nil {
+		return err
+	}
+	info.filePath = filePath
+	return nil
+}
+
+// SetFilePathMkdir is like SetFilePath, but first creates the directory
+// of the file, readable by the owner only, if it doesn't exist.
+func (info *LastSignedInfo) SetFilePathMkdir(filePath string) error {
+	if err := cmn.EnsureDir(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	return info.SetFilePath(filePath)
+}
+
+// Save persists the LastSignedInfo to its SignerState if set,
+// or else to its filePath.
+func (info *LastSignedInfo) Save() error {
+	if info.store != nil {
+		return info.store.Save(info)
+	}
+	if info.filePath == "" {
+		return errors.New("Cannot save LastSignedInfo: filePath not set")
+	}
+	return info.SaveAs(info.filePath)
+}
+
+// SaveAs persists the LastSignedInfo to the given filePath, then to the mirrors, see AddMirror.
+func (info *LastSignedInfo) SaveAs(filePath string) error {
+	return info.saveAs(filePath, JSONCodec{})
+}
+
+func (info *LastSignedInfo) saveAs(filePath string, codec StateCodec) error {
+	infoBytes, err := encodeBytes(codec, info)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filePath, infoBytes); err != nil {
+		return err
+	}
+	info.saveMirrors(infoBytes)
+	return nil
+}
+
+// String returns a string representation of the LastSignedInfo.
+func (info *LastSignedInfo) String() string {
+	return fmt.Sprintf("LastSignedInfo{LH:%v, LR:%v, LS:%v}", info.LastHeight, info.LastRound, info.LastStep)
+}
+
+// HasSignature returns true if sig is the LastSignature.
+func (info *LastSignedInfo) HasSignature(sig crypto.Signature) bool {
+	if info.LastSignature.Empty() || sig.Empty() {
+		return false
+	}
+	return info.LastSignature.Equals(sig)
+}
+
+// Verify returns an error if there is a height/round/step regression
+// or if the HRS matches but there are no LastSignBytes,
+// unless the HRS was advanced to with AdvanceWithoutSigning.
+// It returns true if HRS matches exactly and the LastSignature exists.
+// It panics if the HRS matches, the LastSignBytes are not empty, but the LastSignature is empty;
+// SignVote and SignProposal handle that instead, see SetMissingSignatureStrategy.
+func (info *LastSignedInfo) Verify(height int64, round int, step int8) (bool, error) {
+	sameHRS, err := info.traceVerify(height, round, step)
+	if err != nil {
+		info.reject(height, round, step, err)
+	}
+	return sameHRS, err
+}
+
+func (info *LastSignedInfo) traceVerify(height int64, round int, step int8) (bool, error) {
+	end := info.startSpan("LastSignedInfo.Verify", height, round, step)
+	sameHRS, err := info.verify(height, round, step)
+	switch {
+	case err != nil:
+		end("outcome", "rejected", "error", err.Error())
+	case sameHRS:
+		end("outcome", "same_hrs")
+	default:
+		end("outcome", "advance")
+	}
+	return sameHRS, err
+}
+
+func (info *LastSignedInfo) verify(height int64, round int, step int8) (bool, error) {
+	if info.Tombstoned() {
+		return false, ErrTombstoned
+	}
+	if info.PendingSign != nil {
+		return false, ErrPendingSign
+	}
+	if height < info.FloorHeight {
+		return false, ErrBelowFloor
+	}
+
+	if info.LastHeight > height {
+		return false, ErrHeightRegression
+	}
+
+	if info.LastHeight == height {
+		if info.LastRound > round {
+			return false, ErrRoundRegression
+		}
+
+		if info.LastRound == round {
+			if info.compareSteps(info.LastStep, step) > 0 {
+				return false, ErrStepRegression
+			} else if info.LastStep == step {
+				if info.hasSignBytes() {
+					if info.LastSignature.Empty() {
+						panic("info: LastSignature is nil but LastSignBytes is not!")
+					}
+					return true, nil
+				}
+				if info.Unsigned {
+					// nothing was signed there, so it's like a step advance
+					return false, nil
+				}
+				return false, ErrNoLastSignature
+			} else if info.strictSteps && !isNextStep(info.LastStep, step) {
+				return false, ErrStepSkipped
+			}
+		}
+	}
+	return false, nil
+}
+
+// Set height/round/step and signature on the info,
+// and persist it if a filePath is set.
+// If VerifyOnSet is enabled, the signature is verified first,
+// and in strict step mode, the step is checked against the sign bytes.
+func (info *LastSignedInfo) Set(height int64, round int, step int8,
+	signBytes []byte, sig crypto.Signature) error {
+	end := info.startSpan("LastSignedInfo.Set", height, round, step)
+	if info.strictSteps {
+		if err := checkStepType(step, signBytes); err != nil {
+			end("outcome", "error", "error", err.Error())
+			return err
+		}
+	}
+	if err := info.verifySignature(signBytes, sig); err != nil {
+		end("outcome", "error", "error", err.Error())
+		return err
+	}
+	if err := info.checkBoundKey(signBytes, sig); err != nil {
+		end("outcome", "error", "error", err.Error())
+		return err
+	}
+
+	info.LastHeight = height
+	info.LastRound = round
+	info.LastStep = step
+	info.LastSignature = SignatureFromCrypto(sig)
+	info.recordSignBytes(signBytes)
+	info.LastExtension = nil
+	info.LastSignedByVersion = SignerVersion
+	info.Unsigned = false
+	info.PendingSign = nil
+	info.Seq++
+	info.recordSignHash(height, round, step, signBytes, sig.Bytes())
+	info.history.add(signedRecord{height, round, step, signBytes})
+	info.recordCommitted(height, step, signBytes)
+
+	if err := info.persist(); err != nil {
+		end("outcome", "error", "error", err.Error())
+		return err
+	}
+	end("outcome", "recorded")
+	return nil
+}
+
+// Reset resets the height/round/step and what goes with it. It keeps the Seq,
+// which never goes backwards, the SignHash, which goes with it, the
+// TombstoneInfo, see ClearTombstone, and what guards against signing with the
+// wrong key or for the wrong chain: the FloorHeight, the ChainID (see Bind)
+// and the KeyRotation. See also SetRequireResetAck.
+// NOTE: Unsafe!
+func (info *LastSignedInfo) Reset() error {
+	info.LastHeight = 0
+	info.LastRound = 0
+	info.LastStep = 0
+	info.LastSignature = Signature{}
+	info.LastSignBytes = nil
+	info.LastSignBytesHash = nil
+	info.LastExtension = nil
+	info.LastSignedByVersion = ""
+	info.Unsigned = false
+	info.PendingSign = nil
+	info.CommittedRanges = nil
+	info.UnacknowledgedReset = info.requireResetAck
+	// the sign bytes and signature must go with the HRS
+	if err := info.checkConsistent(); err != nil {
+		panic(err)
+	}
+	info.emitStateEvent(EventReset, "")
+	return info.persist()
+}
+
+// EnsureAtLeast advances the height/round/step to at least the given HRS.
+// If the given HRS is ahead of the latest state, it is recorded without a signature
+// (so nothing can be reused there) and advanced is true.
+// Otherwise it's a no-op and advanced is false; it never regresses.
+// It only returns an error if the HRS is malformed or persisting fails.
+func (info *LastSignedInfo) EnsureAtLeast(height int64, round int, step int8) (advanced bool, err error) {
+	if err := validateHRS(height, round, step); err != nil {
+		return false, err
+	}
+	if info.compareToLast(height, round, step) <= 0 {
+		return false, nil
+	}
+
+	info.LastHeight = height
+	info.LastRound = round
+	info.LastStep = step
+	info.LastSignature = Signature{}
+	info.LastSignBytes = nil
+	info.LastSignBytesHash = nil
+	info.LastExtension = nil
+	info.LastSignedByVersion = ""
+	info.Unsigned = false
+	if err := info.persist(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AdvanceWithoutSigning records that consensus moved on to the given HRS
+// without signing anything, eg. as it wasn't a validator that round.
+// Unlike EnsureAtLeast, where it's unknown whether the HRS was signed,
+// signing at that HRS later is allowed, as a fresh signature.
+// It returns an error if the HRS is malformed or a regression, or if
+// it was already signed.
+func (info *LastSignedInfo) AdvanceWithoutSigning(height int64, round int, step int8) error {
+	if err := validateHRS(height, round, step); err != nil {
+		return err
+	}
+	sameHRS, err := info.verify(height, round, step)
+	if err != nil {
+		return err
+	}
+	if sameHRS {
+		return ErrAlreadySigned
+	}
+
+	info.LastHeight = height
+	info.LastRound = round
+	info.LastStep = step
+	info.LastSignature = Signature{}
+	info.LastSignBytes = nil
+	info.LastSignBytesHash = nil
+	info.LastExtension = nil
+	info.LastSignedByVersion = ""
+	info.Unsigned = true
+	return info.persist()
+}
+
+func (info *LastSignedInfo) persist() error {
+	if info.store == nil && info.filePath == "" {
+		return nil
+	}
+	return info.Save()
+}
+
+// SignVote checks the height/round/step (HRS) are greater than the latest state of the LastSignedInfo.
+// If so, it signs the vote, updates the LastSignedInfo, and sets the signature on the vote.
+// If the HRS are equal and the only thing changed is the timestamp, it sets the Signature to the LastSignature,
+// and the timestamp back to the one it was signed with, so the vote verifies (see SetStrictTimestamps).
+// Else it returns an error.
+func (info *LastSignedInfo) SignVote(signer types.Signer, chainID string, vote *types.Vote) error {
+	_, err := info.SignVoteWithReason(signer, chainID, vote)
+	return err
+}
+
+// SignVoteWithReason is like SignVote, but also returns why a fresh signature
+// was needed, or Reused if the LastSignature was used instead.
+// On a conflict it returns ContentDiffers along with the error, and
+// NotSigned along with any other error.
+func (info *LastSignedInfo) SignVoteWithReason(signer types.Signer, chainID string, vote *types.Vote) (SignReason, error) {
+	return info.signVote(context.Background(), signer, chainID, vote)
+}
+
+func (info *LastSignedInfo) signVote(ctx context.Context, signer types.Signer, chainID string, vote *types.Vote) (SignReason, error) {
+	onlyDifferByTimestamp, normalize := info.comparisons(checkVotesOnlyDifferByTimestamp, normalizeVotes)
+	return info.sign(ctx, signer, signRequest{
+		span:                  "LastSignedInfo.SignVote",
+		chainID:               chainID,
+		height:                vote.Height,
+		round:                 vote.Round,
+		step:                  voteToStep(vote),
+		signBytes:             info.canonicalEncoder().VoteBytes(chainID, vote),
+		onlyDifferByTimestamp: onlyDifferByTimestamp,
+		normalize:             normalize,
+		allow:                 func() error { return info.allow(chainID, vote) },
+		setSignature:          func(sig crypto.Signature) { vote.Signature = sig },
+		setTimestamp:          func(timestamp time.Time) { vote.Timestamp = timestamp },
+		encode:                func() []byte { return info.canonicalEncoder().VoteBytes(chainID, vote) },
+	})
+}
+
+// signRequest is what sign needs to know about a vote or proposal.
+type signRequest struct {
+	span      string
+	chainID   string
+	height    int64
+	round     int
+	step      int8
+	signBytes []byte
+
+	// compare sign bytes of this kind, see checkVotesOnlyDifferByTimestamp
+	onlyDifferByTimestamp func(lastSignBytes, newSignBytes []byte, now time.Time) bool
+	normalize             func(lastSignBytes, newSignBytes []byte, now time.Time) ([]byte, []byte)
+
+	// the SignPolicy, if any
+	allow func() error
+
+	// set the signature, and the timestamp it was signed with when reused
+	setSignature func(sig crypto.Signature)
+	setTimestamp func(timestamp time.Time)
+	// the sign bytes of the vote or proposal as it is, if any, see checkReused
+	encode func() []byte
+	// the timestamp can't be set back, see SetStrictTimestamps
+	strictTimestamps bool
+}
+
+// sign checks, signs and records the request, or reuses the LastSignature,
+// on behalf of SignVote and SignProposal.
+func (info *LastSignedInfo) sign(ctx context.Context, signer types.Signer, req signRequest) (SignReason, error) {
+	height, round, step, signBytes := req.height, req.round, req.step, req.signBytes
+	end := info.startSpan(req.span, height, round, step)
+
+	if info.frozen {
+		info.reject(height, round, step, ErrFrozen)
+		end("outcome", "rejected", "error", ErrFrozen.Error())
+		return NotSigned, ErrFrozen
+	}
+	if info.Tombstoned() {
+		info.reject(height, round, step, ErrTombstoned)
+		end("outcome", "rejected", "error", ErrTombstoned.Error())
+		return NotSigned, ErrTombstoned
+	}
+	if info.UnacknowledgedReset {
+		info.reject(height, round, step, ErrResetNotAcknowledged)
+		end("outcome", "rejected", "error", ErrResetNotAcknowledged.Error())
+		return NotSigned, ErrResetNotAcknowledged
+	}
+
+	if err := info.checkChain(req.chainID); err != nil {
+		info.reject(height, round, step, err)
+		end("outcome", "rejected", "error", err.Error())
+		return NotSigned, err
+	}
+
+	resign, err := info.checkMissingSignature(height, round, step, signBytes)
+	if err != nil {
+		info.reject(height, round, step, err)
+		end("outcome", "rejected", "error", err.Error())
+		return NotSigned, err
+	}
+
+	var sameHRS bool
+	if !resign {
+		sameHRS, err = info.traceVerify(height, round, step)
+	}
+	if err != nil {
+		if err := info.checkHistory(height, round, step, signBytes, req.onlyDifferByTimestamp); err != nil {
+			info.reject(height, round, step, err)
+			end("outcome", "conflict", "reason", ContentDiffers)
+			return ContentDiffers, err
+		}
+		info.reject(height, round, step, err)
+		end("outcome", "rejected", "error", err.Error())
+		return NotSigned, err
+	}
+	reason := info.signReason(height, round, step)
+	if resign {
+		reason = SignatureMissing
+	}
+
+	// We might crash before writing to the wal,
+	// causing us to try to re-sign for the same HRS.
+	// If they're the same or only differ by timestamp,
+	// use the LastSignature. Otherwise, error.
+	// The comparison is only done once Verify confirmed the HRS is the same,
+	// though it would also catch a different HRS, as those are content fields.
+	if sameHRS {
+		if err := info.checkCanonical(signBytes); err != nil {
+			info.reject(height, round, step, err)
+			end("outcome", "rejected", "error", err.Error())
+			return NotSigned, err
+		}
+		info.flagEmptyChainID(info.LastSignBytes)
+		switch {
+		case info.matchesSignBytes(signBytes) && info.noReuse:
+			reason = ReuseDisabled
+		case info.matchesSignBytes(signBytes):
+			reason = Reused
+		case info.LastSignBytes != nil && !info.noReuse &&
+			req.onlyDifferByTimestamp(info.LastSignBytes, signBytes, info.now()):
+			reason = Reused
+			if !isDeterministic(info.LastSignature) {
+				reason = NonDeterministicKey
+			}
+		default:
+			if info.LastSignBytes != nil {
+				info.logConflict(req.normalize, info.LastSignBytes, signBytes)
+			}
+			info.reject(height, round, step, ErrConflictingData)
+			info.handleConflict(height, round, step, signBytes)
+			end("outcome", "conflict", "reason", ContentDiffers)
+			return ContentDiffers, ErrConflictingData
+		}
+	}
+
+	if err := req.allow(); err != nil {
+		info.reject(height, round, step, err)
+		end("outcome", "denied", "error", err.Error())
+		return NotSigned, err
+	}
+	if sameHRS && reason == Reused {
+		// the LastSignature covers the LastSignBytes,
+		// so the vote or proposal must have their timestamp to verify.
+		// With only their hash, they're exactly signBytes.
+		signedBytes := info.LastSignBytes
+		if signedBytes == nil {
+			signedBytes = signBytes
+		}
+		if (info.strictTimestamps || req.strictTimestamps) && !bytes.Equal(signedBytes, signBytes) {
+			info.reject(height, round, step, ErrTimestampRewrite)
+			end("outcome", "rejected", "error", ErrTimestampRewrite.Error())
+			return NotSigned, ErrTimestampRewrite
+		}
+		timestamp, err := info.signedTimestamp(signedBytes)
+		if err != nil {
+			end("outcome", "error", "error", err.Error())
+			return NotSigned, err
+		}
+		req.setTimestamp(timestamp)
+		if err := checkReused(req, signedBytes); err != nil {
+			end("outcome", "error", "error", err.Error())
+			return NotSigned, err
+		}
+		if err := info.pushSignature(req.chainID, signedBytes, false); err != nil {
+			end("outcome", "error", "error", err.Error())
+			return NotSigned, err
+		}
+		req.setSignature(info.LastSignature.Crypto())
+		end("outcome", "reused", "reason", reason)
+		info.emitUpdate(reason, signedBytes, true)
+		return reason, nil
+	}
+
+	if err := info.markPending(height, round, step); err != nil {
+		end("outcome", "error", "error", err.Error())
+		return NotSigned, err
+	}
+	sig, err := signWithContext(ctx, signer, signBytes)
+	if err != nil {
+		end("outcome", "error", "error", err.Error())
+		return NotSigned, err
+	}
+	if err := info.Set(height, round, step, signBytes, sig); err != nil {
+		if err == ErrKeyMismatch {
+			// the signature by the wrong key is dropped
+			info.clearPending()
+		}
+		end("outcome", "error", "error", err.Error())
+		return NotSigned, err
+	}
+	if err := info.pushSignature(req.chainID, signBytes, true); err != nil {
+		end("outcome", "error", "error", err.Error())
+		return NotSigned, err
+	}
+	req.setSignature(sig)
+	end("outcome", "signed", "reason", reason, "time", info.now())
+	info.emitUpdate(reason, signBytes, false)
+	return reason, nil
+}
+
+//-------------------------------------
+
+// returns an error unless dir is a directory we can create files in
+func checkDirWritable(dir string) error {
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("Cannot use directory of LastSignedInfo: %v", err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("Cannot use directory of LastSignedInfo: %v is not a directory", dir)
+	}
+	f, err := ioutil.TempFile(dir, "sign_info_check_")
+	if err != nil {
+		return fmt.Errorf("Cannot use directory of LastSignedInfo: %v", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// returns an error if the height/round/step can't have been signed
+func validateHRS(height int64, round int, step int8) error {
+	if height < 0 {
+		return fmt.Errorf("Invalid height %v", height)
+	}
+	if round < 0 {
+		return fmt.Errorf("Invalid round %v", round)
+	}
+	if step < stepNone || step > stepMax {
+		return fmt.Errorf("Invalid step %v", step)
+	}
+	return nil
+}
+
+// compareHRS returns -1, 0 or 1 if the first height/round/step
+// is lower than, equal to or higher than the second, with the StockStepOrder
+func compareHRS(h1 int64, r1 int, s1 int8, h2 int64, r2 int, s2 int8) int {
+	switch {
+	case h1 != h2:
+		return compareInt64(h1, h2)
+	case r1 != r2:
+		return compareInt64(int64(r1), int64(r2))
+	default:
+		return compareInt64(int64(s1), int64(s2))
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
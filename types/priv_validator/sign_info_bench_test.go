@@ -0,0 +1,158 @@
+package types
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// benchmarks use a fixed clock, key and votes with realistic
+// hash sizes, so results are comparable between runs
+
+var benchTime = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func benchVote(height int64) *types.Vote {
+	return &types.Vote{
+		ValidatorAddress: make([]byte, 20),
+		Height:           height,
+		Round:            0,
+		Timestamp:        benchTime,
+		Type:             types.VoteTypePrecommit,
+		BlockID: types.BlockID{
+			Hash:        cmn.Fingerprint([]byte("0123456789abcdef0123")),
+			PartsHeader: types.PartSetHeader{Total: 4, Hash: []byte("0123456789abcdef0123")},
+		},
+	}
+}
+
+func benchSignInfo() (*LastSignedInfo, types.Signer) {
+	info := NewLastSignedInfo()
+	info.SetClock(NewManualClock(benchTime))
+	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte("bench")).Wrap()
+	return info, types.NewDefaultSigner(privKey)
+}
+
+func BenchmarkVerify(b *testing.B) {
+	info, signer := benchSignInfo()
+	if err := info.SignVote(signer, "test_chain_id", benchVote(10)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := info.Verify(11, 0, stepPrevote); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCheckVotesOnlyDifferByTimestamp(b *testing.B) {
+	last := types.SignBytes("test_chain_id", benchVote(10))
+	vote := benchVote(10)
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+	signBytes := types.SignBytes("test_chain_id", vote)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !checkVotesOnlyDifferByTimestamp(last, signBytes, benchTime) {
+			b.Fatal("expected votes to only differ by timestamp")
+		}
+	}
+}
+
+func BenchmarkSetInMemory(b *testing.B) {
+	benchmarkSet(b, "")
+}
+
+func BenchmarkSetPersisted(b *testing.B) {
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(tempFilePath)
+	benchmarkSet(b, tempFilePath)
+}
+
+func benchmarkSet(b *testing.B, filePath string) {
+	info, signer := benchSignInfo()
+	info.SetFilePath(filePath)
+	signBytes := types.SignBytes("test_chain_id", benchVote(10))
+	sig, _ := signer.Sign(signBytes)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := info.Set(int64(i+1), 0, stepPrecommit, signBytes, sig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignVote(b *testing.B) {
+	info, signer := benchSignInfo()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := info.SignVote(signer, "test_chain_id", benchVote(int64(i+1))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignVoteReuse(b *testing.B) {
+	info, signer := benchSignInfo()
+	vote := benchVote(10)
+	if err := info.SignVote(signer, "test_chain_id", vote); err != nil {
+		b.Fatal(err)
+	}
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := info.SignVote(signer, "test_chain_id", vote); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGzipCodec(b *testing.B) {
+	info, signer := benchSignInfo()
+	if err := info.SignVote(signer, "test_chain_id", benchVote(10)); err != nil {
+		b.Fatal(err)
+	}
+
+	// encodes and decodes, with the size of the encoding in the name
+	codecs := []struct {
+		name  string
+		codec StateCodec
+	}{
+		{"json", JSONCodec{}},
+		{"gzip", GzipCodec{}},
+		{"gzip_best_speed", GzipCodec{Level: gzip.BestSpeed}},
+	}
+	for _, c := range codecs {
+		encoded, err := encodeBytes(c.codec, info)
+		if err != nil {
+			b.Fatal(err)
+		}
+		codec := c.codec
+		b.Run(fmt.Sprintf("%v_%vbytes", c.name, len(encoded)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := encodeBytes(codec, info); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := decodeBytes(codec, encoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
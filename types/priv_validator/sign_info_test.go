@@ -0,0 +1,357 @@
+package types
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire/data"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestLastSignedInfoSaveLoad(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(info.LastHeight, loaded.LastHeight)
+	assert.Equal(info.LastRound, loaded.LastRound)
+	assert.Equal(info.LastStep, loaded.LastStep)
+	assert.Equal(info.LastSignBytes, loaded.LastSignBytes)
+	assert.True(info.LastSignature.Equals(loaded.LastSignature.Crypto()))
+}
+
+func TestLastSignedInfoLoadMissingStep(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	cases := []string{
+		`{"last_height":0,"last_round":0}`,               // absent last_step
+		`{"last_height":0,"last_round":0,"last_step":0}`, // explicit 0
+	}
+	for _, c := range cases {
+		_, tempFilePath := cmn.Tempfile("sign_info_")
+		require.Nil(ioutil.WriteFile(tempFilePath, []byte(c), 0600))
+
+		info, err := LoadLastSignedInfo(tempFilePath)
+		require.Nil(err, c)
+		assert.Equal(stepNone, info.LastStep, c)
+		assert.Equal(FirstSign, info.signReason(1, 0, stepPrevote), c)
+	}
+}
+
+func TestLastSignedInfoSignerVersion(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	defer func(v string) { SignerVersion = v }(SignerVersion)
+	SignerVersion = "0.15.0-abcdef"
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+	assert.Equal("0.15.0-abcdef", info.LastSignedByVersion)
+
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal("0.15.0-abcdef", loaded.LastSignedByVersion)
+
+	// it doesn't affect signing
+	SignerVersion = "0.16.0"
+	sameHRS, err := loaded.Verify(10, 1, stepPrevote)
+	assert.Nil(err)
+	assert.True(sameHRS)
+
+	// files without it still load
+	require.Nil(ioutil.WriteFile(tempFilePath, []byte(`{"last_height":10,"last_round":1,"last_step":2}`), 0600))
+	loaded, err = LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal("", loaded.LastSignedByVersion)
+	assert.Equal(int64(10), loaded.LastHeight)
+}
+
+func TestLastSignedInfoLoadUnknownStep(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	for step := stepNone; step <= stepMax; step++ {
+		info := NewLastSignedInfo()
+		info.LastHeight, info.LastStep = 10, step
+		require.Nil(info.SaveAs(tempFilePath))
+		_, err := LoadLastSignedInfo(tempFilePath)
+		assert.Nil(err, "step %v", step)
+	}
+
+	// a step from the future, or garbage
+	for _, step := range []int8{stepMax + 1, -1} {
+		info := NewLastSignedInfo()
+		info.LastHeight, info.LastStep = 10, step
+		require.Nil(info.SaveAs(tempFilePath))
+		_, err := LoadLastSignedInfo(tempFilePath)
+		assert.Error(err, "step %v", step)
+	}
+}
+
+func TestLastSignedInfoSetFilePath(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir, err := ioutil.TempDir("", "sign_info_")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	info := NewLastSignedInfo()
+	missing := filepath.Join(dir, "missing", "sign_info.json")
+	assert.Error(info.SetFilePath(missing))
+	assert.Error(info.SetFilePath(filepath.Join(dir, "missing", "nested", "sign_info.json")))
+
+	// not a directory
+	notDir := filepath.Join(dir, "file")
+	require.Nil(ioutil.WriteFile(notDir, nil, 0600))
+	assert.Error(info.SetFilePath(filepath.Join(notDir, "sign_info.json")))
+
+	// nothing was set, so nothing is persisted
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	assert.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+
+	require.Nil(info.SetFilePathMkdir(missing))
+	stat, err := os.Stat(filepath.Dir(missing))
+	require.Nil(err)
+	assert.Equal(os.FileMode(0700), stat.Mode().Perm())
+	assert.Nil(info.Set(11, 1, stepPrevote, []byte("signbytes"), sig))
+	loaded, err := LoadLastSignedInfo(missing)
+	require.Nil(err)
+	assert.Equal(int64(11), loaded.LastHeight)
+
+	assert.Nil(info.SetFilePath(""))
+}
+
+func TestLastSignedInfoVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	assert.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+
+	cases := []struct {
+		height  int64
+		round   int
+		step    int8
+		sameHRS bool
+		errored bool
+	}{
+		{10, 1, stepPrevote, true, false},    // same HRS
+		{10, 1, stepPrecommit, false, false}, // step advance
+		{10, 2, stepPropose, false, false},   // round advance
+		{11, 0, stepPropose, false, false},   // height advance
+		{10, 1, stepPropose, false, true},    // step regression
+		{10, 0, stepPrecommit, false, true},  // round regression
+		{9, 5, stepPrecommit, false, true},   // height regression
+	}
+
+	for i, c := range cases {
+		sameHRS, err := info.Verify(c.height, c.round, c.step)
+		assert.Equal(c.sameHRS, sameHRS, "case %d", i)
+		assert.Equal(c.errored, err != nil, "case %d: %v", i, err)
+	}
+}
+
+func TestLastSignedInfoReset(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	assert.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+	assert.Nil(info.Reset())
+
+	assert.Equal(int64(0), info.LastHeight)
+	assert.Equal(0, info.LastRound)
+	assert.Equal(stepNone, info.LastStep)
+	assert.True(info.LastSignature.Empty())
+	assert.Nil(info.LastSignBytes)
+}
+
+func TestLastSignedInfoSignVote(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, _ := newTestSigner()
+	height, round := int64(10), 1
+	voteType := types.VoteTypePrevote
+
+	// sign a vote for first time
+	vote := newVote(height, round, voteType, blockID1)
+	err := info.SignVote(signer, "mychainid", vote)
+	assert.NoError(err, "expected no error signing vote")
+
+	// try to sign the same vote again; should be fine
+	err = info.SignVote(signer, "mychainid", vote)
+	assert.NoError(err, "expected no error on signing same vote")
+
+	// now try some bad votes
+	cases := []*types.Vote{
+		newVote(height, round-1, voteType, blockID1),   // round regression
+		newVote(height-1, round, voteType, blockID1),   // height regression
+		newVote(height-2, round+4, voteType, blockID1), // height regression and different round
+		newVote(height, round, voteType, blockID2),     // different block
+	}
+
+	for _, c := range cases {
+		err = info.SignVote(signer, "mychainid", c)
+		assert.Error(err, "expected error on signing conflicting vote")
+	}
+
+	// try signing a vote with a different time stamp
+	sig := vote.Signature
+	vote.Timestamp = vote.Timestamp.Add(time.Duration(1000))
+	err = info.SignVote(signer, "mychainid", vote)
+	assert.NoError(err)
+	assert.Equal(sig, vote.Signature)
+	assert.True(info.HasSignature(vote.Signature))
+}
+
+func TestLastSignedInfoHasSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	assert.False(info.HasSignature(sig))
+	assert.False(info.HasSignature(crypto.Signature{}))
+
+	assert.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+	assert.True(info.HasSignature(crypto.SignatureEd25519{1}.Wrap()))
+	assert.False(info.HasSignature(crypto.SignatureEd25519{2}.Wrap()))
+	assert.False(info.HasSignature(crypto.SignatureSecp256k1{1}.Wrap()))
+	assert.False(info.HasSignature(crypto.Signature{}))
+}
+
+//-------------------------------------
+
+var (
+	blockID1 = types.BlockID{Hash: bytes.Repeat([]byte{1}, blockHashSize), PartsHeader: types.PartSetHeader{}}
+	blockID2 = types.BlockID{Hash: bytes.Repeat([]byte{2}, blockHashSize), PartsHeader: types.PartSetHeader{}}
+)
+
+func newTestSigner() (types.Signer, crypto.PubKey) {
+	privKey := crypto.GenPrivKeyEd25519().Wrap()
+	return types.NewDefaultSigner(privKey), privKey.PubKey()
+}
+
+func newVote(height int64, round int, typ byte, blockID types.BlockID) *types.Vote {
+	return &types.Vote{
+		ValidatorAddress: data.Bytes("addr"),
+		ValidatorIndex:   0,
+		Height:           height,
+		Round:            round,
+		Type:             typ,
+		Timestamp:        time.Now().UTC(),
+		BlockID:          blockID,
+	}
+}
+
+func TestLastSignedInfoEnsureAtLeast(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+
+	// at or below the mark is a no-op
+	for _, hrs := range [][3]int64{{10, 1, 2}, {10, 1, 1}, {10, 0, 3}, {9, 5, 3}} {
+		advanced, err := info.EnsureAtLeast(hrs[0], int(hrs[1]), int8(hrs[2]))
+		assert.Nil(err)
+		assert.False(advanced, "%v", hrs)
+	}
+	assert.Equal(int64(10), info.LastHeight)
+	assert.Equal([]byte("signbytes"), []byte(info.LastSignBytes))
+
+	// ahead of the mark advances and drops the signature
+	advanced, err := info.EnsureAtLeast(10, 1, stepPrecommit)
+	assert.Nil(err)
+	assert.True(advanced)
+	assert.Equal(stepPrecommit, info.LastStep)
+	assert.True(info.LastSignature.Empty())
+	assert.Nil(info.LastSignBytes)
+
+	// idempotent
+	advanced, err = info.EnsureAtLeast(10, 1, stepPrecommit)
+	assert.Nil(err)
+	assert.False(advanced)
+
+	// it was persisted
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(stepPrecommit, loaded.LastStep)
+
+	// a sign request at the ensured HRS is not treated as a reuse
+	_, err = info.Verify(10, 1, stepPrecommit)
+	assert.Error(err)
+	sameHRS, err := info.Verify(11, 0, stepPropose)
+	assert.Nil(err)
+	assert.False(sameHRS)
+
+	// malformed input
+	for _, hrs := range [][3]int64{{-1, 0, 1}, {20, -1, 1}, {20, 0, 4}, {20, 0, -1}} {
+		advanced, err := info.EnsureAtLeast(hrs[0], int(hrs[1]), int8(hrs[2]))
+		assert.Error(err, "%v", hrs)
+		assert.False(advanced)
+	}
+	assert.Equal(int64(10), info.LastHeight)
+}
+
+func TestLastSignedInfoAdvanceWithoutSigning(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	require.Nil(info.AdvanceWithoutSigning(10, 1, stepPrevote))
+	assert.Equal(1, info.LastRound)
+	assert.Nil(info.LastSignBytes)
+	assert.True(info.LastSignature.Empty())
+	require.Nil(info.AdvanceWithoutSigning(10, 1, stepPrevote)) // idempotent
+
+	// it was persisted
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.True(loaded.Unsigned)
+
+	// signing there is fresh, unlike after EnsureAtLeast
+	sameHRS, err := loaded.Verify(10, 1, stepPrevote)
+	assert.Nil(err)
+	assert.False(sameHRS)
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(loaded.SignVote(signer, "mychainid", vote))
+	assert.False(loaded.Unsigned)
+	assert.True(loaded.HasSignature(vote.Signature))
+
+	// can't go back, nor skip what was signed
+	assert.Equal(ErrAlreadySigned, loaded.AdvanceWithoutSigning(10, 1, stepPrevote))
+	assert.Equal(ErrRoundRegression, loaded.AdvanceWithoutSigning(10, 0, stepPrecommit))
+	assert.Error(loaded.AdvanceWithoutSigning(10, 1, stepMax+1))
+
+	_, err = loaded.EnsureAtLeast(10, 1, stepPrecommit)
+	require.Nil(err)
+	_, err = loaded.Verify(10, 1, stepPrecommit)
+	assert.Equal(ErrNoLastSignature, err)
+}
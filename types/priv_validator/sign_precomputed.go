@@ -0,0 +1,88 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrSignBytesHRSMismatch = errors.New("Sign bytes are not for the height/round/step")
+)
+
+// SignPrecomputed is SignVote or SignProposal for the sign bytes of a vote or
+// proposal the caller already encoded, eg. in consensus, with the
+// CanonicalEncoder: they're checked and signed as they are, or the
+// LastSignature is reused, which reused tells.
+// The sign bytes must be canonical, and the height/round/step they carry must
+// be the given one, otherwise it returns ErrNonCanonicalSignBytes or
+// ErrSignBytesHRSMismatch, and nothing is signed.
+//
+// As the timestamp of the sign bytes can't be set back to the one signed,
+// the LastSignature is only reused for exactly the LastSignBytes: if only the
+// timestamp differs, it returns ErrTimestampRewrite, as SetStrictTimestamps
+// would. The signature returned always covers signBytes.
+func (info *LastSignedInfo) SignPrecomputed(signer types.Signer, signBytes []byte, height int64, round int, step int8) (sig crypto.Signature, reused bool, err error) {
+	req, err := info.precomputedRequest(signBytes, height, round, step)
+	if err != nil {
+		info.reject(height, round, step, err)
+		return crypto.Signature{}, false, err
+	}
+	req.setSignature = func(signature crypto.Signature) { sig = signature }
+	reason, err := info.sign(context.Background(), signer, req)
+	if err != nil {
+		return crypto.Signature{}, false, err
+	}
+	return sig, reason == Reused, nil
+}
+
+// precomputedRequest is the signRequest of SignVote or SignProposal for
+// signBytes, once checked against the height/round/step.
+func (info *LastSignedInfo) precomputedRequest(signBytes []byte, height int64, round int, step int8) (signRequest, error) {
+	encoder := info.canonicalEncoder()
+	if err := checkEncoded(encoder, signBytes); err != nil {
+		return signRequest{}, err
+	}
+	req := signRequest{
+		span:             "LastSignedInfo.SignPrecomputed",
+		height:           height,
+		round:            round,
+		step:             step,
+		signBytes:        signBytes,
+		strictTimestamps: true,
+		setTimestamp:     func(time.Time) {},
+		encode:           func() []byte { return signBytes },
+	}
+
+	if chainID, vote, err := encoder.ParseVote(signBytes); err == nil {
+		var voteStep int8
+		switch vote.Type {
+		case types.VoteTypePrevote:
+			voteStep = stepPrevote
+		case types.VoteTypePrecommit:
+			voteStep = stepPrecommit
+		}
+		if vote.Height != height || vote.Round != round || voteStep != step {
+			return signRequest{}, ErrSignBytesHRSMismatch
+		}
+		req.chainID = chainID
+		req.onlyDifferByTimestamp, req.normalize = info.comparisons(checkVotesOnlyDifferByTimestamp, normalizeVotes)
+		req.allow = func() error { return info.allow(chainID, vote) }
+		return req, nil
+	}
+
+	chainID, proposal, err := encoder.ParseProposal(signBytes)
+	if err != nil {
+		return signRequest{}, ErrNonCanonicalSignBytes
+	}
+	if proposal.Height != height || proposal.Round != round || step != stepPropose {
+		return signRequest{}, ErrSignBytesHRSMismatch
+	}
+	req.chainID = chainID
+	req.onlyDifferByTimestamp, req.normalize = info.comparisons(checkProposalsOnlyDifferByTimestamp, normalizeProposals)
+	req.allow = func() error { return info.allowProposal(chainID, proposal) }
+	return req, nil
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestSignPrecomputed(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, pubKey := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	signBytes := types.SignBytes("mychainid", vote)
+	sig, reused, err := info.SignPrecomputed(signer, signBytes, 10, 0, stepPrevote)
+	require.Nil(err)
+	assert.False(reused)
+	assert.True(pubKey.VerifyBytes(signBytes, sig))
+	assert.Equal(signBytes, []byte(info.LastSignBytes))
+
+	// the same sign bytes reuse the signature
+	again, reused, err := info.SignPrecomputed(signer, signBytes, 10, 0, stepPrevote)
+	require.Nil(err)
+	assert.True(reused)
+	assert.Equal(sig, again)
+
+	// the timestamp can't be set back
+	later := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	_, _, err = info.SignPrecomputed(signer, types.SignBytes("mychainid", later), 10, 0, stepPrevote)
+	assert.Equal(ErrTimestampRewrite, err)
+
+	// double-sign checks apply
+	_, _, err = info.SignPrecomputed(signer, types.SignBytes("mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)), 9, 0, stepPrevote)
+	assert.Equal(ErrHeightRegression, err)
+	info.SetConflictStrategy(ConflictError)
+	_, _, err = info.SignPrecomputed(signer, types.SignBytes("mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)), 10, 0, stepPrevote)
+	assert.Equal(ErrConflictingData, err)
+
+	proposal := &types.Proposal{Height: 11, POLRound: -1}
+	proposalBytes := types.SignBytes("mychainid", proposal)
+	sig, reused, err = info.SignPrecomputed(signer, proposalBytes, 11, 0, stepPropose)
+	require.Nil(err)
+	assert.False(reused)
+	assert.True(pubKey.VerifyBytes(proposalBytes, sig))
+}
+
+func TestSignPrecomputedChecksSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	var rejected []RejectEvent
+	info.SetOnReject(func(event RejectEvent) { rejected = append(rejected, event) })
+
+	signBytes := types.SignBytes("mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1))
+	cases := []struct {
+		height int64
+		round  int
+		step   int8
+	}{
+		{11, 0, stepPrecommit},
+		{10, 1, stepPrecommit},
+		{10, 0, stepPrevote},
+		{10, 0, stepPropose},
+	}
+	for _, c := range cases {
+		_, _, err := info.SignPrecomputed(signer, signBytes, c.height, c.round, c.step)
+		assert.Equal(ErrSignBytesHRSMismatch, err, "%v/%v/%v", c.height, c.round, c.step)
+	}
+	proposalBytes := types.SignBytes("mychainid", &types.Proposal{Height: 10, POLRound: -1})
+	_, _, err := info.SignPrecomputed(signer, proposalBytes, 10, 0, stepPrevote)
+	assert.Equal(ErrSignBytesHRSMismatch, err)
+
+	_, _, err = info.SignPrecomputed(signer, append([]byte(" "), signBytes...), 10, 0, stepPrecommit)
+	assert.Equal(ErrNonCanonicalSignBytes, err)
+	_, _, err = info.SignPrecomputed(signer, []byte("not sign bytes"), 10, 0, stepPrecommit)
+	assert.Equal(ErrNonCanonicalSignBytes, err)
+
+	assert.Len(rejected, len(cases)+3)
+	assert.EqualValues(0, info.LastHeight)
+	assert.Nil(info.LastSignBytes)
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"context"
+	"time"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// SignProposal is SignVote for proposals: it checks the proposal is ahead of
+// the latest HRS, signs it and records it, or reuses the LastSignature if only
+// the timestamp changed, setting the timestamp back to the one signed.
+//
+// Proposals and votes share the same high-water mark, at the propose step:
+// a proposal can be signed before the votes of its height/round, but not after
+// them, as that's a step regression.
+// The SignPolicy is consulted with AllowProposal.
+func (info *LastSignedInfo) SignProposal(signer types.Signer, chainID string, proposal *types.Proposal) error {
+	_, err := info.signProposal(context.Background(), signer, chainID, proposal)
+	return err
+}
+
+func (info *LastSignedInfo) signProposal(ctx context.Context, signer types.Signer, chainID string, proposal *types.Proposal) (SignReason, error) {
+	onlyDifferByTimestamp, normalize := info.comparisons(checkProposalsOnlyDifferByTimestamp, normalizeProposals)
+	return info.sign(ctx, signer, signRequest{
+		span:                  "LastSignedInfo.SignProposal",
+		chainID:               chainID,
+		height:                proposal.Height,
+		round:                 proposal.Round,
+		step:                  stepPropose,
+		signBytes:             info.canonicalEncoder().ProposalBytes(chainID, proposal),
+		onlyDifferByTimestamp: onlyDifferByTimestamp,
+		normalize:             normalize,
+		allow:                 func() error { return info.allowProposal(chainID, proposal) },
+		setSignature:          func(sig crypto.Signature) { proposal.Signature = sig },
+		setTimestamp:          func(timestamp time.Time) { proposal.Timestamp = timestamp },
+		encode:                func() []byte { return info.canonicalEncoder().ProposalBytes(chainID, proposal) },
+	})
+}
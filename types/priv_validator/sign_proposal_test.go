@@ -0,0 +1,98 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func newProposal(height int64, round int, partsHash []byte) *types.Proposal {
+	return &types.Proposal{
+		Height:           height,
+		Round:            round,
+		BlockPartsHeader: types.PartSetHeader{Total: 1, Hash: partsHash},
+		POLRound:         -1,
+		Timestamp:        time.Now().UTC(),
+	}
+}
+
+func TestSignProposal(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, pub := newTestSigner()
+
+	proposal := newProposal(10, 0, []byte("parts1"))
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", proposal), proposal.Signature))
+	assert.EqualValues(10, info.LastHeight)
+	assert.Equal(stepPropose, info.LastStep)
+
+	// only the timestamp differs: the signature is reused, with the signed timestamp
+	later := *proposal
+	later.Timestamp = proposal.Timestamp.Add(time.Minute)
+	later.Signature = crypto.Signature{}
+	require.Nil(info.SignProposal(signer, "mychainid", &later))
+	assert.Equal(proposal.Signature, later.Signature)
+	assert.True(pub.VerifyBytes(types.SignBytes("mychainid", &later), later.Signature))
+
+	// different content
+	assert.Equal(ErrConflictingData, info.SignProposal(signer, "mychainid", newProposal(10, 0, []byte("parts2"))))
+	polRound := *proposal
+	polRound.POLRound = 0
+	assert.Equal(ErrConflictingData, info.SignProposal(signer, "mychainid", &polRound))
+
+	// regressions
+	assert.Equal(ErrHeightRegression, info.SignProposal(signer, "mychainid", newProposal(9, 0, []byte("parts1"))))
+}
+
+func TestSignProposalInterleavedWithVotes(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, _ := newTestSigner()
+
+	// propose, then vote on it, at the same height and round
+	require.Nil(info.SignProposal(signer, "mychainid", newProposal(10, 0, []byte("parts1"))))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// proposing again at that round comes after the votes
+	assert.Equal(ErrStepRegression, info.SignProposal(signer, "mychainid", newProposal(10, 0, []byte("parts1"))))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+
+	// the next round starts with a proposal
+	require.Nil(info.SignProposal(signer, "mychainid", newProposal(10, 1, []byte("parts2"))))
+	assert.Equal(ErrRoundRegression, info.SignProposal(signer, "mychainid", newProposal(10, 0, []byte("parts2"))))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2)))
+
+	// a vote at the round of the last proposal can't go back before it
+	assert.Equal(ErrRoundRegression, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+
+	// we're not the proposer of every round: voting without proposing is fine,
+	// but then it's too late to propose
+	require.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrStepRegression, info.SignProposal(signer, "mychainid", newProposal(11, 0, []byte("parts1"))))
+}
+
+func TestSignProposalBackdatedConflict(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetHistorySize(10)
+	signer, _ := newTestSigner()
+
+	proposal := newProposal(10, 0, []byte("parts1"))
+	require.Nil(info.SignProposal(signer, "mychainid", proposal))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	assert.Equal(ErrBackdatedConflict, info.SignProposal(signer, "mychainid", newProposal(10, 0, []byte("parts2"))))
+	later := *proposal
+	later.Timestamp = proposal.Timestamp.Add(time.Minute)
+	assert.Equal(ErrStepRegression, info.SignProposal(signer, "mychainid", &later))
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// SignReason describes why a fresh signature was needed when signing.
+// Its zero value is NotSigned.
+type SignReason int
+
+const (
+	// NotSigned means an error other than a conflict was returned, so
+	// nothing was signed, nor the LastSignature reused.
+	NotSigned SignReason = iota
+	// Reused means no fresh signature was needed: the LastSignature was used.
+	Reused
+	// FirstSign means nothing was signed before.
+	FirstSign
+	// HeightAdvanced means the height is higher than the last signed one.
+	HeightAdvanced
+	// RoundAdvanced means the round is higher than the last signed one, at the same height.
+	RoundAdvanced
+	// StepAdvanced means the step is higher than the last signed one, at the same height and round.
+	StepAdvanced
+	// ContentDiffers means the HRS is the same as the last signed one but the content is not.
+	// It's returned together with the conflict error; nothing is signed.
+	ContentDiffers
+	// NonDeterministicKey means the HRS is the same and only the timestamp differs,
+	// but the LastSignature was made by a key whose signatures are not deterministic,
+	// so the new bytes are signed instead of reusing it.
+	// Since only the timestamp differs, this can't produce conflicting votes.
+	NonDeterministicKey
+	// ReuseDisabled means the HRS and the sign bytes are the same, so the
+	// LastSignature could be reused, but reuse is disabled, so they are signed again.
+	// See SetDisableSignatureReuse.
+	ReuseDisabled
+	// SignatureMissing means the HRS and the sign bytes are the same, but the
+	// LastSignature is missing, so they are signed again.
+	// See SetMissingSignatureStrategy.
+	SignatureMissing
+)
+
+// String returns a string representation of the SignReason.
+func (reason SignReason) String() string {
+	switch reason {
+	case NotSigned:
+		return "NotSigned"
+	case Reused:
+		return "Reused"
+	case FirstSign:
+		return "FirstSign"
+	case HeightAdvanced:
+		return "HeightAdvanced"
+	case RoundAdvanced:
+		return "RoundAdvanced"
+	case StepAdvanced:
+		return "StepAdvanced"
+	case ContentDiffers:
+		return "ContentDiffers"
+	case NonDeterministicKey:
+		return "NonDeterministicKey"
+	case ReuseDisabled:
+		return "ReuseDisabled"
+	case SignatureMissing:
+		return "SignatureMissing"
+	default:
+		return "Unknown"
+	}
+}
+
+// signReason returns why signing at the given HRS needs a fresh signature,
+// assuming it passed Verify and is not the same HRS.
+func (info *LastSignedInfo) signReason(height int64, round int, step int8) SignReason {
+	switch {
+	case info.LastHeight == 0 && info.LastStep == stepNone && !info.hasSignBytes():
+		return FirstSign
+	case height > info.LastHeight:
+		return HeightAdvanced
+	case round > info.LastRound:
+		return RoundAdvanced
+	default:
+		return StepAdvanced
+	}
+}
+
+// isDeterministic returns true if signing the same bytes with the key
+// that made sig always results in the same signature.
+func isDeterministic(sig Signature) bool {
+	switch sig.Unwrap().(type) {
+	case crypto.SignatureEd25519:
+		return true
+	default:
+		return false
+	}
+}
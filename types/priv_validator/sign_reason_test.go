@@ -0,0 +1,114 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestSignVoteReasons(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+
+	cases := []struct {
+		vote   *types.Vote
+		reason SignReason
+	}{
+		{newVote(10, 1, types.VoteTypePrevote, blockID1), FirstSign},
+		{newVote(10, 1, types.VoteTypePrecommit, blockID1), StepAdvanced},
+		{newVote(10, 2, types.VoteTypePrevote, blockID1), RoundAdvanced},
+		{newVote(11, 0, types.VoteTypePrevote, blockID1), HeightAdvanced},
+	}
+	for i, c := range cases {
+		reason, err := info.SignVoteWithReason(signer, "mychainid", c.vote)
+		require.Nil(err, "case %d", i)
+		assert.Equal(c.reason, reason, "case %d: got %v", i, reason)
+	}
+
+	// same vote, or only the timestamp differs
+	vote := cases[len(cases)-1].vote
+	reason, err := info.SignVoteWithReason(signer, "mychainid", vote)
+	assert.Nil(err)
+	assert.Equal(Reused, reason)
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+	reason, err = info.SignVoteWithReason(signer, "mychainid", vote)
+	assert.Nil(err)
+	assert.Equal(Reused, reason)
+
+	// conflicting data
+	reason, err = info.SignVoteWithReason(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID2))
+	assert.Error(err)
+	assert.Equal(ContentDiffers, reason)
+}
+
+func TestSignVoteReasonNonDeterministicKey(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signer := types.NewDefaultSigner(crypto.GenPrivKeySecp256k1().Wrap())
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	reason, err := info.SignVoteWithReason(signer, "mychainid", vote)
+	require.Nil(err)
+	assert.Equal(FirstSign, reason)
+
+	// the exact same bytes can still reuse the signature
+	reason, err = info.SignVoteWithReason(signer, "mychainid", vote)
+	require.Nil(err)
+	assert.Equal(Reused, reason)
+
+	// but a new timestamp is signed again
+	vote.Timestamp = vote.Timestamp.Add(time.Second)
+	reason, err = info.SignVoteWithReason(signer, "mychainid", vote)
+	require.Nil(err)
+	assert.Equal(NonDeterministicKey, reason)
+	assert.Equal(types.SignBytes("mychainid", vote), []byte(info.LastSignBytes))
+	assert.True(info.LastSignature.Equals(vote.Signature))
+}
+
+func TestSignReasonNotSigned(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, _ := newTestSigner()
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+
+	// errors are never mistaken for a reused signature
+	reason, err := info.SignVoteWithReason(signer, "mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1))
+	assert.Equal(ErrHeightRegression, err)
+	assert.Equal(NotSigned, reason)
+	reason, err = info.SignVoteWithReason(signer, "", newVote(11, 0, types.VoteTypePrevote, blockID1))
+	assert.Equal(ErrEmptyChainID, err)
+	assert.Equal(NotSigned, reason)
+	info.SetSignPolicy(freezePolicy{0})
+	reason, err = info.SignVoteWithReason(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1))
+	assert.Equal(ErrPolicyDenied, err)
+	assert.Equal(NotSigned, reason)
+	info.SetSignPolicy(nil)
+
+	// but conflicts are told apart
+	reason, err = info.SignVoteWithReason(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2))
+	assert.Equal(ErrConflictingData, err)
+	assert.Equal(ContentDiffers, reason)
+}
+
+func TestSignReasonString(t *testing.T) {
+	assert.Equal(t, "ContentDiffers", ContentDiffers.String())
+	assert.Equal(t, "NotSigned", NotSigned.String())
+	assert.Equal(t, "SignatureMissing", SignatureMissing.String())
+	assert.Equal(t, "Unknown", SignReason(100).String())
+}
+
+func TestSignReasonZeroValue(t *testing.T) {
+	var reason SignReason
+	assert.Equal(t, NotSigned, reason)
+	assert.NotEqual(t, Reused, reason)
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// Signature is the representation of LastSignature, both in memory and on disk.
+// It wraps the signature type of the crypto library so that swapping
+// go-crypto for the newer tmcrypto doesn't change the LastSignedInfo
+// field or break files written by either version.
+//
+// It is always written in the go-crypto format ({"type":"ed25519","data":"<hex>"}),
+// and can be read from either the go-crypto or the tmcrypto/amino format
+// ({"type":"tendermint/SignatureEd25519","value":"<base64>"}).
+type Signature struct {
+	crypto.Signature
+}
+
+// SignatureFromCrypto converts a signature from the crypto library.
+func SignatureFromCrypto(sig crypto.Signature) Signature {
+	return Signature{sig}
+}
+
+// Crypto converts the signature back to the crypto library type.
+func (sig Signature) Crypto() crypto.Signature {
+	return sig.Signature
+}
+
+// amino registered names for the signature types
+const (
+	aminoPrefix        = "tendermint/"
+	aminoNameEd25519   = "tendermint/SignatureEd25519"
+	aminoNameSecp256k1 = "tendermint/SignatureSecp256k1"
+)
+
+const signatureEd25519Size = 64
+
+type aminoJSONSignature struct {
+	Type  string `json:"type"`
+	Value []byte `json:"value"` // base64
+}
+
+// MarshalJSON writes the signature in the go-crypto format.
+func (sig Signature) MarshalJSON() ([]byte, error) {
+	if sig.Empty() {
+		return []byte("null"), nil
+	}
+	return sig.Signature.MarshalJSON()
+}
+
+// UnmarshalJSON reads a signature in either the go-crypto or the tmcrypto format.
+func (sig *Signature) UnmarshalJSON(bz []byte) error {
+	if bytes.Equal(bytes.TrimSpace(bz), []byte("null")) {
+		sig.Signature = crypto.Signature{}
+		return nil
+	}
+
+	var amino aminoJSONSignature
+	if err := json.Unmarshal(bz, &amino); err == nil && strings.HasPrefix(amino.Type, aminoPrefix) {
+		return sig.fromAmino(amino)
+	}
+	return sig.Signature.UnmarshalJSON(bz)
+}
+
+// MarshalAminoJSON writes the signature in the tmcrypto/amino format,
+// for tooling that migrates state files to the new crypto library.
+func (sig Signature) MarshalAminoJSON() ([]byte, error) {
+	if sig.Empty() {
+		return []byte("null"), nil
+	}
+	switch inner := sig.Unwrap().(type) {
+	case crypto.SignatureEd25519:
+		return json.Marshal(aminoJSONSignature{aminoNameEd25519, inner[:]})
+	case crypto.SignatureSecp256k1:
+		return json.Marshal(aminoJSONSignature{aminoNameSecp256k1, []byte(inner)})
+	default:
+		return nil, fmt.Errorf("Unknown signature type %T", inner)
+	}
+}
+
+func (sig *Signature) fromAmino(amino aminoJSONSignature) error {
+	switch amino.Type {
+	case aminoNameEd25519:
+		if len(amino.Value) != signatureEd25519Size {
+			return fmt.Errorf("Invalid ed25519 signature length %v", len(amino.Value))
+		}
+		var inner crypto.SignatureEd25519
+		copy(inner[:], amino.Value)
+		sig.Signature = inner.Wrap()
+	case aminoNameSecp256k1:
+		sig.Signature = crypto.SignatureSecp256k1(amino.Value).Wrap()
+	default:
+		return fmt.Errorf("Unknown signature type %v", amino.Type)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+func TestSignatureLegacyRoundTrip(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	privKey := crypto.GenPrivKeyEd25519().Wrap()
+	sig := SignatureFromCrypto(privKey.Sign([]byte("msg")))
+
+	bz, err := json.Marshal(sig)
+	require.Nil(err)
+	expected := fmt.Sprintf(`{"type":"ed25519","data":"%X"}`, sig.Bytes()[1:])
+	assert.JSONEq(expected, string(bz))
+
+	var decoded Signature
+	require.Nil(json.Unmarshal(bz, &decoded))
+	assert.True(sig.Equals(decoded.Crypto()))
+}
+
+func TestSignatureAminoRoundTrip(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	privKey := crypto.GenPrivKeyEd25519().Wrap()
+	sig := SignatureFromCrypto(privKey.Sign([]byte("msg")))
+
+	bz, err := sig.MarshalAminoJSON()
+	require.Nil(err)
+	expected := fmt.Sprintf(`{"type":"tendermint/SignatureEd25519","value":"%s"}`,
+		base64.StdEncoding.EncodeToString(sig.Bytes()[1:]))
+	assert.JSONEq(expected, string(bz))
+
+	var decoded Signature
+	require.Nil(json.Unmarshal(bz, &decoded))
+	assert.True(sig.Equals(decoded.Crypto()))
+
+	// amino in, legacy out
+	legacy, err := json.Marshal(decoded)
+	require.Nil(err)
+	assert.True(strings.Contains(string(legacy), `"type":"ed25519"`))
+}
+
+func TestSignatureEmpty(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	bz, err := json.Marshal(Signature{})
+	require.Nil(err)
+	assert.Equal("null", string(bz))
+
+	bz, err = Signature{}.MarshalAminoJSON()
+	require.Nil(err)
+	assert.Equal("null", string(bz))
+
+	var decoded Signature
+	require.Nil(json.Unmarshal([]byte("null"), &decoded))
+	assert.True(decoded.Empty())
+}
+
+func TestSignatureAminoInvalid(t *testing.T) {
+	cases := []string{
+		`{"type":"tendermint/SignatureEd25519","value":"AQID"}`,   // wrong length
+		`{"type":"tendermint/SignatureUnknown","value":"AQID"}`,   // unknown type
+		`{"type":"tendermint/SignatureEd25519","value":"!!!!!!"}`, // bad base64
+	}
+	for i, c := range cases {
+		var sig Signature
+		assert.Error(t, json.Unmarshal([]byte(c), &sig), "case %d", i)
+	}
+}
+
+func TestLoadLastSignedInfoAminoSignature(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	privKey := crypto.GenPrivKeyEd25519().Wrap()
+	sig := SignatureFromCrypto(privKey.Sign([]byte("signbytes")))
+	aminoSig, err := sig.MarshalAminoJSON()
+	require.Nil(err)
+
+	serialized := fmt.Sprintf(`{"last_height":10,"last_round":1,"last_step":2,"last_signature":%s,"last_signbytes":"%X"}`,
+		aminoSig, []byte("signbytes"))
+	info := NewLastSignedInfo()
+	require.Nil(json.Unmarshal([]byte(serialized), info))
+	assert.True(sig.Equals(info.LastSignature.Crypto()))
+
+	sameHRS, err := info.Verify(10, 1, stepPrevote)
+	assert.Nil(err)
+	assert.True(sameHRS)
+}
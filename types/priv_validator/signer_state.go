@@ -0,0 +1,133 @@
+package types
+
+import (
+	"fmt"
+
+	dbm "github.com/tendermint/tmlibs/db"
+	"github.com/tendermint/tmlibs/log"
+)
+
+// SignerState is where a LastSignedInfo is persisted.
+// Save must not return before the LastSignedInfo is durably stored.
+type SignerState interface {
+	Load() (*LastSignedInfo, error)
+	Save(info *LastSignedInfo) error
+}
+
+// SignerStateReader is implemented by SignerStates that can be read without
+// changing anything, eg. without recovering an interrupted write, so they can
+// be read without holding the Locker, as StartScrubber does.
+type SignerStateReader interface {
+	Read() (*LastSignedInfo, error)
+}
+
+// SetSignerState makes Set, Reset, etc. persist to the SignerState instead of
+// the filePath. Passing nil goes back to the filePath.
+func (info *LastSignedInfo) SetSignerState(store SignerState) {
+	info.store = store
+}
+
+//-------------------------------------
+
+// SignInfoFile is the default SignerState, a file, in JSON by default.
+type SignInfoFile struct {
+	filePath string
+	codec    StateCodec
+	logger   log.Logger
+}
+
+// NewSignInfoFile returns a SignInfoFile for the filePath.
+func NewSignInfoFile(filePath string) *SignInfoFile {
+	return &SignInfoFile{filePath, JSONCodec{}, log.NewNopLogger()}
+}
+
+// SetCodec sets the format of the file.
+func (sif *SignInfoFile) SetCodec(codec StateCodec) {
+	sif.codec = codec
+}
+
+// SetLogger sets the logger Load logs to, eg. the recovery from a crash in
+// the middle of saving, or a file accessible by anyone but its owner.
+// The LastSignedInfo loaded logs there too.
+func (sif *SignInfoFile) SetLogger(logger log.Logger) {
+	sif.logger = logger
+}
+
+// Load implements SignerState. See LoadLastSignedInfo.
+// The LastSignedInfo persists to the file.
+func (sif *SignInfoFile) Load() (*LastSignedInfo, error) {
+	info, err := loadLastSignedInfo(sif.filePath, sif.codec, sif.logger)
+	if err != nil {
+		return nil, err
+	}
+	info.SetSignerState(sif)
+	return info, nil
+}
+
+// Read implements SignerStateReader. Unlike Load, a temp file left by an
+// interrupted write is neither completed nor deleted.
+func (sif *SignInfoFile) Read() (*LastSignedInfo, error) {
+	return readLastSignedInfo(sif.filePath, sif.codec)
+}
+
+// Save implements SignerState.
+func (sif *SignInfoFile) Save(info *LastSignedInfo) error {
+	return info.saveAs(sif.filePath, sif.codec)
+}
+
+//-------------------------------------
+
+var signInfoKey = []byte("signInfoKey")
+
+// SignInfoDB is a SignerState stored in a database under a fixed key,
+// in JSON by default, eg. to keep it along with the consensus state and
+// back them up together.
+type SignInfoDB struct {
+	db    dbm.DB
+	codec StateCodec
+}
+
+// NewSignInfoDB returns a SignInfoDB for the db.
+func NewSignInfoDB(db dbm.DB) *SignInfoDB {
+	return &SignInfoDB{db, JSONCodec{}}
+}
+
+// SetCodec sets the format of the value in the db.
+func (sidb *SignInfoDB) SetCodec(codec StateCodec) {
+	sidb.codec = codec
+}
+
+// Load implements SignerState.
+// If nothing was saved yet, it returns a LastSignedInfo in its initial state.
+// The LastSignedInfo persists to the db.
+func (sidb *SignInfoDB) Load() (*LastSignedInfo, error) {
+	info, err := sidb.Read()
+	if err != nil {
+		return nil, err
+	}
+	info.SetSignerState(sidb)
+	return info, nil
+}
+
+// Read implements SignerStateReader.
+func (sidb *SignInfoDB) Read() (*LastSignedInfo, error) {
+	buf := sidb.db.Get(signInfoKey)
+	if len(buf) == 0 {
+		return NewLastSignedInfo(), nil
+	}
+	info, err := decodeBytes(sidb.codec, buf)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading LastSignedInfo from db: %v", err)
+	}
+	return info, nil
+}
+
+// Save implements SignerState. The write is synchronous.
+func (sidb *SignInfoDB) Save(info *LastSignedInfo) error {
+	infoBytes, err := encodeBytes(sidb.codec, info)
+	if err != nil {
+		return err
+	}
+	sidb.db.SetSync(signInfoKey, infoBytes)
+	return nil
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func TestSignerStates(t *testing.T) {
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	require.Nil(t, NewLastSignedInfo().SaveAs(tempFilePath))
+
+	stores := map[string]SignerState{
+		"file": NewSignInfoFile(tempFilePath),
+		"db":   NewSignInfoDB(dbm.NewMemDB()),
+	}
+	for name, store := range stores {
+		assert, require := assert.New(t), require.New(t)
+
+		info, err := store.Load()
+		require.Nil(err, name)
+		assert.Equal(int64(0), info.LastHeight, name)
+		info.SetSignerState(store)
+
+		signer, _ := newTestSigner()
+		vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+		require.Nil(info.SignVote(signer, "mychainid", vote), name)
+
+		loaded, err := store.Load()
+		require.Nil(err, name)
+		assert.Equal(int64(10), loaded.LastHeight, name)
+		assert.Equal(info.LastSignBytes, loaded.LastSignBytes, name)
+		assert.True(loaded.HasSignature(vote.Signature), name)
+	}
+}
+
+func TestSignInfoDB(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	db := dbm.NewMemDB()
+	info, err := NewSignInfoDB(db).Load()
+	require.Nil(err)
+
+	// the loaded info persists to the db
+	require.Nil(info.SetFloorHeight(5))
+	assert.NotEmpty(db.Get(signInfoKey))
+	loaded, err := NewSignInfoDB(db).Load()
+	require.Nil(err)
+	assert.Equal(int64(5), loaded.FloorHeight)
+
+	db.SetSync(signInfoKey, []byte("garbage"))
+	_, err = NewSignInfoDB(db).Load()
+	assert.Error(err)
+}
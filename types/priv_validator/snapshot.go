@@ -0,0 +1,114 @@
+package types
+
+import (
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// Snapshot returns a copy of the persisted fields (the height/round/step,
+// signature, sign bytes, ...), eg. to checkpoint the state before replaying
+// a consensus WAL in tests.
+// The copy shares no memory with the LastSignedInfo, so later changes to
+// either don't affect the other. Unlike Reset, this is meant to be undone
+// precisely with Restore.
+func (info *LastSignedInfo) Snapshot() LastSignedInfo {
+	return LastSignedInfo{
+		LastHeight:    info.LastHeight,
+		LastRound:     info.LastRound,
+		LastStep:      info.LastStep,
+		LastSignature: copySignature(info.LastSignature),
+		LastSignBytes: copyBytes(info.LastSignBytes),
+		PendingSign:   copyPendingSign(info.PendingSign),
+
+		LastSignedByVersion: info.LastSignedByVersion,
+		CommittedRanges:     copyHeightRanges(info.CommittedRanges),
+		FloorHeight:         info.FloorHeight,
+		Unsigned:            info.Unsigned,
+		Seq:                 info.Seq,
+		LastExtension:       copySignedExtension(info.LastExtension),
+		ChainID:             info.ChainID,
+		SignHash:            copyBytes(info.SignHash),
+		LastSignBytesHash:   copyBytes(info.LastSignBytesHash),
+		KeyRotation:         copyKeyRotation(info.KeyRotation),
+		TombstoneInfo:       copyTombstone(info.TombstoneInfo),
+		UnacknowledgedReset: info.UnacknowledgedReset,
+	}
+}
+
+// Restore sets the persisted fields to those of the snapshot,
+// and persists them if a filePath is set.
+// NOTE: Unsafe! Like Reset, it can move the state backwards,
+// except the Seq and the SignHash that goes with it, which are kept if the
+// Seq is ahead of the snapshot's, and the TombstoneInfo, which is kept if the
+// snapshot has none.
+func (info *LastSignedInfo) Restore(snapshot LastSignedInfo) error {
+	info.restore(snapshot)
+	return info.persist()
+}
+
+// restore is Restore without persisting.
+func (info *LastSignedInfo) restore(snapshot LastSignedInfo) {
+	info.LastHeight = snapshot.LastHeight
+	info.LastRound = snapshot.LastRound
+	info.LastStep = snapshot.LastStep
+	info.LastSignature = copySignature(snapshot.LastSignature)
+	info.LastSignBytes = copyBytes(snapshot.LastSignBytes)
+	info.LastSignBytesHash = copyBytes(snapshot.LastSignBytesHash)
+	info.PendingSign = copyPendingSign(snapshot.PendingSign)
+	info.LastSignedByVersion = snapshot.LastSignedByVersion
+	info.CommittedRanges = copyHeightRanges(snapshot.CommittedRanges)
+	info.FloorHeight = snapshot.FloorHeight
+	info.Unsigned = snapshot.Unsigned
+	info.LastExtension = copySignedExtension(snapshot.LastExtension)
+	info.ChainID = snapshot.ChainID
+	info.KeyRotation = copyKeyRotation(snapshot.KeyRotation)
+	info.UnacknowledgedReset = snapshot.UnacknowledgedReset
+	// the SignHash must stay the one of the Seq, see Fingerprint
+	if snapshot.Seq > info.Seq || (snapshot.Seq == info.Seq && info.SignHash == nil) {
+		info.Seq = snapshot.Seq
+		info.SignHash = copyBytes(snapshot.SignHash)
+	}
+	if snapshot.TombstoneInfo != nil {
+		info.TombstoneInfo = copyTombstone(snapshot.TombstoneInfo)
+	}
+}
+
+func copyBytes(bz []byte) []byte {
+	if bz == nil {
+		return nil
+	}
+	cpy := make([]byte, len(bz))
+	copy(cpy, bz)
+	return cpy
+}
+
+func copyPendingSign(pending *PendingSign) *PendingSign {
+	if pending == nil {
+		return nil
+	}
+	cpy := *pending
+	return &cpy
+}
+
+func copySignedExtension(extension *SignedExtension) *SignedExtension {
+	if extension == nil {
+		return nil
+	}
+	return &SignedExtension{copyBytes(extension.SignBytes), copySignature(extension.Signature)}
+}
+
+func copyHeightRanges(ranges []HeightRange) []HeightRange {
+	if ranges == nil {
+		return nil
+	}
+	return append([]HeightRange{}, ranges...)
+}
+
+func copySignature(sig Signature) Signature {
+	switch inner := sig.Unwrap().(type) {
+	case crypto.SignatureSecp256k1:
+		return SignatureFromCrypto(crypto.SignatureSecp256k1(copyBytes(inner)).Wrap())
+	default:
+		// the other signature types are arrays, which are copied by value
+		return sig
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), sig))
+
+	snapshot := info.Snapshot()
+
+	// the snapshot doesn't change with the info
+	info.LastSignBytes[0] = 'X'
+	require.Nil(info.Set(12, 0, stepPrecommit, []byte("other"), crypto.SignatureEd25519{2}.Wrap()))
+	assert.Equal(int64(10), snapshot.LastHeight)
+	assert.Equal([]byte("signbytes"), []byte(snapshot.LastSignBytes))
+
+	require.Nil(info.Restore(snapshot))
+	assert.Equal(int64(10), info.LastHeight)
+	assert.Equal(1, info.LastRound)
+	assert.Equal(stepPrevote, info.LastStep)
+	assert.True(info.LastSignature.Equals(sig))
+	assert.Equal([]byte("signbytes"), []byte(info.LastSignBytes))
+
+	// the info doesn't change with the snapshot
+	snapshot.LastSignBytes[0] = 'X'
+	assert.Equal([]byte("signbytes"), []byte(info.LastSignBytes))
+
+	// the restored state was persisted
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Equal(int64(10), loaded.LastHeight)
+	assert.Equal([]byte("signbytes"), []byte(loaded.LastSignBytes))
+}
+
+func TestRestoreOlderSnapshot(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	require.Nil(info.Set(10, 1, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	snapshot := info.Snapshot()
+	require.Nil(info.Set(11, 0, stepPrevote, []byte("other"), crypto.SignatureEd25519{2}.Wrap()))
+	require.Nil(info.Set(12, 0, stepPrevote, []byte("another"), crypto.SignatureEd25519{3}.Wrap()))
+	seq, signHash := info.Seq, info.SignHash
+
+	// the Seq doesn't go backwards, nor the SignHash that goes with it
+	require.Nil(info.Restore(snapshot))
+	assert.EqualValues(10, info.LastHeight)
+	assert.Equal(seq, info.Seq)
+	assert.Equal(signHash, info.SignHash)
+
+	// so it keeps matching a peer that recorded the same signatures
+	peer := NewLastSignedInfo()
+	require.Nil(peer.Set(10, 1, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	require.Nil(peer.Set(11, 0, stepPrevote, []byte("other"), crypto.SignatureEd25519{2}.Wrap()))
+	require.Nil(peer.Set(12, 0, stepPrevote, []byte("another"), crypto.SignatureEd25519{3}.Wrap()))
+	require.Nil(info.Set(13, 0, stepPrevote, []byte("next"), crypto.SignatureEd25519{4}.Wrap()))
+	require.Nil(peer.Set(13, 0, stepPrevote, []byte("next"), crypto.SignatureEd25519{4}.Wrap()))
+	assert.Equal(peer.Fingerprint(), info.Fingerprint())
+
+	// a snapshot ahead sets both
+	ahead := info.Snapshot()
+	fresh := NewLastSignedInfo()
+	require.Nil(fresh.Restore(ahead))
+	assert.Equal(ahead.Seq, fresh.Seq)
+	assert.Equal(ahead.SignHash, fresh.SignHash)
+	assert.Equal(info.Fingerprint(), fresh.Fingerprint())
+}
+
+func TestSnapshotCopiesSignature(t *testing.T) {
+	inner := crypto.SignatureSecp256k1{1, 2, 3}
+	info := NewLastSignedInfo()
+	require.Nil(t, info.Set(10, 1, stepPrevote, []byte("signbytes"), inner.Wrap()))
+
+	snapshot := info.Snapshot()
+	inner[0] = 9
+	assert.Equal(t, crypto.SignatureSecp256k1{1, 2, 3}, snapshot.LastSignature.Unwrap())
+}
@@ -0,0 +1,48 @@
+package types
+
+// StepOrder compares two steps, returning -1, 0 or 1 if a is lower than,
+// equal to or higher than b. It lets a fork whose consensus orders the steps
+// differently reuse the regression checks. It must be a total order of the
+// steps stepNone to stepMax:
+//
+//   - it returns 0 if and only if a == b;
+//   - compare(a, b) == -compare(b, a);
+//   - if a < b and b < c, then a < c;
+//   - stepNone is the lowest, as it's the initial state.
+//
+// Otherwise a step could be both ahead of and behind the last one,
+// and signed twice.
+type StepOrder func(a, b int8) int
+
+// StockStepOrder is the default StepOrder, by value: propose, prevote, precommit.
+func StockStepOrder(a, b int8) int {
+	return compareInt64(int64(a), int64(b))
+}
+
+// SetStepOrder sets the order of the steps at the same height and round, for
+// Verify, and so signing, EnsureAtLeast, AdvanceWithoutSigning and Recover.
+// Passing nil restores StockStepOrder.
+// What's done across LastSignedInfos (eg. ReconcileSources, mirrors) and
+// NextAllowed and the strict step mode keep the stock order.
+func (info *LastSignedInfo) SetStepOrder(order StepOrder) {
+	info.stepOrder = order
+}
+
+func (info *LastSignedInfo) compareSteps(a, b int8) int {
+	if info.stepOrder == nil {
+		return StockStepOrder(a, b)
+	}
+	return info.stepOrder(a, b)
+}
+
+// compareToLast is compareHRS of the HRS and the latest one, with the StepOrder.
+func (info *LastSignedInfo) compareToLast(height int64, round int, step int8) int {
+	switch {
+	case height != info.LastHeight:
+		return compareInt64(height, info.LastHeight)
+	case round != info.LastRound:
+		return compareInt64(int64(round), int64(info.LastRound))
+	default:
+		return info.compareSteps(step, info.LastStep)
+	}
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+// forkStepOrder has the precommit before the prevote
+func forkStepOrder(a, b int8) int {
+	rank := map[int8]int64{stepNone: 0, stepPropose: 1, stepPrecommit: 2, stepPrevote: 3}
+	return compareInt64(rank[a], rank[b])
+}
+
+func TestStepOrder(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetStepOrder(forkStepOrder)
+	signer, _ := newTestSigner()
+
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrStepRegression, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	assert.Equal(ErrStepRegression, info.SignProposal(signer, "mychainid", &types.Proposal{Height: 10, POLRound: -1}))
+
+	// heights and rounds are ordered as usual
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID1)))
+	advanced, err := info.EnsureAtLeast(10, 1, stepPrevote)
+	require.Nil(err)
+	assert.True(advanced)
+	advanced, err = info.EnsureAtLeast(10, 1, stepPrecommit)
+	require.Nil(err)
+	assert.False(advanced)
+
+	// the stock order is back with nil
+	info.SetStepOrder(nil)
+	_, err = info.Verify(10, 1, stepPrecommit)
+	assert.Nil(err)
+	assert.Equal(-1, StockStepOrder(stepPrevote, stepPrecommit))
+}
@@ -0,0 +1,31 @@
+package types
+
+// StorageSize returns the size in bytes of the LastSignedInfo as Save would
+// write it, in the format of its SignInfoFile, or in JSON, plus the size of
+// the sign history kept in memory (see SetHistorySize), as its HRS and sign
+// bytes. Nothing is written, eg. to watch for sign bytes growing out of bounds.
+func (info *LastSignedInfo) StorageSize() int {
+	var codec StateCodec = JSONCodec{}
+	if sif, ok := info.store.(*SignInfoFile); ok {
+		codec = sif.codec
+	}
+	counter := &countingWriter{}
+	if err := codec.Encode(counter, info); err != nil {
+		info.getLogger().Error("Cannot encode LastSignedInfo to size it", "error", err)
+	}
+	size := counter.n
+	for _, record := range info.history.records {
+		size += len(encodeHRS(record.height, record.round, record.step)) + len(record.signBytes)
+	}
+	return size
+}
+
+// countingWriter counts the bytes written to it, and drops them.
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestStorageSize(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	// the size of the file, without history
+	infoBytes, err := ioutil.ReadFile(filePath)
+	require.Nil(err)
+	assert.Equal(len(infoBytes), info.StorageSize())
+
+	// with history, its sign bytes are counted too
+	info.SetHistorySize(10)
+	size := info.StorageSize()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(size+13+len(info.LastSignBytes), info.StorageSize())
+
+	// in the format of the SignInfoFile
+	sif := NewSignInfoFile(filePath)
+	sif.SetCodec(GzipCodec{Inner: JSONCodec{}})
+	info.SetSignerState(sif)
+	require.Nil(info.Save())
+	infoBytes, err = ioutil.ReadFile(filePath)
+	require.Nil(err)
+	assert.Equal(len(infoBytes)+13+len(info.LastSignBytes), info.StorageSize())
+}
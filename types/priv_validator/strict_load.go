@@ -0,0 +1,96 @@
+package types
+
+import (
+	"errors"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+var (
+	ErrKeyTypeMismatch = errors.New("LastSignature type does not match the key type")
+)
+
+// LoadLastSignedInfoStrict is like LoadLastSignedInfo, but also checks that
+// the LastSignature was made by pubKey. This catches files mixed from
+// different key setups or otherwise corrupted, before anything is signed.
+//
+// If the signature type doesn't match the key type, it fails fast with
+// ErrKeyTypeMismatch; otherwise if the signature doesn't verify against
+// the LastSignBytes, it fails with ErrBadSignature. If it's bound to another
+// key by RotateKey, it fails with ErrKeyMismatch. See VerifyAgainstExpected.
+// The loaded info also verifies every signature on Set (see SetVerifyOnSet).
+//
+// It also fails with ErrInsecurePermissions if the file is accessible
+// by anyone but its owner, instead of only warning.
+func LoadLastSignedInfoStrict(filePath string, pubKey crypto.PubKey) (*LastSignedInfo, error) {
+	if err := checkPermissions(filePath); err != nil {
+		return nil, err
+	}
+	info, err := LoadLastSignedInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := info.VerifyAgainstExpected(pubKey); err != nil {
+		return nil, err
+	}
+	info.SetVerifyOnSet(pubKey)
+	return info, nil
+}
+
+// VerifyAgainstExpected checks the signatures recorded were made by expected,
+// the key of the validator, eg. at startup, to catch a state file from another
+// key setup. As far as it can tell, a signature by another key is an error:
+//
+//   - if it's bound to another key by RotateKey, or verifies every Set against
+//     another key (see SetVerifyOnSet), it returns ErrKeyMismatch;
+//   - if the type of the LastSignature or of the signature of the LastExtension
+//     isn't the one of expected, it returns ErrKeyTypeMismatch;
+//   - if either doesn't verify against its sign bytes, it returns ErrBadSignature.
+//
+// A signature of the same type by another key can't be told apart from a
+// corrupt one, so both are ErrBadSignature. In hash-only mode (see SetHashOnly),
+// the LastSignature can't be verified, only its type checked.
+func (info *LastSignedInfo) VerifyAgainstExpected(expected crypto.PubKey) error {
+	if bound := info.boundKey(); !bound.Empty() && !bound.Equals(expected) {
+		return ErrKeyMismatch
+	}
+	if !info.verifyOnSet.Empty() && !info.verifyOnSet.Equals(expected) {
+		return ErrKeyMismatch
+	}
+	if err := verifyExpected(expected, info.LastSignBytes, info.LastSignature); err != nil {
+		return err
+	}
+	if info.LastExtension != nil {
+		return verifyExpected(expected, info.LastExtension.SignBytes, info.LastExtension.Signature)
+	}
+	return nil
+}
+
+// returns an error unless sig, if any, is of the type of expected and verifies
+// against signBytes, if known
+func verifyExpected(expected crypto.PubKey, signBytes []byte, sig Signature) error {
+	if sig.Empty() {
+		return nil
+	}
+	if !sameKeyType(expected, sig.Crypto()) {
+		return ErrKeyTypeMismatch
+	}
+	if signBytes != nil && !expected.VerifyBytes(signBytes, sig.Crypto()) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// returns true if sig is of the type made by pubKey
+func sameKeyType(pubKey crypto.PubKey, sig crypto.Signature) bool {
+	switch pubKey.Unwrap().(type) {
+	case crypto.PubKeyEd25519:
+		_, ok := sig.Unwrap().(crypto.SignatureEd25519)
+		return ok
+	case crypto.PubKeySecp256k1:
+		_, ok := sig.Unwrap().(crypto.SignatureSecp256k1)
+		return ok
+	default:
+		return false
+	}
+}
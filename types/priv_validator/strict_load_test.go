@@ -0,0 +1,70 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestLoadLastSignedInfoStrict(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	signer, pubKey := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+	loaded, err := LoadLastSignedInfoStrict(tempFilePath, pubKey)
+	require.Nil(err)
+	assert.Equal(int64(10), loaded.LastHeight)
+	assert.Equal(ErrBadSignature, loaded.Set(11, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+
+	// another ed25519 key
+	_, otherPubKey := newTestSigner()
+	_, err = LoadLastSignedInfoStrict(tempFilePath, otherPubKey)
+	assert.Equal(ErrBadSignature, err)
+
+	// a secp256k1 key
+	secpPubKey := crypto.GenPrivKeySecp256k1().PubKey()
+	_, err = LoadLastSignedInfoStrict(tempFilePath, secpPubKey)
+	assert.Equal(ErrKeyTypeMismatch, err)
+
+	// nothing signed yet
+	require.Nil(NewLastSignedInfo().SaveAs(tempFilePath))
+	_, err = LoadLastSignedInfoStrict(tempFilePath, secpPubKey)
+	assert.Nil(err)
+}
+
+func TestVerifyAgainstExpected(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, pubKey := newTestSigner()
+	otherSigner, otherPubKey := newTestSigner()
+	info := NewLastSignedInfo()
+	assert.Nil(info.VerifyAgainstExpected(pubKey))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+	assert.Nil(info.VerifyAgainstExpected(pubKey))
+	assert.Equal(ErrBadSignature, info.VerifyAgainstExpected(otherPubKey))
+	assert.Equal(ErrKeyTypeMismatch, info.VerifyAgainstExpected(crypto.GenPrivKeySecp256k1().PubKey()))
+
+	// the extension is signed by another key
+	_, err := info.SignExtension(otherSigner, []byte("extension"))
+	require.Nil(err)
+	assert.Equal(ErrBadSignature, info.VerifyAgainstExpected(pubKey))
+
+	// the state of another key setup
+	mixedUp := NewLastSignedInfo()
+	require.Nil(mixedUp.SignVote(otherSigner, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrBadSignature, mixedUp.VerifyAgainstExpected(pubKey))
+	mixedUp.SetVerifyOnSet(otherPubKey)
+	assert.Equal(ErrKeyMismatch, mixedUp.VerifyAgainstExpected(pubKey))
+	mixedUp.SetVerifyOnSet(crypto.PubKey{})
+	require.Nil(mixedUp.RotateKey(pubKey))
+	assert.Nil(mixedUp.VerifyAgainstExpected(pubKey))
+	assert.Equal(ErrKeyMismatch, mixedUp.VerifyAgainstExpected(otherPubKey))
+}
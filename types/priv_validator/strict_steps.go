@@ -0,0 +1,64 @@
+package types
+
+import (
+	"errors"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrStepSkipped      = errors.New("Step skipped")
+	ErrStepTypeMismatch = errors.New("Step doesn't match the type of the sign bytes")
+)
+
+// SetStrictSteps enables or disables strict step mode, which is off by default.
+//
+// In strict step mode, within the same height and round, Verify only allows
+// the step to advance by exactly one:
+//
+//	propose -> prevote
+//	prevote -> precommit
+//
+// So eg. propose -> precommit is rejected with ErrStepSkipped.
+// The first step signed in a round (including the first one ever)
+// may be any step, since validators that aren't the proposer
+// start a round at prevote.
+// Advancing the height or the round is always allowed.
+//
+// Set also checks the step matches what the sign bytes encode, returning
+// ErrStepTypeMismatch otherwise: a proposal for the propose step, and a
+// prevote or precommit for the prevote or precommit step. Sign bytes that
+// are neither a vote nor a proposal are not checked.
+func (info *LastSignedInfo) SetStrictSteps(strict bool) {
+	info.strictSteps = strict
+}
+
+// returns true if step directly follows lastStep within a round
+func isNextStep(lastStep, step int8) bool {
+	return lastStep == stepNone || step == lastStep+1
+}
+
+// checkStepType returns ErrStepTypeMismatch if signBytes are a vote or proposal
+// that doesn't belong to step.
+func checkStepType(step int8, signBytes []byte) error {
+	decoded, ok := decodeSignBytes(signBytes)
+	if !ok {
+		return nil
+	}
+	var signedStep int8
+	switch decoded := decoded.(type) {
+	case types.CanonicalJSONOnceProposal:
+		signedStep = stepPropose
+	case types.CanonicalJSONOnceVote:
+		switch decoded.Vote.Type {
+		case types.VoteTypePrevote:
+			signedStep = stepPrevote
+		case types.VoteTypePrecommit:
+			signedStep = stepPrecommit
+		}
+	}
+	if signedStep != step {
+		return ErrStepTypeMismatch
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestStrictSteps(t *testing.T) {
+	sig := crypto.SignatureEd25519{1}.Wrap()
+
+	cases := []struct {
+		lastStep int8
+		height   int64
+		round    int
+		step     int8
+		lax      error
+		strict   error
+	}{
+		{stepPropose, 10, 1, stepPrevote, nil, nil},
+		{stepPrevote, 10, 1, stepPrecommit, nil, nil},
+		{stepPropose, 10, 1, stepPrecommit, nil, ErrStepSkipped},
+		{stepPropose, 10, 2, stepPrecommit, nil, nil}, // new round
+		{stepPropose, 11, 0, stepPrecommit, nil, nil}, // new height
+	}
+
+	for i, c := range cases {
+		for _, strict := range []bool{false, true} {
+			info := NewLastSignedInfo()
+			info.SetStrictSteps(strict)
+			require.Nil(t, info.Set(10, 1, c.lastStep, []byte("signbytes"), sig))
+
+			expected := c.lax
+			if strict {
+				expected = c.strict
+			}
+			_, err := info.Verify(c.height, c.round, c.step)
+			assert.Equal(t, expected, err, "case %d strict=%v", i, strict)
+		}
+	}
+}
+
+func TestStrictStepsFirstSign(t *testing.T) {
+	info := NewLastSignedInfo()
+	info.SetStrictSteps(true)
+
+	// any step can be the first one
+	_, err := info.Verify(1, 0, stepPrecommit)
+	assert.Nil(t, err)
+	_, err = info.Verify(0, 0, stepPrevote)
+	assert.Nil(t, err)
+}
+
+func TestStrictStepsStepType(t *testing.T) {
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	signBytes := map[string][]byte{
+		"proposal":  types.SignBytes("mychainid", &types.Proposal{Height: 10, Round: 1, POLRound: -1}),
+		"prevote":   types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1)),
+		"precommit": types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrecommit, blockID1)),
+		"unknown":   types.SignBytes("mychainid", newVote(10, 1, 7, blockID1)),
+		"other":     []byte("signbytes"),
+	}
+	matching := map[int8]string{stepPropose: "proposal", stepPrevote: "prevote", stepPrecommit: "precommit"}
+
+	for step, match := range matching {
+		for kind, bytes := range signBytes {
+			for _, strict := range []bool{false, true} {
+				info := NewLastSignedInfo()
+				info.SetStrictSteps(strict)
+				err := info.Set(10, 1, step, bytes, sig)
+				if strict && kind != match && kind != "other" {
+					assert.Equal(t, ErrStepTypeMismatch, err, "step %v, %v", step, kind)
+					assert.EqualValues(t, 0, info.LastHeight)
+				} else {
+					assert.Nil(t, err, "step %v, %v, strict=%v", step, kind, strict)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/tendermint/tmlibs/log"
+)
+
+// tempFilePrefix is the prefix of the temp files the LastSignedInfo is
+// written to before being renamed to filePath. Each write gets its own file,
+// so concurrent writers never rename each other's temp file away.
+// They're in the same directory, so the rename is never across filesystems,
+// where it would fail or not be atomic. That's why there's no option to put
+// them elsewhere, eg. in os.TempDir().
+func tempFilePrefix(filePath string) string {
+	return filepath.Base(filePath) + ".tmp"
+}
+
+// writeFileAtomic writes to a new temp file with permissions 0600, syncs it,
+// renames it to filePath and syncs the directory, so the rename is durable.
+// If we crash before the rename, the temp file is recovered on load.
+func writeFileAtomic(filePath string, data []byte) error {
+	dir := filepath.Dir(filePath)
+	f, err := ioutil.TempFile(dir, tempFilePrefix(filePath))
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filePath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// tempFiles returns the temp files of filePath, matching <filePath>.tmp*.
+func tempFiles(filePath string) ([]string, error) {
+	dir := filepath.Dir(filePath)
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var tmps []string
+	prefix := tempFilePrefix(filePath)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			tmps = append(tmps, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return tmps, nil
+}
+
+// syncDir syncs the directory, eg. after a rename in it.
+// Directories can't be synced on windows, where it's a no-op.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// recoverTempFile handles the temp files left by a crash during writeFileAtomic.
+// If one is valid and strictly ahead of filePath (or filePath is missing),
+// the rename is completed, with the one furthest ahead if there are several.
+// The others are deleted.
+// It fails, leaving all files alone, if filePath exists but can't be parsed.
+func recoverTempFile(filePath string, codec StateCodec, logger log.Logger) error {
+	tmps, err := tempFiles(filePath)
+	if err != nil || len(tmps) == 0 {
+		return err
+	}
+
+	var current *LastSignedInfo
+	currentBytes, err := ioutil.ReadFile(filePath)
+	if err == nil {
+		if current, err = decodeBytes(codec, currentBytes); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	best, latest := "", current
+	for _, tmp := range tmps {
+		tmpBytes, err := ioutil.ReadFile(tmp)
+		if err != nil {
+			return err
+		}
+		pending, err := decodeBytes(codec, tmpBytes)
+		if err != nil || validateHRS(pending.LastHeight, pending.LastRound, pending.LastStep) != nil {
+			continue
+		}
+		if latest == nil || compareHRS(pending.LastHeight, pending.LastRound, pending.LastStep,
+			latest.LastHeight, latest.LastRound, latest.LastStep) > 0 {
+			best, latest = tmp, pending
+		}
+	}
+
+	for _, tmp := range tmps {
+		if tmp == best {
+			continue
+		}
+		logger.Error("Deleting leftover temp file of LastSignedInfo", "file", tmp)
+		if err := os.Remove(tmp); err != nil {
+			return err
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	logger.Error("Completing interrupted write of LastSignedInfo", "file", filePath, "to", latest)
+	if err := os.Rename(best, filePath); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(filePath))
+}
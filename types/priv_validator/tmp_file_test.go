@@ -0,0 +1,136 @@
+package types
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	cmn "github.com/tendermint/tmlibs/common"
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestLoadRecoversTempFile(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	writeInfo := func(filePath string, height int64) {
+		info := NewLastSignedInfo()
+		require.Nil(info.Set(height, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+		jsonBytes, err := json.Marshal(info)
+		require.Nil(err)
+		require.Nil(ioutil.WriteFile(filePath, jsonBytes, 0600))
+	}
+
+	cases := []struct {
+		name       string
+		mainHeight int64 // 0 for no main file
+		tmpHeight  int64 // 0 for garbage
+		loaded     int64
+	}{
+		{"tmp ahead", 10, 11, 11},
+		{"tmp same", 10, 10, 10},
+		{"tmp behind", 10, 9, 10},
+		{"tmp garbage", 10, 0, 10},
+		{"no main file", 0, 11, 11},
+	}
+	for _, c := range cases {
+		_, filePath := cmn.Tempfile("sign_info_")
+		os.Remove(filePath)
+		if c.mainHeight > 0 {
+			writeInfo(filePath, c.mainHeight)
+		}
+		if c.tmpHeight > 0 {
+			writeInfo(filePath+".tmp123", c.tmpHeight)
+		} else {
+			require.Nil(ioutil.WriteFile(filePath+".tmp123", []byte(`{"last_height":`), 0600))
+		}
+
+		state := NewSignInfoFile(filePath)
+		state.SetLogger(log.TestingLogger())
+		info, err := state.Load()
+		require.Nil(err, c.name)
+		assert.Equal(c.loaded, info.LastHeight, c.name)
+		tmps, err := tempFiles(filePath)
+		require.Nil(err)
+		assert.Empty(tmps, c.name)
+	}
+
+	// with several temp files, the one furthest ahead wins
+	_, filePath := cmn.Tempfile("sign_info_")
+	writeInfo(filePath, 10)
+	writeInfo(filePath+".tmp1", 12)
+	writeInfo(filePath+".tmp2", 11)
+	require.Nil(ioutil.WriteFile(filePath+".tmp3", []byte(`{"last_height":`), 0600))
+	info, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.EqualValues(12, info.LastHeight)
+	tmps, err := tempFiles(filePath)
+	require.Nil(err)
+	assert.Empty(tmps)
+
+	// a corrupt main file is left for the operator
+	_, filePath = cmn.Tempfile("sign_info_")
+	require.Nil(ioutil.WriteFile(filePath, []byte("garbage"), 0600))
+	writeInfo(filePath+".tmp123", 11)
+	_, err = LoadLastSignedInfo(filePath)
+	assert.Error(err)
+	_, err = os.Stat(filePath + ".tmp123")
+	assert.Nil(err)
+}
+
+func TestSaveLeavesNoTempFile(t *testing.T) {
+	_, filePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	require.Nil(t, info.SaveAs(filePath))
+	tmps, err := tempFiles(filePath)
+	require.Nil(t, err)
+	assert.Empty(t, tmps)
+}
+
+func TestConcurrentSavesUseTheirOwnTempFile(t *testing.T) {
+	_, filePath := cmn.Tempfile("sign_info_")
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func(height int64) {
+			info := NewLastSignedInfo()
+			info.LastHeight, info.LastStep = height, stepPrevote
+			errs <- info.SaveAs(filePath)
+		}(int64(i + 1))
+	}
+	for i := 0; i < cap(errs); i++ {
+		assert.Nil(t, <-errs)
+	}
+	_, err := LoadLastSignedInfo(filePath)
+	assert.Nil(t, err)
+}
+
+func TestSaveUsesTempFileInSameDirectory(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir, err := ioutil.TempDir("", "sign_info_")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "sign_info.json")
+
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	require.Nil(info.Save())
+	files, err := ioutil.ReadDir(dir)
+	require.Nil(err)
+	require.Len(files, 1)
+	assert.Equal("sign_info.json", files[0].Name())
+
+	// with the directory read-only, the temp file can't be created,
+	// even though the file itself is writable
+	require.Nil(os.Chmod(dir, 0500))
+	defer os.Chmod(dir, 0700)
+	if f, err := os.Create(filepath.Join(dir, "probe")); err == nil {
+		f.Close()
+		t.Skip("directory permissions are not enforced, eg. running as root")
+	}
+	assert.Error(info.Save())
+}
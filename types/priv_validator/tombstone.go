@@ -0,0 +1,94 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTombstoned              = errors.New("Signing is stopped for good after a serious fault, see ClearTombstone")
+	ErrTombstoneReasonMismatch = errors.New("Reason does not match the one the tombstone was set with")
+	ErrEmptyTombstoneReason    = errors.New("Tombstone needs a reason")
+	ErrNotTombstoned           = errors.New("LastSignedInfo is not tombstoned")
+)
+
+// Tombstone records why, and at which height/round/step, signing was stopped
+// for good. See LastSignedInfo.Tombstone.
+type Tombstone struct {
+	Reason string    `json:"reason"`
+	Height int64     `json:"height"`
+	Round  int       `json:"round"`
+	Step   int8      `json:"step"`
+	Time   time.Time `json:"time"`
+}
+
+// Tombstone stops signing for good, eg. after evidence of a double-sign:
+// Verify, SignVote, SignProposal and SignExtension return ErrTombstoned until
+// ClearTombstone is called, once the fault was investigated. Unlike Frozen,
+// it's persisted, so it survives a restart, and Reset doesn't clear it.
+// It's set even if persisting it fails, which is returned.
+// Tombstoning again keeps the first reason.
+func (info *LastSignedInfo) Tombstone(reason string) error {
+	if reason == "" {
+		return ErrEmptyTombstoneReason
+	}
+	if info.Tombstoned() {
+		return nil
+	}
+	info.TombstoneInfo = &Tombstone{
+		Reason: reason,
+		Height: info.LastHeight,
+		Round:  info.LastRound,
+		Step:   info.LastStep,
+		Time:   info.now(),
+	}
+	info.getLogger().Error("Tombstoned, signing is stopped for good", "reason", reason,
+		"height", info.LastHeight, "round", info.LastRound, "step", info.LastStep)
+	info.emitStateEvent(EventTombstoned, reason)
+	return info.persist()
+}
+
+// Tombstoned returns true if signing was stopped with Tombstone.
+func (info *LastSignedInfo) Tombstoned() bool {
+	return info.TombstoneInfo != nil
+}
+
+// ClearTombstone lets signing resume after Tombstone. As a confirmation, reason
+// must be the one the tombstone was set with, otherwise it returns
+// ErrTombstoneReasonMismatch. The clearing is logged, for the audit trail.
+func (info *LastSignedInfo) ClearTombstone(reason string) error {
+	tombstone := info.TombstoneInfo
+	if tombstone == nil {
+		return ErrNotTombstoned
+	}
+	if reason != tombstone.Reason {
+		return ErrTombstoneReasonMismatch
+	}
+	info.TombstoneInfo = nil
+	if err := info.persist(); err != nil {
+		info.TombstoneInfo = tombstone
+		return err
+	}
+	info.getLogger().Error("Tombstone cleared, signing resumes", "reason", tombstone.Reason,
+		"height", tombstone.Height, "round", tombstone.Round, "step", tombstone.Step, "since", tombstone.Time)
+	return nil
+}
+
+// tombstoneConflict tombstones after conflicting data, see ConflictTombstone.
+// If persisting fails, signing is frozen as well, as the tombstone may not
+// survive a restart.
+func (info *LastSignedInfo) tombstoneConflict(height int64, round int, step int8) {
+	if err := info.Tombstone(fmt.Sprintf("Conflicting data at %v/%v/%v", height, round, step)); err != nil {
+		info.getLogger().Error("Cannot persist the tombstone, freezing signing", "error", err)
+		info.freeze(fmt.Sprintf("Cannot persist the tombstone: %v", err))
+	}
+}
+
+func copyTombstone(tombstone *Tombstone) *Tombstone {
+	if tombstone == nil {
+		return nil
+	}
+	cpy := *tombstone
+	return &cpy
+}
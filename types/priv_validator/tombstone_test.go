@@ -0,0 +1,80 @@
+package types
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestTombstone(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+
+	assert.Equal(ErrEmptyTombstoneReason, info.Tombstone(""))
+	assert.Equal(ErrNotTombstoned, info.ClearTombstone("double-sign"))
+	require.Nil(info.Tombstone("double-sign"))
+	require.Nil(info.Tombstone("another"))
+	assert.Equal("double-sign", info.TombstoneInfo.Reason)
+	assert.Equal(int64(10), info.TombstoneInfo.Height)
+
+	check := func(info *LastSignedInfo) {
+		_, err := info.Verify(11, 0, stepPrevote)
+		assert.Equal(ErrTombstoned, err)
+		assert.Equal(ErrTombstoned, info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+		assert.Equal(ErrTombstoned, info.SignProposal(signer, "mychainid", &types.Proposal{Height: 11, POLRound: -1}))
+		_, err = info.SignExtension(signer, []byte("extension"))
+		assert.Equal(ErrTombstoned, err)
+		assert.False(info.Health().Ready)
+	}
+	check(info)
+
+	// it survives a restart, a Reset, a Restore and a reconcile
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	check(loaded)
+	require.Nil(loaded.Reset())
+	check(loaded)
+	require.Nil(loaded.Restore(NewLastSignedInfo().Snapshot()))
+	check(loaded)
+	reconciled, err := ReconcileSources(NewLastSignedInfo(), loaded)
+	require.Nil(err)
+	check(reconciled)
+
+	// clearing needs the reason as a confirmation
+	assert.Equal(ErrTombstoneReasonMismatch, loaded.ClearTombstone("another"))
+	require.Nil(loaded.ClearTombstone("double-sign"))
+	loaded, err = LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	assert.False(loaded.Tombstoned())
+	require.Nil(loaded.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+}
+
+func TestConflictTombstone(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	info := NewLastSignedInfo()
+	require.Nil(info.SetFilePath(filePath))
+	info.SetConflictStrategy(ConflictTombstone)
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Equal(ErrConflictingData, info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID2)))
+	assert.False(info.Frozen())
+
+	loaded, err := LoadLastSignedInfo(filePath)
+	require.Nil(err)
+	require.True(loaded.Tombstoned())
+	assert.Equal("Conflicting data at 10/0/2", loaded.TombstoneInfo.Reason)
+	assert.Equal(ErrTombstoned, loaded.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+}
@@ -0,0 +1,44 @@
+package types
+
+// Tracer is used to wrap the signing helpers in spans, eg. to see how much
+// signing contributes to block time. It lets callers plug in any tracing
+// backend (OpenTelemetry, OpenTracing, ...) without this package depending on it.
+//
+// StartSpan is called with the span name and the height/round/step being
+// signed as keyvals ("height", h, "round", r, "step", s). The returned function
+// ends the span and is called exactly once with the outcome keyvals,
+// eg. ("outcome", "reused"). A fresh signature also has the time it was made,
+// by the Clock, eg. ("outcome", "signed", "reason", reason, "time", t).
+type Tracer interface {
+	StartSpan(name string, keyvals ...interface{}) (end func(keyvals ...interface{}))
+}
+
+// TracerFunc is an adapter to allow the use of ordinary functions as Tracers.
+type TracerFunc func(name string, keyvals ...interface{}) func(keyvals ...interface{})
+
+// StartSpan implements Tracer.
+func (f TracerFunc) StartSpan(name string, keyvals ...interface{}) func(keyvals ...interface{}) {
+	return f(name, keyvals...)
+}
+
+type nopTracer struct{}
+
+func (nopTracer) StartSpan(string, ...interface{}) func(...interface{}) {
+	return func(...interface{}) {}
+}
+
+// SetTracer sets the Tracer used by Verify, Set and SignVote.
+// Passing nil restores the default no-op tracer.
+func (info *LastSignedInfo) SetTracer(tracer Tracer) {
+	if tracer == nil {
+		tracer = nopTracer{}
+	}
+	info.tracer = tracer
+}
+
+func (info *LastSignedInfo) startSpan(name string, height int64, round int, step int8) func(keyvals ...interface{}) {
+	if info.tracer == nil {
+		return nopTracer{}.StartSpan(name)
+	}
+	return info.tracer.StartSpan(name, "height", height, "round", round, "step", step)
+}
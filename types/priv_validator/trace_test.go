@@ -0,0 +1,78 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/types"
+)
+
+type recordedSpan struct {
+	name    string
+	start   []interface{}
+	outcome string
+	ended   int
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (rt *recordingTracer) StartSpan(name string, keyvals ...interface{}) func(...interface{}) {
+	span := &recordedSpan{name: name, start: keyvals}
+	rt.spans = append(rt.spans, span)
+	return func(keyvals ...interface{}) {
+		span.ended++
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			if keyvals[i] == "outcome" {
+				span.outcome = keyvals[i+1].(string)
+			}
+		}
+	}
+}
+
+func (rt *recordingTracer) outcomes(name string) []string {
+	var outcomes []string
+	for _, span := range rt.spans {
+		if span.name == name {
+			outcomes = append(outcomes, span.outcome)
+		}
+	}
+	return outcomes
+}
+
+func TestTracerSpans(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := &recordingTracer{}
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	info.SetTracer(tracer)
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+	assert.Error(info.SignVote(signer, "mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2)))
+	assert.Error(info.SignVote(signer, "mychainid", newVote(9, 1, types.VoteTypePrevote, blockID1)))
+
+	assert.Equal([]string{"signed", "reused", "conflict", "rejected"}, tracer.outcomes("LastSignedInfo.SignVote"))
+	assert.Equal([]string{"advance", "same_hrs", "same_hrs", "rejected"}, tracer.outcomes("LastSignedInfo.Verify"))
+	assert.Equal([]string{"recorded"}, tracer.outcomes("LastSignedInfo.Set"))
+
+	for _, span := range tracer.spans {
+		assert.Equal(1, span.ended, "span %v must be ended exactly once", span.name)
+		assert.Equal("height", span.start[0])
+	}
+	assert.Equal([]interface{}{"height", int64(10), "round", 1, "step", stepPrevote}, tracer.spans[0].start)
+}
+
+func TestTracerDefaultsToNop(t *testing.T) {
+	signer, _ := newTestSigner()
+
+	info := &LastSignedInfo{}
+	assert.NoError(t, info.SignVote(signer, "mychainid", newVote(1, 0, types.VoteTypePrevote, blockID1)))
+
+	info.SetTracer(nil)
+	assert.NoError(t, info.SignVote(signer, "mychainid", newVote(2, 0, types.VoteTypePrevote, blockID1)))
+}
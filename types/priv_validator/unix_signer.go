@@ -0,0 +1,275 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// The protocol of ServeUnix and UnixClient is a request and a response per
+// call, each a JSON message prefixed with its length as a big-endian uint32.
+// The server holds the LastSignedInfo and the key, so the double-sign checks
+// are done where the signing happens: the client only sends what to sign.
+
+// maxUnixMessageSize bounds the messages, so a bad length can't exhaust memory.
+const maxUnixMessageSize = 1 << 20
+
+type unixRequest struct {
+	Method string `json:"method"`
+
+	// verify and set
+	Height    int64     `json:"height,omitempty"`
+	Round     int       `json:"round,omitempty"`
+	Step      int8      `json:"step,omitempty"`
+	SignBytes []byte    `json:"sign_bytes,omitempty"`
+	Signature Signature `json:"signature,omitempty"`
+
+	// sign_vote and sign_proposal
+	ChainID  string          `json:"chain_id,omitempty"`
+	Vote     *types.Vote     `json:"vote,omitempty"`
+	Proposal *types.Proposal `json:"proposal,omitempty"`
+}
+
+type unixResponse struct {
+	SameHRS  bool            `json:"same_hrs,omitempty"`
+	Vote     *types.Vote     `json:"vote,omitempty"`
+	Proposal *types.Proposal `json:"proposal,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// ServeUnix serves Verify, Set, SignVote and SignProposal of the LastSignedInfo
+// loaded from state, signing with signer, on a Unix domain socket at path,
+// eg. for a signer daemon serving a validator with a UnixClient.
+// Connections are served concurrently, but the calls one at a time.
+// It only returns on an error, eg. if it can't load the state or listen.
+func ServeUnix(path string, state SignerState, signer types.Signer) error {
+	info, err := state.Load()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return newUnixServer(info, signer).serve(listener)
+}
+
+type unixServer struct {
+	mtx    sync.Mutex
+	info   *LastSignedInfo
+	signer types.Signer
+}
+
+func newUnixServer(info *LastSignedInfo, signer types.Signer) *unixServer {
+	return &unixServer{info: info, signer: signer}
+}
+
+func (s *unixServer) serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn handles the requests on conn until it's closed or fails.
+func (s *unixServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var req unixRequest
+		if err := readUnixMessage(conn, &req); err != nil {
+			return
+		}
+		if err := writeUnixMessage(conn, s.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *unixServer) handle(req unixRequest) unixResponse {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var resp unixResponse
+	var err error
+	switch req.Method {
+	case "verify":
+		resp.SameHRS, err = s.info.Verify(req.Height, req.Round, req.Step)
+	case "set":
+		err = s.info.Set(req.Height, req.Round, req.Step, req.SignBytes, req.Signature.Crypto())
+	case "sign_vote":
+		if req.Vote == nil {
+			err = errors.New("Missing vote")
+			break
+		}
+		err = s.info.SignVote(s.signer, req.ChainID, req.Vote)
+		resp.Vote = req.Vote
+	case "sign_proposal":
+		if req.Proposal == nil {
+			err = errors.New("Missing proposal")
+			break
+		}
+		err = s.info.SignProposal(s.signer, req.ChainID, req.Proposal)
+		resp.Proposal = req.Proposal
+	default:
+		err = fmt.Errorf("Unknown method %q", req.Method)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+//-------------------------------------
+
+// UnixClient calls a LastSignedInfo served by ServeUnix.
+// It's safe for concurrent use.
+type UnixClient struct {
+	mtx  sync.Mutex
+	conn net.Conn
+}
+
+// DialUnix connects to the socket at path served by ServeUnix.
+func DialUnix(path string) (*UnixClient, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return newUnixClient(conn), nil
+}
+
+func newUnixClient(conn net.Conn) *UnixClient {
+	return &UnixClient{conn: conn}
+}
+
+// Close closes the connection.
+func (c *UnixClient) Close() error {
+	return c.conn.Close()
+}
+
+// Verify calls Verify on the server.
+func (c *UnixClient) Verify(height int64, round int, step int8) (bool, error) {
+	resp, err := c.call(unixRequest{Method: "verify", Height: height, Round: round, Step: step})
+	if err != nil {
+		return false, err
+	}
+	return resp.SameHRS, nil
+}
+
+// Set calls Set on the server.
+func (c *UnixClient) Set(height int64, round int, step int8, signBytes []byte, sig crypto.Signature) error {
+	_, err := c.call(unixRequest{Method: "set", Height: height, Round: round, Step: step,
+		SignBytes: signBytes, Signature: SignatureFromCrypto(sig)})
+	return err
+}
+
+// SignVote calls SignVote on the server, and sets the signature on the vote,
+// and the timestamp if the server reused the LastSignature.
+func (c *UnixClient) SignVote(chainID string, vote *types.Vote) error {
+	resp, err := c.call(unixRequest{Method: "sign_vote", ChainID: chainID, Vote: vote})
+	if err != nil {
+		return err
+	}
+	if resp.Vote == nil {
+		return errors.New("Missing vote in response")
+	}
+	vote.Signature = resp.Vote.Signature
+	vote.Timestamp = resp.Vote.Timestamp
+	return nil
+}
+
+// SignProposal calls SignProposal on the server, and sets the signature on the
+// proposal, and the timestamp if the server reused the LastSignature.
+func (c *UnixClient) SignProposal(chainID string, proposal *types.Proposal) error {
+	resp, err := c.call(unixRequest{Method: "sign_proposal", ChainID: chainID, Proposal: proposal})
+	if err != nil {
+		return err
+	}
+	if resp.Proposal == nil {
+		return errors.New("Missing proposal in response")
+	}
+	proposal.Signature = resp.Proposal.Signature
+	proposal.Timestamp = resp.Proposal.Timestamp
+	return nil
+}
+
+// call sends the request and returns the response, or the error of the server.
+func (c *UnixClient) call(req unixRequest) (unixResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var resp unixResponse
+	if err := writeUnixMessage(c.conn, req); err != nil {
+		return resp, err
+	}
+	if err := readUnixMessage(c.conn, &resp); err != nil {
+		return resp, err
+	}
+	if resp.Error != "" {
+		return resp, unixError(resp.Error)
+	}
+	return resp, nil
+}
+
+// unixErrors are the errors the client returns as is,
+// so they can be compared like those of a local LastSignedInfo.
+var unixErrors = []error{
+	ErrHeightRegression, ErrRoundRegression, ErrStepRegression, ErrNoLastSignature,
+	ErrConflictingData, ErrAlreadySigned, ErrBelowFloor, ErrBackdatedConflict,
+	ErrFrozen, ErrNonCanonicalSignBytes, ErrPendingSign, ErrPolicyDenied,
+	ErrStepSkipped, ErrStepTypeMismatch, ErrBadSignature, ErrTombstoned,
+	ErrResetNotAcknowledged, ErrTimestampRewrite, ErrReusedSignBytesMismatch,
+	ErrEmptyChainID, ErrChainMismatch, ErrKeyMismatch, ErrSignBytesHRSMismatch,
+}
+
+func unixError(msg string) error {
+	for _, err := range unixErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+//-------------------------------------
+
+func writeUnixMessage(w io.Writer, msg interface{}) error {
+	bz, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(bz) > maxUnixMessageSize {
+		return fmt.Errorf("Message of %v bytes is too large", len(bz))
+	}
+	buf := make([]byte, 4+len(bz))
+	binary.BigEndian.PutUint32(buf, uint32(len(bz)))
+	copy(buf[4:], bz)
+	_, err = w.Write(buf)
+	return err
+}
+
+func readUnixMessage(r io.Reader, msg interface{}) error {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxUnixMessageSize {
+		return fmt.Errorf("Message of %v bytes is too large", size)
+	}
+	bz := make([]byte, size)
+	if _, err := io.ReadFull(r, bz); err != nil {
+		return err
+	}
+	return json.Unmarshal(bz, msg)
+}
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+func TestUnixSignerRoundTrip(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetConflictStrategy(ConflictError)
+	signer, pubKey := newTestSigner()
+	serverConn, clientConn := net.Pipe()
+	go newUnixServer(info, signer).serveConn(serverConn)
+	client := newUnixClient(clientConn)
+	defer client.Close()
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(client.SignVote("mychainid", vote))
+	assert.True(pubKey.VerifyBytes(types.SignBytes("mychainid", vote), vote.Signature))
+	assert.True(info.HasSignature(vote.Signature))
+
+	// the server reuses the signature, with its timestamp
+	later := *vote
+	later.Signature = crypto.Signature{}
+	later.Timestamp = vote.Timestamp.Add(time.Second)
+	require.Nil(client.SignVote("mychainid", &later))
+	assert.Equal(vote.Signature, later.Signature)
+	assert.Equal(types.SignBytes("mychainid", vote), types.SignBytes("mychainid", &later))
+
+	// and refuses to double sign, with the same errors
+	assert.Equal(ErrConflictingData, client.SignVote("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID2)))
+	assert.Equal(ErrHeightRegression, client.SignVote("mychainid", newVote(9, 0, types.VoteTypePrevote, blockID1)))
+
+	proposal := &types.Proposal{Height: 11, POLRound: -1, Timestamp: time.Now().UTC()}
+	require.Nil(client.SignProposal("mychainid", proposal))
+	assert.True(pubKey.VerifyBytes(types.SignBytes("mychainid", proposal), proposal.Signature))
+
+	sameHRS, err := client.Verify(11, 0, stepPropose)
+	require.Nil(err)
+	assert.True(sameHRS)
+	_, err = client.Verify(10, 0, stepPrecommit)
+	assert.Equal(ErrHeightRegression, err)
+
+	require.Nil(client.Set(12, 0, stepPrevote, []byte("signbytes"), crypto.SignatureEd25519{1}.Wrap()))
+	assert.Equal(int64(12), info.LastHeight)
+	assert.Equal([]byte("signbytes"), []byte(info.LastSignBytes))
+
+	_, err = client.call(unixRequest{Method: "unknown"})
+	assert.Error(err)
+	_, err = client.call(unixRequest{Method: "sign_vote"})
+	assert.Error(err)
+}
+
+func TestUnixErrorsRoundTrip(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	for _, err := range unixErrors {
+		serverConn, clientConn := net.Pipe()
+		go writeUnixMessage(serverConn, unixResponse{Error: err.Error()})
+		var resp unixResponse
+		require.Nil(readUnixMessage(clientConn, &resp))
+		assert.True(err == unixError(resp.Error), "%v", err)
+		serverConn.Close()
+		clientConn.Close()
+	}
+	assert.False(ErrConflictingData == unixError("Conflicting"))
+
+	// errors of the signing path, as the server returns them
+	info := NewLastSignedInfo()
+	signer, _ := newTestSigner()
+	serverConn, clientConn := net.Pipe()
+	go newUnixServer(info, signer).serveConn(serverConn)
+	client := newUnixClient(clientConn)
+	defer client.Close()
+	assert.True(ErrEmptyChainID == client.SignVote("", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	require.Nil(info.Bind("mychainid"))
+	assert.True(ErrChainMismatch == client.SignVote("otherchainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	require.Nil(info.Tombstone("test"))
+	assert.True(ErrTombstoned == client.SignVote("mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+}
+
+func TestServeUnix(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir, err := ioutil.TempDir("", "unix_signer_")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	_, filePath := cmn.Tempfile("sign_info_")
+	defer os.Remove(filePath)
+	require.Nil(NewLastSignedInfo().SaveAs(filePath))
+
+	socketPath := filepath.Join(dir, "signer.sock")
+	state := NewSignInfoFile(filePath)
+	signer, _ := newTestSigner()
+	errs := make(chan error, 1)
+	go func() { errs <- ServeUnix(socketPath, state, signer) }()
+
+	var client *UnixClient
+	for i := 0; i < 100 && client == nil; i++ {
+		if client, err = DialUnix(socketPath); err != nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	require.NotNil(client, "%v", err)
+	defer client.Close()
+
+	vote := newVote(10, 1, types.VoteTypePrevote, blockID1)
+	require.Nil(client.SignVote("mychainid", vote))
+
+	// the state is persisted by the server
+	loaded, err := state.Load()
+	require.Nil(err)
+	assert.True(loaded.HasSignature(vote.Signature))
+
+	// it fails to serve on a path in use
+	assert.Error(ServeUnix(socketPath, state, signer))
+	select {
+	case err := <-errs:
+		t.Fatalf("server stopped: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Update builds the arguments of Set by name, so they can't be transposed,
+// and checks them before anything is changed, eg.
+//
+//	err := NewUpdate().Height(h).Round(r).Step(s).Bytes(signBytes).Signature(sig).Apply(info)
+type Update struct {
+	height    int64
+	round     int
+	step      int8
+	signBytes []byte
+	sig       crypto.Signature
+
+	hasHeight, hasRound, hasStep bool
+}
+
+// NewUpdate returns an empty Update.
+func NewUpdate() *Update {
+	return &Update{}
+}
+
+// Height sets the height.
+func (u *Update) Height(height int64) *Update {
+	u.height, u.hasHeight = height, true
+	return u
+}
+
+// Round sets the round.
+func (u *Update) Round(round int) *Update {
+	u.round, u.hasRound = round, true
+	return u
+}
+
+// Step sets the step.
+func (u *Update) Step(step int8) *Update {
+	u.step, u.hasStep = step, true
+	return u
+}
+
+// Bytes sets the sign bytes.
+func (u *Update) Bytes(signBytes []byte) *Update {
+	u.signBytes = signBytes
+	return u
+}
+
+// Signature sets the signature of the sign bytes.
+func (u *Update) Signature(sig crypto.Signature) *Update {
+	u.sig = sig
+	return u
+}
+
+// Validate returns an error if a field is missing or invalid, or if the sign
+// bytes are a vote or proposal of another height, round or step.
+func (u *Update) Validate() error {
+	switch {
+	case !u.hasHeight:
+		return errors.New("Update is missing the height")
+	case !u.hasRound:
+		return errors.New("Update is missing the round")
+	case !u.hasStep:
+		return errors.New("Update is missing the step")
+	case u.signBytes == nil:
+		return errors.New("Update is missing the sign bytes")
+	case u.sig.Empty():
+		return errors.New("Update is missing the signature")
+	}
+	if err := validateHRS(u.height, u.round, u.step); err != nil {
+		return err
+	}
+	if u.step == stepNone {
+		return errors.New("Update cannot be at the none step")
+	}
+	return checkSignedHRS(u.height, u.round, u.step, u.signBytes)
+}
+
+// Apply validates the Update, then calls Set with it.
+func (u *Update) Apply(info *LastSignedInfo) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	return info.Set(u.height, u.round, u.step, u.signBytes, u.sig)
+}
+
+// checkSignedHRS returns an error if signBytes are a vote or proposal
+// of another height, round or step.
+func checkSignedHRS(height int64, round int, step int8, signBytes []byte) error {
+	if err := checkStepType(step, signBytes); err != nil {
+		return err
+	}
+	decoded, ok := decodeSignBytes(signBytes)
+	if !ok {
+		return nil
+	}
+	var signedHeight int64
+	var signedRound int
+	switch decoded := decoded.(type) {
+	case types.CanonicalJSONOnceProposal:
+		signedHeight, signedRound = decoded.Proposal.Height, decoded.Proposal.Round
+	case types.CanonicalJSONOnceVote:
+		signedHeight, signedRound = decoded.Vote.Height, decoded.Vote.Round
+	}
+	if signedHeight != height || signedRound != round {
+		return fmt.Errorf("Sign bytes are for %v/%v, not %v/%v", signedHeight, signedRound, height, round)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestUpdate(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	signBytes := types.SignBytes("mychainid", newVote(10, 1, types.VoteTypePrevote, blockID1))
+	sig := crypto.SignatureEd25519{1}.Wrap()
+	update := func() *Update {
+		return NewUpdate().Height(10).Round(1).Step(stepPrevote).Bytes(signBytes).Signature(sig)
+	}
+
+	invalid := map[string]*Update{
+		"no height":      NewUpdate().Round(1).Step(stepPrevote).Bytes(signBytes).Signature(sig),
+		"no round":       NewUpdate().Height(10).Step(stepPrevote).Bytes(signBytes).Signature(sig),
+		"no step":        NewUpdate().Height(10).Round(1).Bytes(signBytes).Signature(sig),
+		"no bytes":       NewUpdate().Height(10).Round(1).Step(stepPrevote).Signature(sig),
+		"no signature":   NewUpdate().Height(10).Round(1).Step(stepPrevote).Bytes(signBytes),
+		"negative round": update().Round(-1),
+		"none step":      update().Step(stepNone),
+		"unknown step":   update().Step(stepMax + 1),
+		"wrong height":   update().Height(11),
+		"swapped":        update().Round(2).Step(1), // round and step transposed
+		"wrong type":     update().Step(stepPrecommit),
+	}
+	for name, u := range invalid {
+		assert.Error(u.Apply(info), name)
+		assert.Equal(int64(0), info.LastHeight, name)
+	}
+
+	require.Nil(update().Apply(info))
+	assert.Equal(int64(10), info.LastHeight)
+	assert.Equal(1, info.LastRound)
+	assert.Equal(stepPrevote, info.LastStep)
+	assert.Equal(signBytes, []byte(info.LastSignBytes))
+	assert.True(info.HasSignature(sig))
+
+	// sign bytes that aren't a vote or proposal are only checked by Set
+	require.Nil(NewUpdate().Height(11).Round(0).Step(stepPrevote).Bytes([]byte("signbytes")).Signature(sig).Apply(info))
+}
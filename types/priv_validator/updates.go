@@ -0,0 +1,40 @@
+package types
+
+// SetOnUpdate sets a callback that's called with an HRSUpdate each time
+// SignVote or SignProposal returns a signature, fresh or reused, eg. to feed
+// a ReadOnlyReplica or a dashboard.
+// It's called synchronously, once the signature is recorded and set, so it
+// should return quickly; the update is only built if there's a callback.
+// Passing nil removes it.
+func (info *LastSignedInfo) SetOnUpdate(onUpdate func(HRSUpdate)) {
+	info.onUpdate = onUpdate
+}
+
+func (info *LastSignedInfo) emitUpdate(reason SignReason, signBytes []byte, reused bool) {
+	eventType := EventSigned
+	if reused {
+		eventType = EventReused
+	}
+	info.emitEvent(SignerEvent{
+		Type:      eventType,
+		Height:    info.LastHeight,
+		Round:     info.LastRound,
+		Step:      info.LastStep,
+		SignBytes: copyBytes(signBytes),
+		Signature: info.LastSignature.Crypto(),
+		Reason:    reason,
+	})
+	if info.onUpdate == nil {
+		return
+	}
+	info.onUpdate(HRSUpdate{
+		Height:    info.LastHeight,
+		Round:     info.LastRound,
+		Step:      info.LastStep,
+		Signature: info.LastSignature.Crypto(),
+		SignBytes: copyBytes(signBytes),
+		Reason:    reason,
+		Reused:    reused,
+		Time:      info.now(),
+	})
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestOnUpdate(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := NewLastSignedInfo()
+	info.SetClock(NewManualClock(now))
+	info.SetConflictStrategy(ConflictError)
+	var updates []HRSUpdate
+	info.SetOnUpdate(func(update HRSUpdate) { updates = append(updates, update) })
+	signer, _ := newTestSigner()
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", vote))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	require.Nil(info.SignProposal(signer, "mychainid", &types.Proposal{Height: 11, POLRound: -1}))
+	// no update without a signature
+	assert.Error(info.SignVote(signer, "mychainid", vote))
+
+	require.Len(updates, 3)
+	assert.Equal(FirstSign, updates[0].Reason)
+	assert.False(updates[0].Reused)
+	assert.Equal(vote.Signature, updates[0].Signature)
+	assert.Equal(types.SignBytes("mychainid", vote), updates[0].SignBytes)
+	assert.Equal(now, updates[0].Time)
+	assert.Equal(Reused, updates[1].Reason)
+	assert.True(updates[1].Reused)
+	assert.Equal(vote.Signature, updates[1].Signature)
+	assert.Equal(HeightAdvanced, updates[2].Reason)
+	assert.EqualValues(11, updates[2].Height)
+	assert.Equal(stepPropose, updates[2].Step)
+
+	// the updates can be applied to a replica
+	replica := NewReadOnlyReplica()
+	for _, update := range updates {
+		require.Nil(replica.Apply(update))
+	}
+	replicated, err := replica.Load()
+	require.Nil(err)
+	assert.Equal(info.LastSignBytes, replicated.LastSignBytes)
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ValidateVote checks a vote of the validator, eg. for a relayer or a light
+// client that has its LastSignedInfo: the signature must verify against pub,
+// otherwise it returns ErrBadSignature, and the vote must not conflict with
+// what was recorded:
+//
+//   - at the latest HRS, it must be the LastSignBytes, but for the timestamp,
+//     otherwise it returns ErrConflictingData;
+//   - below it, it must be the sign bytes in the history at that HRS, if any
+//     (see SetHistorySize), otherwise it returns ErrBackdatedConflict;
+//   - above it, it can't conflict with anything recorded yet.
+//
+// It doesn't change anything, not even on a conflict.
+func (info *LastSignedInfo) ValidateVote(chainID string, vote *types.Vote, pub crypto.PubKey) error {
+	if !types.IsVoteTypeValid(vote.Type) {
+		return fmt.Errorf("Invalid vote type %v", vote.Type)
+	}
+	signBytes := info.canonicalEncoder().VoteBytes(chainID, vote)
+	if vote.Signature.Empty() || !pub.VerifyBytes(signBytes, vote.Signature) {
+		return ErrBadSignature
+	}
+
+	height, round, step := vote.Height, vote.Round, voteToStep(vote)
+	switch compareHRS(height, round, step, info.LastHeight, info.LastRound, info.LastStep) {
+	case 0:
+		if info.LastSignBytes != nil && !info.sameSignedData(info.LastSignBytes, signBytes) {
+			return ErrConflictingData
+		}
+		if info.LastSignBytesHash != nil && !info.matchesSignBytes(signBytes) {
+			return ErrConflictingData
+		}
+	case -1:
+		onlyDifferByTimestamp, _ := info.comparisons(checkVotesOnlyDifferByTimestamp, normalizeVotes)
+		return info.checkHistory(height, round, step, signBytes, onlyDifferByTimestamp)
+	}
+	return nil
+}
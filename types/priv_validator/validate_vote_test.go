@@ -0,0 +1,61 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestValidateVote(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	info := NewLastSignedInfo()
+	info.SetHistorySize(5)
+	signer, pubKey := newTestSigner()
+	signed := func(height int64, round int, typ byte, blockID types.BlockID) *types.Vote {
+		vote := newVote(height, round, typ, blockID)
+		sig, err := signer.Sign(types.SignBytes("mychainid", vote))
+		require.Nil(err)
+		vote.Signature = sig
+		return vote
+	}
+
+	prevote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", prevote))
+	precommit := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+	require.Nil(info.SignVote(signer, "mychainid", precommit))
+	snapshot := info.Snapshot()
+
+	assert.Nil(info.ValidateVote("mychainid", precommit, pubKey))
+	assert.Nil(info.ValidateVote("mychainid", prevote, pubKey))
+	later := *precommit
+	later.Timestamp = precommit.Timestamp.Add(time.Second)
+	later.Signature, _ = signer.Sign(types.SignBytes("mychainid", &later))
+	assert.Nil(info.ValidateVote("mychainid", &later, pubKey))
+	assert.Nil(info.ValidateVote("mychainid", signed(11, 0, types.VoteTypePrevote, blockID2), pubKey))
+
+	// conflicts
+	assert.Equal(ErrConflictingData, info.ValidateVote("mychainid", signed(10, 0, types.VoteTypePrecommit, blockID2), pubKey))
+	assert.Equal(ErrBackdatedConflict, info.ValidateVote("mychainid", signed(10, 0, types.VoteTypePrevote, blockID2), pubKey))
+	// an older vote that isn't in the history can't be checked
+	assert.Nil(info.ValidateVote("mychainid", signed(9, 0, types.VoteTypePrevote, blockID2), pubKey))
+
+	// bad signatures
+	assert.Equal(ErrBadSignature, info.ValidateVote("otherchainid", precommit, pubKey))
+	_, otherPubKey := newTestSigner()
+	assert.Equal(ErrBadSignature, info.ValidateVote("mychainid", precommit, otherPubKey))
+	unsigned := *precommit
+	unsigned.Signature = crypto.Signature{}
+	assert.Equal(ErrBadSignature, info.ValidateVote("mychainid", &unsigned, pubKey))
+	invalid := *precommit
+	invalid.Type = 0x42
+	assert.Error(info.ValidateVote("mychainid", &invalid, pubKey))
+
+	// nothing changed
+	assert.Equal(snapshot, info.Snapshot())
+	assert.False(info.Frozen())
+}
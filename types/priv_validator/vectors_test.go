@@ -0,0 +1,107 @@
+package types
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+// vectorMessage is a vote or proposal of a test vector.
+type vectorMessage struct {
+	Type       string `json:"type"` // prevote, precommit or proposal
+	Height     int64  `json:"height"`
+	Round      int    `json:"round"`
+	BlockHash  string `json:"block_hash"` // hex, empty for a nil vote
+	PartsTotal int    `json:"parts_total"`
+	PartsHash  string `json:"parts_hash"` // hex
+	POLRound   int    `json:"pol_round"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// sign signs the message with info, and returns the outcome
+// and the sign bytes.
+func (m vectorMessage) sign(t *testing.T, info *LastSignedInfo, signer types.Signer) (string, []byte) {
+	blockHash, err := hex.DecodeString(m.BlockHash)
+	require.Nil(t, err)
+	partsHash, err := hex.DecodeString(m.PartsHash)
+	require.Nil(t, err)
+	timestamp, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+	require.Nil(t, err)
+	partsHeader := types.PartSetHeader{Total: m.PartsTotal, Hash: partsHash}
+
+	var signBytes []byte
+	var reason SignReason
+	switch m.Type {
+	case "proposal":
+		proposal := &types.Proposal{Height: m.Height, Round: m.Round, BlockPartsHeader: partsHeader,
+			POLRound: m.POLRound, Timestamp: timestamp}
+		signBytes = types.SignBytes(vectorChainID, proposal)
+		reason, err = info.signProposal(context.Background(), signer, vectorChainID, proposal)
+	case "prevote", "precommit":
+		voteType := types.VoteTypePrevote
+		if m.Type == "precommit" {
+			voteType = types.VoteTypePrecommit
+		}
+		vote := &types.Vote{Height: m.Height, Round: m.Round, Type: voteType,
+			BlockID: types.BlockID{Hash: blockHash, PartsHeader: partsHeader}, Timestamp: timestamp}
+		signBytes = types.SignBytes(vectorChainID, vote)
+		reason, err = info.SignVoteWithReason(signer, vectorChainID, vote)
+	default:
+		t.Fatalf("Unknown message type %v", m.Type)
+	}
+
+	switch err {
+	case nil:
+		if reason == Reused {
+			return "reuse", signBytes
+		}
+		return "sign", signBytes
+	case ErrHeightRegression, ErrRoundRegression, ErrStepRegression:
+		return "regress", signBytes
+	case ErrConflictingData:
+		return "conflict", signBytes
+	default:
+		t.Fatalf("Unexpected error %v", err)
+		return "", nil
+	}
+}
+
+const vectorChainID = "test_chain_id"
+
+// TestVectors checks the decisions and sign bytes against the golden vectors in
+// testdata/vectors.json: the outcome of signing next after signing last, if any.
+// A failure means the double sign decisions or the sign bytes changed;
+// if that's intended, update the vectors in the same change.
+func TestVectors(t *testing.T) {
+	vectorsJSON, err := ioutil.ReadFile("testdata/vectors.json")
+	require.Nil(t, err)
+	var vectors []struct {
+		Name      string         `json:"name"`
+		Last      *vectorMessage `json:"last"`
+		Next      vectorMessage  `json:"next"`
+		Decision  string         `json:"decision"`
+		SignBytes string         `json:"sign_bytes"`
+	}
+	require.Nil(t, json.Unmarshal(vectorsJSON, &vectors))
+	require.NotEmpty(t, vectors)
+
+	signer := NewDeterministicTestSigner("vectors")
+	for _, vector := range vectors {
+		info := NewLastSignedInfo()
+		info.SetConflictStrategy(ConflictError)
+		if vector.Last != nil {
+			decision, _ := vector.Last.sign(t, info, signer)
+			require.Equal(t, "sign", decision, vector.Name)
+		}
+		decision, signBytes := vector.Next.sign(t, info, signer)
+		assert.Equal(t, vector.Decision, decision, vector.Name)
+		assert.Equal(t, vector.SignBytes, string(signBytes), vector.Name)
+	}
+}
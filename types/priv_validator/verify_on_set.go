@@ -0,0 +1,32 @@
+package types
+
+import (
+	"errors"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+var (
+	ErrBadSignature = errors.New("Signature does not verify")
+)
+
+// SetVerifyOnSet makes Set verify every signature against pubKey before
+// recording it, so a broken signer fails loudly right away instead of
+// on the next startup. On failure, Set returns ErrBadSignature and
+// nothing is recorded.
+// Passing an empty PubKey disables it, which is the default since
+// it adds a signature verification to every Set.
+func (info *LastSignedInfo) SetVerifyOnSet(pubKey crypto.PubKey) {
+	info.verifyOnSet = pubKey
+}
+
+// returns ErrBadSignature if VerifyOnSet is enabled and sig doesn't verify
+func (info *LastSignedInfo) verifySignature(signBytes []byte, sig crypto.Signature) error {
+	if info.verifyOnSet.Empty() {
+		return nil
+	}
+	if sig.Empty() || !info.verifyOnSet.VerifyBytes(signBytes, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+)
+
+// brokenSigner returns garbage signatures.
+type brokenSigner struct{}
+
+func (brokenSigner) Sign(msg []byte) (crypto.Signature, error) {
+	return crypto.SignatureEd25519{1}.Wrap(), nil
+}
+
+func TestVerifyOnSet(t *testing.T) {
+	assert := assert.New(t)
+
+	signer, pubKey := newTestSigner()
+	info := NewLastSignedInfo()
+	info.SetVerifyOnSet(pubKey)
+
+	vote := newVote(10, 0, types.VoteTypePrevote, blockID1)
+	assert.NoError(info.SignVote(signer, "mychainid", vote))
+
+	// a broken signer doesn't advance the high-water mark
+	vote = newVote(11, 0, types.VoteTypePrevote, blockID1)
+	assert.Equal(ErrBadSignature, info.SignVote(brokenSigner{}, "mychainid", vote))
+	assert.True(vote.Signature.Empty())
+	assert.Equal(int64(10), info.LastHeight)
+
+	assert.Equal(ErrBadSignature, info.Set(11, 0, stepPrevote, []byte("signbytes"), crypto.Signature{}))
+	assert.Equal(int64(10), info.LastHeight)
+
+	// disabled
+	info.SetVerifyOnSet(crypto.PubKey{})
+	assert.NoError(info.SignVote(brokenSigner{}, "mychainid", vote))
+	assert.Equal(int64(11), info.LastHeight)
+}
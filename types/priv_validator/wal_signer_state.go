@@ -0,0 +1,234 @@
+package types
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/tendermint/tmlibs/log"
+)
+
+// SignerWAL is the part of a write-ahead log that WALSignerState needs,
+// eg. the consensus WAL (see consensus.NewSignerWAL), so the advances of the
+// LastSignedInfo are interleaved with the consensus messages, or a FileSignerWAL.
+type SignerWAL interface {
+	// Append appends entry to the log, durably.
+	Append(entry []byte) error
+	// Entries calls fn with the entries appended, newest first, until it
+	// returns false or there are none left. A truncated or corrupt tail,
+	// eg. of a write cut short by a crash, is skipped.
+	Entries(fn func(entry []byte) bool) error
+}
+
+// defaultMaxScanEntries is how many entries WALSignerState.Load reads by default.
+const defaultMaxScanEntries = 1000
+
+// WALSignerState is a SignerState that appends each saved LastSignedInfo,
+// in JSON, as an entry of a SignerWAL, for operators who want a single
+// write-ahead log as the source of truth.
+//
+// Load scans the latest entries and takes the one with the highest
+// height/round/step (the newest of those at the same one), so entries that
+// can't be decoded are skipped. The scan is bounded, see SetMaxScanEntries.
+// So moving the state backwards, eg. with Reset, may not survive a restart,
+// which errs on the safe side.
+type WALSignerState struct {
+	wal            SignerWAL
+	maxScanEntries int
+	logger         log.Logger
+}
+
+// NewWALSignerState returns a WALSignerState for the wal.
+func NewWALSignerState(wal SignerWAL) *WALSignerState {
+	return &WALSignerState{wal, defaultMaxScanEntries, log.NewNopLogger()}
+}
+
+// SetLogger sets the logger Load logs the entries it skips to.
+// The LastSignedInfo loaded logs there too.
+func (ws *WALSignerState) SetLogger(logger log.Logger) {
+	ws.logger = logger
+}
+
+// SetMaxScanEntries sets how many of the latest entries Load reads, 1000 by
+// default. Every Set appends one, so it only needs to cover the entries that
+// may be unreadable at the tail.
+func (ws *WALSignerState) SetMaxScanEntries(maxEntries int) {
+	ws.maxScanEntries = maxEntries
+}
+
+// Load implements SignerState.
+// If nothing was saved yet, it returns a LastSignedInfo in its initial state.
+// The LastSignedInfo persists to the wal.
+func (ws *WALSignerState) Load() (*LastSignedInfo, error) {
+	info, err := ws.Read()
+	if err != nil {
+		return nil, err
+	}
+	info.SetSignerState(ws)
+	info.SetLogger(ws.logger)
+	return info, nil
+}
+
+// Read implements SignerStateReader.
+func (ws *WALSignerState) Read() (*LastSignedInfo, error) {
+	var best *LastSignedInfo
+	scanned := 0
+	err := ws.wal.Entries(func(entry []byte) bool {
+		scanned++
+		info, err := decodeBytes(JSONCodec{}, entry)
+		if err != nil {
+			ws.logger.Error("Skipping a LastSignedInfo in the WAL", "err", err)
+		} else if best == nil || compareHRS(info.LastHeight, info.LastRound, info.LastStep,
+			best.LastHeight, best.LastRound, best.LastStep) > 0 {
+			best = info
+		}
+		return scanned < ws.maxScanEntries
+	})
+	if err != nil {
+		return nil, err
+	}
+	if best == nil {
+		best = NewLastSignedInfo()
+	}
+	return best, nil
+}
+
+// Save implements SignerState.
+func (ws *WALSignerState) Save(info *LastSignedInfo) error {
+	infoBytes, err := encodeBytes(JSONCodec{}, info)
+	if err != nil {
+		return err
+	}
+	return ws.wal.Append(infoBytes)
+}
+
+//-------------------------------------
+
+// maxSignerWALEntrySize bounds the entries, so a bad length can't exhaust memory.
+const maxSignerWALEntrySize = 1 << 20
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// defaultMaxSignerWALSize is the size a FileSignerWAL is compacted at by default.
+const defaultMaxSignerWALSize = 1 << 20
+
+// FileSignerWAL is a SignerWAL in a file of its own, framed like the consensus
+// WAL: each entry is preceded by its CRC-32C and its length, as 4 bytes
+// big-endian each.
+//
+// Once appending an entry would make the file larger than its max size
+// (see SetMaxSize), the file is replaced by one with only that entry,
+// so reading it on startup is bounded.
+type FileSignerWAL struct {
+	filePath string
+	maxSize  int64
+
+	end   int64 // where the next entry goes, once known
+	ended bool
+}
+
+// NewFileSignerWAL returns a FileSignerWAL for the filePath.
+// The file is created on the first Append.
+func NewFileSignerWAL(filePath string) *FileSignerWAL {
+	return &FileSignerWAL{filePath: filePath, maxSize: defaultMaxSignerWALSize}
+}
+
+// SetMaxSize sets the size the file is compacted at, 1MB by default.
+func (fw *FileSignerWAL) SetMaxSize(maxSize int64) {
+	fw.maxSize = maxSize
+}
+
+// Append implements SignerWAL. The entry is synced to disk.
+// A truncated tail left by a crash is overwritten.
+func (fw *FileSignerWAL) Append(entry []byte) error {
+	if len(entry) > maxSignerWALEntrySize {
+		return errors.New("Entry is too large")
+	}
+	frame := make([]byte, 8+len(entry))
+	binary.BigEndian.PutUint32(frame[0:4], crc32.Checksum(entry, crc32c))
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(entry)))
+	copy(frame[8:], entry)
+
+	if !fw.ended {
+		_, end, err := fw.read()
+		if err != nil {
+			return err
+		}
+		fw.end, fw.ended = end, true
+	}
+	if fw.end > 0 && fw.end+int64(len(frame)) > fw.maxSize {
+		if err := writeFileAtomic(fw.filePath, frame); err != nil {
+			return err
+		}
+		fw.end = int64(len(frame))
+		return nil
+	}
+
+	file, err := os.OpenFile(fw.filePath, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(fw.end); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(frame, fw.end); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	fw.end += int64(len(frame))
+	return nil
+}
+
+// Entries implements SignerWAL.
+func (fw *FileSignerWAL) Entries(fn func(entry []byte) bool) error {
+	entries, _, err := fw.read()
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !fn(entries[i]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// read returns the entries in the file, oldest first, and the offset where
+// they end: the first frame that's truncated or corrupt, and anything after
+// it, is the tail a crash left behind.
+func (fw *FileSignerWAL) read() (entries [][]byte, end int64, err error) {
+	file, err := os.Open(fw.filePath)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return entries, end, nil
+		}
+		crc, length := binary.BigEndian.Uint32(header[0:4]), binary.BigEndian.Uint32(header[4:8])
+		if length > maxSignerWALEntrySize {
+			return entries, end, nil
+		}
+		entry := make([]byte, length)
+		if _, err := io.ReadFull(r, entry); err != nil {
+			return entries, end, nil
+		}
+		if crc32.Checksum(entry, crc32c) != crc {
+			return entries, end, nil
+		}
+		entries = append(entries, entry)
+		end += 8 + int64(length)
+	}
+}
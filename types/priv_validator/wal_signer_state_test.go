@@ -0,0 +1,119 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// memSignerWAL is a SignerWAL in memory.
+type memSignerWAL struct {
+	entries [][]byte
+}
+
+func (mw *memSignerWAL) Append(entry []byte) error {
+	mw.entries = append(mw.entries, entry)
+	return nil
+}
+
+func (mw *memSignerWAL) Entries(fn func(entry []byte) bool) error {
+	for i := len(mw.entries) - 1; i >= 0; i-- {
+		if !fn(mw.entries[i]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestWALSignerState(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	wal := &memSignerWAL{}
+	state := NewWALSignerState(wal)
+	info, err := state.Load()
+	require.Nil(err)
+	assert.Equal(stepNone, info.LastStep)
+
+	signer, _ := newTestSigner()
+	for height := int64(1); height <= 3; height++ {
+		require.Nil(info.SignVote(signer, "mychainid", newVote(height, 0, types.VoteTypePrevote, blockID1)))
+	}
+	assert.Len(wal.entries, 3)
+
+	// the highest is taken, not the newest, and what can't be decoded is skipped
+	lower := NewLastSignedInfo()
+	require.Nil(lower.SignVote(signer, "mychainid", newVote(2, 0, types.VoteTypePrecommit, blockID1)))
+	require.Nil(state.Save(lower))
+	wal.entries = append(wal.entries, []byte("garbage"))
+	loaded, err := state.Load()
+	require.Nil(err)
+	assert.EqualValues(3, loaded.LastHeight)
+	assert.Equal(info.LastSignBytes, loaded.LastSignBytes)
+
+	// the scan is bounded
+	state.SetMaxScanEntries(2)
+	loaded, err = state.Load()
+	require.Nil(err)
+	assert.EqualValues(2, loaded.LastHeight)
+}
+
+func TestFileSignerWALTruncatedTail(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("signer_wal_")
+	defer os.Remove(filePath)
+	state := NewWALSignerState(NewFileSignerWAL(filePath))
+	info, err := state.Load()
+	require.Nil(err)
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(1, 0, types.VoteTypePrevote, blockID1)))
+	require.Nil(info.SignVote(signer, "mychainid", newVote(2, 0, types.VoteTypePrevote, blockID1)))
+	good, err := ioutil.ReadFile(filePath)
+	require.Nil(err)
+
+	load := func() *LastSignedInfo {
+		loaded, err := NewWALSignerState(NewFileSignerWAL(filePath)).Load()
+		require.Nil(err)
+		return loaded
+	}
+	for name, tail := range map[string][]byte{
+		"partial header": good[:4],
+		"partial entry":  good[:len(good)/2+8],
+		"bad checksum":   append([]byte{0, 0, 0, 0}, good[4:len(good)/2]...),
+	} {
+		require.Nil(ioutil.WriteFile(filePath, append(append([]byte{}, good...), tail...), 0600))
+		assert.EqualValues(2, load().LastHeight, name)
+	}
+
+	// appending after a truncated tail overwrites it
+	loaded := load()
+	require.Nil(loaded.SignVote(signer, "mychainid", newVote(3, 0, types.VoteTypePrevote, blockID1)))
+	assert.EqualValues(3, load().LastHeight)
+}
+
+func TestFileSignerWALCompaction(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, filePath := cmn.Tempfile("signer_wal_")
+	defer os.Remove(filePath)
+	wal := NewFileSignerWAL(filePath)
+	wal.SetMaxSize(4096)
+	info, err := NewWALSignerState(wal).Load()
+	require.Nil(err)
+	signer, _ := newTestSigner()
+	for height := int64(1); height <= 50; height++ {
+		require.Nil(info.SignVote(signer, "mychainid", newVote(height, 0, types.VoteTypePrevote, blockID1)))
+		stat, err := os.Stat(filePath)
+		require.Nil(err)
+		assert.True(stat.Size() <= 4096)
+	}
+
+	loaded, err := NewWALSignerState(NewFileSignerWAL(filePath)).Load()
+	require.Nil(err)
+	assert.EqualValues(50, loaded.LastHeight)
+}
@@ -0,0 +1,80 @@
+package types
+
+import "errors"
+
+var (
+	ErrPendingSign = errors.New("Pending sign found, must Recover first")
+)
+
+// PendingSign marks a height/round/step that was about to be signed.
+type PendingSign struct {
+	Height int64 `json:"height"`
+	Round  int   `json:"round"`
+	Step   int8  `json:"step"`
+}
+
+// SetWriteAhead enables or disables write-ahead mode, which is off by default.
+//
+// In write-ahead mode, SignVote persists a PendingSign marker for the HRS
+// before signing, and persists the signature after, clearing the marker.
+// If we crash in between, the marker is still there on restart, meaning
+// we may have signed that HRS. Verify then fails with ErrPendingSign
+// until Recover is called.
+func (info *LastSignedInfo) SetWriteAhead(writeAhead bool) {
+	info.writeAhead = writeAhead
+}
+
+// Recover handles a PendingSign left by a crash mid-sign.
+// It treats the pending HRS as signed: the state is advanced to it without
+// a signature, so it can never be signed again (see EnsureAtLeast),
+// and the marker is cleared. The result is persisted if a filePath is set.
+// It returns false if there was no PendingSign.
+func (info *LastSignedInfo) Recover() (bool, error) {
+	pending := info.PendingSign
+	if pending == nil {
+		return false, nil
+	}
+
+	if info.compareToLast(pending.Height, pending.Round, pending.Step) > 0 {
+		info.LastHeight = pending.Height
+		info.LastRound = pending.Round
+		info.LastStep = pending.Step
+		info.LastSignature = Signature{}
+		info.LastSignBytes = nil
+		info.LastSignBytesHash = nil
+		info.LastExtension = nil
+		info.LastSignedByVersion = ""
+		info.Unsigned = false
+	}
+	info.PendingSign = nil
+	if err := info.persist(); err != nil {
+		info.PendingSign = pending
+		return false, err
+	}
+	return true, nil
+}
+
+// markPending persists the marker before signing, in write-ahead mode.
+// It's left in place if signing fails, as we can't know if it was signed.
+func (info *LastSignedInfo) markPending(height int64, round int, step int8) error {
+	if !info.writeAhead {
+		return nil
+	}
+	info.PendingSign = &PendingSign{height, round, step}
+	if err := info.persist(); err != nil {
+		// nothing was signed yet
+		info.PendingSign = nil
+		return err
+	}
+	return nil
+}
+
+// clearPending drops the marker when the signature was dropped,
+// as it won't ever be used.
+func (info *LastSignedInfo) clearPending() {
+	if info.PendingSign == nil {
+		return
+	}
+	info.PendingSign = nil
+	info.persist() // if this fails, the marker stays and must be Recovered
+}
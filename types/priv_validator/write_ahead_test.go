@@ -0,0 +1,114 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/tendermint/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// crashingSigner simulates a crash while signing,
+// optionally after the signature was produced.
+type crashingSigner struct {
+	signer    types.Signer
+	afterSign bool
+}
+
+func (cs crashingSigner) Sign(msg []byte) (crypto.Signature, error) {
+	if cs.afterSign {
+		cs.signer.Sign(msg)
+	}
+	panic("crash")
+}
+
+func signVoteAndCrash(info *LastSignedInfo, signer types.Signer, vote *types.Vote) (crashed bool) {
+	defer func() {
+		crashed = recover() != nil
+	}()
+	info.SignVote(signer, "mychainid", vote)
+	return false
+}
+
+func TestWriteAheadCrashRecovery(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	signer, _ := newTestSigner()
+	for _, afterSign := range []bool{false, true} {
+		_, tempFilePath := cmn.Tempfile("sign_info_")
+		info := NewLastSignedInfo()
+		info.SetFilePath(tempFilePath)
+		info.SetWriteAhead(true)
+		require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+
+		// crash between the two phases
+		vote := newVote(10, 0, types.VoteTypePrecommit, blockID1)
+		require.True(signVoteAndCrash(info, crashingSigner{signer, afterSign}, vote))
+
+		// restart
+		info, err := LoadLastSignedInfo(tempFilePath)
+		require.Nil(err)
+		assert.Equal(&PendingSign{10, 0, stepPrecommit}, info.PendingSign)
+		assert.Equal(stepPrevote, info.LastStep)
+
+		// nothing can be signed until recovered
+		_, err = info.Verify(11, 0, stepPrevote)
+		assert.Equal(ErrPendingSign, err)
+		assert.Equal(ErrPendingSign, info.SignVote(signer, "mychainid", vote))
+
+		recovered, err := info.Recover()
+		require.Nil(err)
+		assert.True(recovered)
+		assert.Nil(info.PendingSign)
+
+		// the in-flight HRS is treated as signed
+		assert.Error(info.SignVote(signer, "mychainid", vote))
+		assert.NoError(info.SignVote(signer, "mychainid", newVote(11, 0, types.VoteTypePrevote, blockID1)))
+
+		// the recovery was persisted
+		loaded, err := LoadLastSignedInfo(tempFilePath)
+		require.Nil(err)
+		assert.Nil(loaded.PendingSign)
+		assert.Equal(int64(11), loaded.LastHeight)
+
+		recovered, err = loaded.Recover()
+		assert.Nil(err)
+		assert.False(recovered)
+	}
+}
+
+func TestWriteAheadCrashBeforeMarker(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// without write-ahead, a crash mid-sign leaves no trace
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	require.True(signVoteAndCrash(info, crashingSigner{signer, true}, newVote(10, 0, types.VoteTypePrecommit, blockID1)))
+
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Nil(loaded.PendingSign)
+	assert.Equal(stepPrevote, loaded.LastStep)
+}
+
+func TestWriteAheadClearsMarker(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	_, tempFilePath := cmn.Tempfile("sign_info_")
+	info := NewLastSignedInfo()
+	info.SetFilePath(tempFilePath)
+	info.SetWriteAhead(true)
+	signer, _ := newTestSigner()
+	require.Nil(info.SignVote(signer, "mychainid", newVote(10, 0, types.VoteTypePrevote, blockID1)))
+	assert.Nil(info.PendingSign)
+
+	loaded, err := LoadLastSignedInfo(tempFilePath)
+	require.Nil(err)
+	assert.Nil(loaded.PendingSign)
+	assert.Equal(info.LastSignBytes, loaded.LastSignBytes)
+}